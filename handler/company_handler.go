@@ -15,6 +15,7 @@ type CompanyService interface {
 	CreateCompany(ctx context.Context, company *model.Company) (*model.Company, error)
 	FindCompanyById(ctx context.Context, id string) (*model.Company, error)
 	UpdateCompany(ctx context.Context, companyId string, update *model.Company) error
+	UpdateCompanyPlan(ctx context.Context, userId string, companyId string, plan *model.Company) error
 }
 
 type CompanyHandler struct {
@@ -103,3 +104,40 @@ func (h *CompanyHandler) PutCompany(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+// PutCompanyPlan PUT /company/:id/plan
+// Admin: sets a company's plan and application fee overrides, for the platform admin in the X-User-Id header.
+// Request BODY: { "plan": "standard" | "pro", "applicationFeePercent": 2.5, "applicationFeeFixed": 30 }
+// Response: 204 No Content | 400 error text | 401 without X-User-Id | 403 for other users | 404 not found
+func (h *CompanyHandler) PutCompanyPlan(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	var body model.Company
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.companyService.UpdateCompanyPlan(c.Request.Context(), c.GetHeader("X-User-Id"), id, &body); err != nil {
+		log.Println(err.Error())
+		switch {
+		case strings.Contains(err.Error(), "user id is required"):
+			c.String(http.StatusUnauthorized, err.Error())
+		case strings.Contains(err.Error(), "not allowed"):
+			c.String(http.StatusForbidden, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			c.String(http.StatusNotFound, "company not found")
+		case strings.Contains(err.Error(), "plan must be") || strings.Contains(err.Error(), "application fee"):
+			c.String(http.StatusBadRequest, err.Error())
+		default:
+			c.String(http.StatusBadRequest, "something went wrong")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
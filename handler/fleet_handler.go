@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"tow-management-system-api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FleetService defines the contract for truck, inspection and maintenance operations.
+type FleetService interface {
+	CreateTruck(ctx context.Context, companyId string, truck *model.Truck) (*model.Truck, error)
+	FindTrucksByCompanyId(ctx context.Context, companyId string) ([]*model.Truck, error)
+	FindTruckById(ctx context.Context, truckId string) (*model.Truck, error)
+	UpdateTruck(ctx context.Context, truckId string, update *model.Truck) error
+	FindInspectionChecklist(ctx context.Context, companyId string) ([]string, error)
+	SetInspectionChecklist(ctx context.Context, companyId string, items []string) error
+	SubmitInspection(ctx context.Context, truckId string, inspection *model.Inspection) (*model.Inspection, error)
+	FindInspectionsByTruckId(ctx context.Context, truckId string) ([]*model.Inspection, error)
+	CreateWorkOrder(ctx context.Context, truckId string, workOrder *model.WorkOrder) (*model.WorkOrder, error)
+	FindWorkOrdersByTruckId(ctx context.Context, truckId string) ([]*model.WorkOrder, error)
+	CompleteWorkOrder(ctx context.Context, workOrderId string, odometer *int, notes string) (*model.WorkOrder, error)
+	FindMaintenanceDue(ctx context.Context, companyId string) ([]*model.MaintenanceDue, error)
+}
+
+// FleetHandler handles HTTP routes for the truck registry, DVIRs and maintenance.
+type FleetHandler struct {
+	fleetService FleetService
+}
+
+// NewFleetHandler creates a new FleetHandler instance.
+func NewFleetHandler(service FleetService) *FleetHandler {
+	return &FleetHandler{fleetService: service}
+}
+
+// PostTruck POST /fleet/trucks/company/:companyId
+// Request: Truck payload in JSON body
+// Response: 201 Truck | 400 generic error text
+func (h *FleetHandler) PostTruck(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	var body model.Truck
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	truck, err := h.fleetService.CreateTruck(c.Request.Context(), companyId, &body)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusCreated, truck)
+}
+
+// GetTrucks GET /fleet/trucks/company/:companyId
+// Response: 200 [Truck] | 400/500 generic error text
+func (h *FleetHandler) GetTrucks(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	trucks, err := h.fleetService.FindTrucksByCompanyId(c.Request.Context(), companyId)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, trucks)
+}
+
+// GetTruck GET /fleet/trucks/:truckId
+// Response: 200 Truck | 400/404 generic error text
+func (h *FleetHandler) GetTruck(c *gin.Context) {
+	truckId := c.Param("truckId")
+	if truckId == "" {
+		c.String(http.StatusBadRequest, "truck id is required")
+		return
+	}
+
+	truck, err := h.fleetService.FindTruckById(c.Request.Context(), truckId)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "truck not found")
+			return
+		}
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, truck)
+}
+
+// PutTruck PUT /fleet/trucks/:truckId
+// Request: partial Truck fields in JSON body
+// Response: 204 | 400/404 generic error text
+func (h *FleetHandler) PutTruck(c *gin.Context) {
+	truckId := c.Param("truckId")
+	if truckId == "" {
+		c.String(http.StatusBadRequest, "truck id is required")
+		return
+	}
+
+	var body model.Truck
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.fleetService.UpdateTruck(c.Request.Context(), truckId, &body); err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "truck not found")
+			return
+		}
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetInspectionChecklist GET /fleet/checklist/company/:companyId
+// Response: 200 { "items": [...] } | 400/404 generic error text
+func (h *FleetHandler) GetInspectionChecklist(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	items, err := h.fleetService.FindInspectionChecklist(c.Request.Context(), companyId)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "company not found")
+			return
+		}
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// PutInspectionChecklist PUT /fleet/checklist/company/:companyId
+// Request Body: { "items": ["Service Brakes", ...] }
+// Response: 204 | 400 generic error text
+func (h *FleetHandler) PutInspectionChecklist(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	var body struct {
+		Items []string `json:"items" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.fleetService.SetInspectionChecklist(c.Request.Context(), companyId, body.Items); err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PostInspection POST /fleet/trucks/:truckId/inspections
+// Request: Inspection payload in JSON body
+// Response: 201 Inspection | 400/404 generic error text
+func (h *FleetHandler) PostInspection(c *gin.Context) {
+	truckId := c.Param("truckId")
+	if truckId == "" {
+		c.String(http.StatusBadRequest, "truck id is required")
+		return
+	}
+
+	var body model.Inspection
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	inspection, err := h.fleetService.SubmitInspection(c.Request.Context(), truckId, &body)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "truck not found") {
+			c.String(http.StatusNotFound, "truck not found")
+			return
+		}
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusCreated, inspection)
+}
+
+// GetInspections GET /fleet/trucks/:truckId/inspections
+// Response: 200 [Inspection] | 400/500 generic error text
+func (h *FleetHandler) GetInspections(c *gin.Context) {
+	truckId := c.Param("truckId")
+	if truckId == "" {
+		c.String(http.StatusBadRequest, "truck id is required")
+		return
+	}
+
+	inspections, err := h.fleetService.FindInspectionsByTruckId(c.Request.Context(), truckId)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, inspections)
+}
+
+// PostWorkOrder POST /fleet/trucks/:truckId/work-orders
+// Request: WorkOrder payload in JSON body
+// Response: 201 WorkOrder | 400/404 generic error text
+func (h *FleetHandler) PostWorkOrder(c *gin.Context) {
+	truckId := c.Param("truckId")
+	if truckId == "" {
+		c.String(http.StatusBadRequest, "truck id is required")
+		return
+	}
+
+	var body model.WorkOrder
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	workOrder, err := h.fleetService.CreateWorkOrder(c.Request.Context(), truckId, &body)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "truck not found") {
+			c.String(http.StatusNotFound, "truck not found")
+			return
+		}
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusCreated, workOrder)
+}
+
+// GetWorkOrders GET /fleet/trucks/:truckId/work-orders
+// Response: 200 [WorkOrder] | 400/500 generic error text
+func (h *FleetHandler) GetWorkOrders(c *gin.Context) {
+	truckId := c.Param("truckId")
+	if truckId == "" {
+		c.String(http.StatusBadRequest, "truck id is required")
+		return
+	}
+
+	workOrders, err := h.fleetService.FindWorkOrdersByTruckId(c.Request.Context(), truckId)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, workOrders)
+}
+
+// PostCompleteWorkOrder POST /fleet/work-orders/:workOrderId/complete
+// Request Body: { "odometer": 123456, "notes": "..." } (both optional)
+// Response: 200 WorkOrder | 400/404 generic error text
+func (h *FleetHandler) PostCompleteWorkOrder(c *gin.Context) {
+	workOrderId := c.Param("workOrderId")
+	if workOrderId == "" {
+		c.String(http.StatusBadRequest, "work order id is required")
+		return
+	}
+
+	var body struct {
+		Odometer *int   `json:"odometer"`
+		Notes    string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	workOrder, err := h.fleetService.CompleteWorkOrder(c.Request.Context(), workOrderId, body.Odometer, body.Notes)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "work order not found")
+			return
+		}
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, workOrder)
+}
+
+// GetMaintenanceDue GET /fleet/maintenance/company/:companyId
+// Response: 200 [MaintenanceDue] | 400/500 generic error text
+func (h *FleetHandler) GetMaintenanceDue(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	due, err := h.fleetService.FindMaintenanceDue(c.Request.Context(), companyId)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, due)
+}
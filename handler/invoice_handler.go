@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"tow-management-system-api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvoiceService defines the contract for rendering tow invoices.
+type InvoiceService interface {
+	GetInvoice(ctx context.Context, towId, userId string) (*model.Invoice, error)
+	GetPublicInvoice(ctx context.Context, towId, accessToken string) (*model.Invoice, error)
+	GetDisputeEvidence(ctx context.Context, towId, userId string) (*model.DisputeEvidence, error)
+}
+
+// InvoiceHandler handles HTTP routes for tow invoices.
+type InvoiceHandler struct {
+	invoiceService InvoiceService
+}
+
+// NewInvoiceHandler creates a new InvoiceHandler instance.
+func NewInvoiceHandler(service InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{invoiceService: service}
+}
+
+// GetInvoice GET /invoices/tows/:towId
+// Downloads a tow's PDF invoice for a user of the company that ran the tow, identified by the X-User-Id header.
+// The first download assigns the company's next invoice number.
+// Response: 200 application/pdf attachment | 400 error text | 401 without X-User-Id | 403 for users of other
+// companies | 404 not found
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	towId := c.Param("towId")
+	if towId == "" {
+		c.String(http.StatusBadRequest, "tow id is required")
+		return
+	}
+
+	invoice, err := h.invoiceService.GetInvoice(c.Request.Context(), towId, c.GetHeader("X-User-Id"))
+	if err != nil {
+		log.Println(err.Error())
+		switch {
+		case strings.Contains(err.Error(), "user id is required"):
+			c.String(http.StatusUnauthorized, err.Error())
+		case strings.Contains(err.Error(), "not allowed"):
+			c.String(http.StatusForbidden, err.Error())
+		default:
+			writeInvoiceError(c, err)
+		}
+		return
+	}
+
+	writeInvoice(c, invoice)
+}
+
+// GetPublicInvoice GET /payments/tows/:towId/invoice?token=...
+// Downloads a tow's PDF invoice for the customer, using the access token from their tow link.
+// Response: 200 application/pdf attachment | 400 error text | 404 not found or wrong token
+func (h *InvoiceHandler) GetPublicInvoice(c *gin.Context) {
+	towId := c.Param("towId")
+	token := c.Query("token")
+	if towId == "" || token == "" {
+		c.String(http.StatusBadRequest, "tow id and token are required")
+		return
+	}
+
+	invoice, err := h.invoiceService.GetPublicInvoice(c.Request.Context(), towId, token)
+	if err != nil {
+		log.Println(err.Error())
+		writeInvoiceError(c, err)
+		return
+	}
+
+	writeInvoice(c, invoice)
+}
+
+// GetDisputeEvidence GET /disputes/tows/:towId/evidence
+// Assembles the evidence for answering a disputed tow payment: the dispute, customer, vehicle and trip, a dated
+// timeline, attachments and the invoice PDF (base64). For users of the company that ran the tow (X-User-Id).
+// Response: 200 DisputeEvidence | 400 error text | 401 without X-User-Id | 403 for users of other companies |
+// 404 not found | 409 when the tow has no dispute
+func (h *InvoiceHandler) GetDisputeEvidence(c *gin.Context) {
+	towId := c.Param("towId")
+	if towId == "" {
+		c.String(http.StatusBadRequest, "tow id is required")
+		return
+	}
+
+	evidence, err := h.invoiceService.GetDisputeEvidence(c.Request.Context(), towId, c.GetHeader("X-User-Id"))
+	if err != nil {
+		log.Println(err.Error())
+		switch {
+		case strings.Contains(err.Error(), "user id is required"):
+			c.String(http.StatusUnauthorized, err.Error())
+		case strings.Contains(err.Error(), "not allowed"):
+			c.String(http.StatusForbidden, err.Error())
+		case strings.Contains(err.Error(), "no dispute"):
+			c.String(http.StatusConflict, err.Error())
+		default:
+			writeInvoiceError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, evidence)
+}
+
+// writeInvoice sends an invoice as a PDF download.
+func writeInvoice(c *gin.Context, invoice *model.Invoice) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", invoice.FileName))
+	c.Data(http.StatusOK, "application/pdf", invoice.PDF)
+}
+
+// writeInvoiceError maps invoice errors to a response.
+func writeInvoiceError(c *gin.Context, err error) {
+	switch {
+	case strings.Contains(err.Error(), "tow not found"):
+		c.String(http.StatusNotFound, "tow not found")
+	case strings.Contains(err.Error(), "no price to invoice"):
+		c.String(http.StatusBadRequest, err.Error())
+	default:
+		c.String(http.StatusInternalServerError, "something went wrong")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"tow-management-system-api/model"
 
 	"github.com/gin-gonic/gin"
@@ -11,7 +13,7 @@ import (
 
 // MetricService defines the minimal contract for computing metrics.
 type MetricService interface {
-	CalculateMetrics(ctx context.Context, companyId string) ([]*model.Metric, error)
+	CalculateMetrics(ctx context.Context, companyId string, from, to int64) ([]*model.Metric, error)
 }
 
 // MetricHandler handles HTTP routes for metric-related operations.
@@ -26,13 +28,14 @@ func NewMetricHandler(service MetricService) *MetricHandler {
 	}
 }
 
-// GetCompanyMetrics GET /metrics/:companyId
-// Retrieves computed metrics (e.g., Active Tows, Completed Tows) for a given company.
+// GetCompanyMetrics GET /metrics/:companyId?from=...&to=...
+// Retrieves computed metrics (e.g., Active Tows, Completed Tows, Payout Amount) for a given company. The optional
+// from and to (unix seconds) limit the payout amount to payments and refunds in that period.
 //
 // Response:
 //
 //	200 [Metric] - JSON array of metrics
-//	400 - "company id is required"
+//	400 - "company id is required" or an invalid period
 //	500 - "something went wrong"
 func (h *MetricHandler) GetCompanyMetrics(c *gin.Context) {
 	companyId := c.Param("companyId")
@@ -41,12 +44,40 @@ func (h *MetricHandler) GetCompanyMetrics(c *gin.Context) {
 		return
 	}
 
-	metrics, err := h.metricService.CalculateMetrics(c.Request.Context(), companyId)
+	from, to, ok := periodQuery(c)
+	if !ok {
+		return
+	}
+
+	metrics, err := h.metricService.CalculateMetrics(c.Request.Context(), companyId, from, to)
 	if err != nil {
 		log.Println(err.Error())
+		if strings.Contains(err.Error(), "from must be") {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
 		c.String(http.StatusInternalServerError, "something went wrong")
 		return
 	}
 
 	c.JSON(http.StatusOK, metrics)
 }
+
+// periodQuery parses the optional from and to query parameters, in unix seconds, of a reporting period. It
+// writes a 400 response and returns false when either is not a number.
+func periodQuery(c *gin.Context) (int64, int64, bool) {
+	var period [2]int64
+	for i, key := range []string{"from", "to"} {
+		raw := c.Query(key)
+		if raw == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.String(http.StatusBadRequest, key+" must be unix seconds")
+			return 0, 0, false
+		}
+		period[i] = parsed
+	}
+	return period[0], period[1], true
+}
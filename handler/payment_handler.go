@@ -2,9 +2,11 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
+	"tow-management-system-api/model"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stripe/stripe-go/v83"
@@ -14,6 +16,12 @@ import (
 type PaymentService interface {
 	RetrievePaymentAccount(ctx context.Context, companyId string) (*stripe.Account, error)
 	GenerateDashboardLink(ctx context.Context, companyId, returnURL, refreshURL string) (string, error)
+	GetTowPaymentOutcome(ctx context.Context, towId, accessToken string) (*model.TowPaymentOutcome, error)
+	RefundTow(ctx context.Context, towId string, amount int, reason, issuedBy string) (*model.Tow, error)
+	RecordPayment(ctx context.Context, towId string, payment *model.Payment, collectedBy string) (*model.Tow, error)
+	VoidPayment(ctx context.Context, towId, paymentId, voidedBy string) (*model.Tow, error)
+	GetPaymentSummary(ctx context.Context, companyId string, from, to int64) (*model.PaymentSummary, error)
+	FindPayouts(ctx context.Context, companyId string, from, to int64) ([]*model.Payout, error)
 }
 
 // PaymentHandler handles payment-related HTTP endpoints.
@@ -84,3 +92,240 @@ func (h *PaymentHandler) PostPaymentAccount(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"url": url})
 }
+
+// GetTowPaymentOutcome GET /payments/tows/:towId?token=...
+// Called by the checkout return page with the tow ID and token from its URL to show the verified payment outcome.
+// Response: 200 TowPaymentOutcome | 400 invalid request | 404 not found (unknown tow or wrong token)
+func (h *PaymentHandler) GetTowPaymentOutcome(c *gin.Context) {
+	towId := c.Param("towId")
+	token := c.Query("token")
+	if towId == "" || token == "" {
+		c.String(http.StatusBadRequest, "tow id and token are required")
+		return
+	}
+
+	outcome, err := h.paymentService.GetTowPaymentOutcome(c.Request.Context(), towId, token)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "tow not found")
+			return
+		}
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, outcome)
+}
+
+// PostTowRefund POST /payments/tows/:towId/refunds
+// Refunds a paid tow through Stripe. Omit "amount" to refund everything not yet refunded. The X-User-Id header
+// identifies the company user who issues the refund.
+// Request Body: { "amount": int (cents, optional), "reason": "..." }
+// Response: 200 Tow (with refunds and paymentStatus refunded or partially_refunded) | 400 error text |
+// 401 without a user | 403 for users of another company | 404 not found
+func (h *PaymentHandler) PostTowRefund(c *gin.Context) {
+	towId := c.Param("towId")
+	if towId == "" {
+		c.String(http.StatusBadRequest, "tow id is required")
+		return
+	}
+
+	var body struct {
+		Amount int    `json:"amount"`
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.String(http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+	}
+
+	tow, err := h.paymentService.RefundTow(c.Request.Context(), towId, body.Amount, body.Reason, c.GetHeader("X-User-Id"))
+	if err != nil {
+		log.Println(err.Error())
+		switch {
+		case strings.Contains(err.Error(), "user id is required"):
+			c.String(http.StatusUnauthorized, err.Error())
+		case strings.Contains(err.Error(), "not allowed"):
+			c.String(http.StatusForbidden, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			c.String(http.StatusNotFound, "tow not found")
+		case isRefundValidationError(err):
+			c.String(http.StatusBadRequest, err.Error())
+		default:
+			c.String(http.StatusBadRequest, "something went wrong")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tow)
+}
+
+// PostTowPayment POST /payments/tows/:towId/payments
+// Records an offline payment (cash, check, card, motor_club, insurance or other) in the tow's payment ledger. The
+// X-User-Id header identifies the company user who collected it.
+// Request Body: { "method": "cash", "amount": int (cents), "reference": "check or PO number", "note": "...",
+// "collectedAt": unix seconds (optional, defaults to now) }
+// Response: 200 Tow (with payments, paidAmount, balanceDue and paymentStatus) | 400 error text | 401 without a user |
+// 403 for users of another company | 404 not found | 409 when the reference was already recorded
+func (h *PaymentHandler) PostTowPayment(c *gin.Context) {
+	towId := c.Param("towId")
+	if towId == "" {
+		c.String(http.StatusBadRequest, "tow id is required")
+		return
+	}
+
+	var body model.Payment
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	tow, err := h.paymentService.RecordPayment(c.Request.Context(), towId, &body, c.GetHeader("X-User-Id"))
+	if err != nil {
+		log.Println(err.Error())
+		switch {
+		case strings.Contains(err.Error(), "user id is required"):
+			c.String(http.StatusUnauthorized, err.Error())
+		case strings.Contains(err.Error(), "not allowed"):
+			c.String(http.StatusForbidden, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			c.String(http.StatusNotFound, "tow not found")
+		case strings.Contains(err.Error(), "already recorded"):
+			c.String(http.StatusConflict, err.Error())
+		case isLedgerValidationError(err):
+			c.String(http.StatusBadRequest, err.Error())
+		default:
+			c.String(http.StatusBadRequest, "something went wrong")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tow)
+}
+
+// PostVoidTowPayment POST /payments/tows/:towId/payments/:paymentId/void
+// Voids a mistaken offline payment so it no longer counts toward the balance; the entry stays in the ledger. The
+// X-User-Id header identifies the company user who voided it. Stripe payments are refunded instead.
+// Response: 200 Tow | 400 error text | 401 without a user | 403 for users of another company | 404 not found |
+// 409 when the payment was already voided
+func (h *PaymentHandler) PostVoidTowPayment(c *gin.Context) {
+	towId := c.Param("towId")
+	paymentId := c.Param("paymentId")
+	if towId == "" || paymentId == "" {
+		c.String(http.StatusBadRequest, "tow id and payment id are required")
+		return
+	}
+
+	tow, err := h.paymentService.VoidPayment(c.Request.Context(), towId, paymentId, c.GetHeader("X-User-Id"))
+	if err != nil {
+		log.Println(err.Error())
+		switch {
+		case strings.Contains(err.Error(), "user id is required"):
+			c.String(http.StatusUnauthorized, err.Error())
+		case strings.Contains(err.Error(), "not allowed"):
+			c.String(http.StatusForbidden, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			c.String(http.StatusNotFound, err.Error())
+		case strings.Contains(err.Error(), "already voided"):
+			c.String(http.StatusConflict, err.Error())
+		case isLedgerValidationError(err):
+			c.String(http.StatusBadRequest, err.Error())
+		default:
+			c.String(http.StatusBadRequest, "something went wrong")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tow)
+}
+
+// GetPaymentSummary GET /payments/summary/company/:companyId?from=...&to=...
+// Gross collected, platform fees, refunds and net for the company's payments in the optional period (unix
+// seconds, to exclusive), with its current Stripe balance and the payouts made in the period.
+// Response: 200 PaymentSummary | 400 invalid request | 404 not found
+func (h *PaymentHandler) GetPaymentSummary(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+	from, to, ok := periodQuery(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.paymentService.GetPaymentSummary(c.Request.Context(), companyId, from, to)
+	if err != nil {
+		log.Println(err.Error())
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			c.String(http.StatusNotFound, "company not found")
+		case strings.Contains(err.Error(), "from must be"):
+			c.String(http.StatusBadRequest, err.Error())
+		default:
+			c.String(http.StatusBadRequest, "something went wrong")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetPayouts GET /payments/payouts/company/:companyId?from=...&to=...
+// Lists the Stripe payouts to the company's bank created in the optional period (unix seconds, to exclusive),
+// newest first, with the tows each payout covered.
+// Response: 200 [Payout] | 400 invalid request | 404 not found
+func (h *PaymentHandler) GetPayouts(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+	from, to, ok := periodQuery(c)
+	if !ok {
+		return
+	}
+
+	payouts, err := h.paymentService.FindPayouts(c.Request.Context(), companyId, from, to)
+	if err != nil {
+		log.Println(err.Error())
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			c.String(http.StatusNotFound, "company not found")
+		case strings.Contains(err.Error(), "from must be"), strings.Contains(err.Error(), "stripe account"):
+			c.String(http.StatusBadRequest, err.Error())
+		default:
+			c.String(http.StatusBadRequest, "something went wrong")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, payouts)
+}
+
+// isLedgerValidationError reports whether err is the reason a payment could not be recorded or voided because of
+// the request itself. Wrapped errors come from storage and are not passed on to the caller.
+func isLedgerValidationError(err error) bool {
+	if errors.Unwrap(err) != nil {
+		return false
+	}
+	message := err.Error()
+	return strings.HasPrefix(message, "payment ") ||
+		strings.HasPrefix(message, "unknown payment method") ||
+		strings.HasPrefix(message, "stripe payments ")
+}
+
+// isRefundValidationError reports whether err is the reason a tow could not be refunded because of the request
+// itself. Wrapped errors come from storage or Stripe and are not passed on to the caller.
+func isRefundValidationError(err error) bool {
+	if errors.Unwrap(err) != nil {
+		return false
+	}
+	message := err.Error()
+	return strings.HasPrefix(message, "refund amount ") ||
+		strings.HasPrefix(message, "only paid tows ") ||
+		strings.HasPrefix(message, "tow has no payment ")
+}
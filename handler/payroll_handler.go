@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"tow-management-system-api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PayrollService defines the contract for driver commission and payroll operations.
+type PayrollService interface {
+	FindCommissionRules(ctx context.Context, companyId string) ([]*model.CommissionRule, error)
+	SetCommissionRules(ctx context.Context, companyId string, rules []*model.CommissionRule) error
+	GeneratePayroll(ctx context.Context, companyId string, periodStart, periodEnd int64) (*model.PayrollRun, error)
+	FindPayrollRunsByCompanyId(ctx context.Context, companyId string) ([]*model.PayrollRun, error)
+	FindPayrollRunById(ctx context.Context, companyId string, payrollId string) (*model.PayrollRun, error)
+	AdjustPayrollEntry(ctx context.Context, companyId string, payrollId string, driverId string, adjustment int, note string) (*model.PayrollRun, error)
+	ApprovePayrollRun(ctx context.Context, companyId string, payrollId string, approvedBy string) (*model.PayrollRun, error)
+	ExportPayrollCSV(ctx context.Context, companyId string, payrollId string) ([]byte, error)
+}
+
+// PayrollHandler handles HTTP routes for driver commission and payroll operations.
+type PayrollHandler struct {
+	payrollService PayrollService
+}
+
+// NewPayrollHandler creates a new PayrollHandler instance.
+func NewPayrollHandler(service PayrollService) *PayrollHandler {
+	return &PayrollHandler{payrollService: service}
+}
+
+// GetCommissionRules GET /payroll/rules/company/:companyId
+// Response: 200 [CommissionRule] | 400/500 generic error text
+func (h *PayrollHandler) GetCommissionRules(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	rules, err := h.payrollService.FindCommissionRules(c.Request.Context(), companyId)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// PutCommissionRules PUT /payroll/rules/company/:companyId
+// Request: [CommissionRule]
+// Response: 204 | 400 generic error text
+func (h *PayrollHandler) PutCommissionRules(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	var body []*model.CommissionRule
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.payrollService.SetCommissionRules(c.Request.Context(), companyId, body); err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PostPayrollRun POST /payroll/company/:companyId
+// Request Body: { "periodStart": unix seconds, "periodEnd": unix seconds }
+// Response: 201 PayrollRun | 400 generic error text
+func (h *PayrollHandler) PostPayrollRun(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	var body struct {
+		PeriodStart int64 `json:"periodStart" binding:"required"`
+		PeriodEnd   int64 `json:"periodEnd" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	run, err := h.payrollService.GeneratePayroll(c.Request.Context(), companyId, body.PeriodStart, body.PeriodEnd)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusCreated, run)
+}
+
+// GetPayrollRuns GET /payroll/company/:companyId
+// Response: 200 [PayrollRun] | 400/500 generic error text
+func (h *PayrollHandler) GetPayrollRuns(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	runs, err := h.payrollService.FindPayrollRunsByCompanyId(c.Request.Context(), companyId)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// GetPayrollRun GET /payroll/company/:companyId/:payrollId
+// Response: 200 PayrollRun | 400/404 generic error text
+func (h *PayrollHandler) GetPayrollRun(c *gin.Context) {
+	companyId := c.Param("companyId")
+	payrollId := c.Param("payrollId")
+	if companyId == "" || payrollId == "" {
+		c.String(http.StatusBadRequest, "company id and payroll id are required")
+		return
+	}
+
+	run, err := h.payrollService.FindPayrollRunById(c.Request.Context(), companyId, payrollId)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "payroll run not found")
+			return
+		}
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// PutPayrollEntry PUT /payroll/company/:companyId/:payrollId/entries/:driverId
+// Request Body: { "adjustment": cents (may be negative), "note": "..." }
+// Response: 200 PayrollRun | 400/404 generic error text
+func (h *PayrollHandler) PutPayrollEntry(c *gin.Context) {
+	companyId := c.Param("companyId")
+	payrollId := c.Param("payrollId")
+	driverId := c.Param("driverId")
+	if companyId == "" || payrollId == "" || driverId == "" {
+		c.String(http.StatusBadRequest, "company id, payroll id and driver id are required")
+		return
+	}
+
+	var body struct {
+		Adjustment int    `json:"adjustment"`
+		Note       string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	run, err := h.payrollService.AdjustPayrollEntry(c.Request.Context(), companyId, payrollId, driverId, body.Adjustment, body.Note)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "payroll entry not found")
+			return
+		}
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// PostApprovePayrollRun POST /payroll/company/:companyId/:payrollId/approve
+// Response: 200 PayrollRun | 400/404 generic error text
+func (h *PayrollHandler) PostApprovePayrollRun(c *gin.Context) {
+	companyId := c.Param("companyId")
+	payrollId := c.Param("payrollId")
+	if companyId == "" || payrollId == "" {
+		c.String(http.StatusBadRequest, "company id and payroll id are required")
+		return
+	}
+
+	run, err := h.payrollService.ApprovePayrollRun(c.Request.Context(), companyId, payrollId, c.GetHeader("X-User-Id"))
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "payroll run not found")
+			return
+		}
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// GetPayrollExport GET /payroll/company/:companyId/:payrollId/export
+// Response: 200 text/csv attachment | 400/404 generic error text
+func (h *PayrollHandler) GetPayrollExport(c *gin.Context) {
+	companyId := c.Param("companyId")
+	payrollId := c.Param("payrollId")
+	if companyId == "" || payrollId == "" {
+		c.String(http.StatusBadRequest, "company id and payroll id are required")
+		return
+	}
+
+	data, err := h.payrollService.ExportPayrollCSV(c.Request.Context(), companyId, payrollId)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "payroll run not found")
+			return
+		}
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"payroll-%s.csv\"", payrollId))
+	c.Data(http.StatusOK, "text/csv", data)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"strings"
 	"tow-management-system-api/model"
 
 	"github.com/gin-gonic/gin"
@@ -11,8 +12,20 @@ import (
 
 // PriceService defines the contract for Price-related business logic.
 type PriceService interface {
-	FindPricesByCompanyId(ctx context.Context, companyId string) ([]*model.Price, error)
-	SetPrice(ctx context.Context, prices []*model.Price) error
+	FindPricesByCompanyId(ctx context.Context, companyId string, includeArchived bool) ([]*model.Price, error)
+	SetPrice(ctx context.Context, companyId string, prices []*model.Price) error
+	SetLegacyPrices(ctx context.Context, prices []*model.Price) error
+	DeletePrice(ctx context.Context, companyId string, priceId string) (bool, error)
+	CreatePriceListDraft(ctx context.Context, companyId string, prices []*model.Price, notes *string) (*model.PriceList, error)
+	UpdatePriceListDraft(ctx context.Context, companyId string, priceListId string, prices []*model.Price, notes *string) (*model.PriceList, error)
+	PublishPriceList(ctx context.Context, companyId string, priceListId string, effectiveFrom int64, publishedBy string) (*model.PriceList, error)
+	FindPriceListsByCompanyId(ctx context.Context, companyId string) ([]*model.PriceList, error)
+	FindPriceListById(ctx context.Context, companyId string, priceListId string) (*model.PriceList, error)
+	SimulatePriceList(ctx context.Context, companyId string, priceListId string, prices []*model.Price, from, to int64) (*model.PriceSimulation, error)
+	CreatePricingZone(ctx context.Context, companyId string, zone *model.PricingZone) (*model.PricingZone, error)
+	UpdatePricingZone(ctx context.Context, companyId string, zoneId string, zone *model.PricingZone) error
+	DeletePricingZone(ctx context.Context, companyId string, zoneId string) error
+	FindPricingZonesByCompanyId(ctx context.Context, companyId string) ([]*model.PricingZone, error)
 }
 
 // PriceHandler handles HTTP routes for Price-related operations.
@@ -25,8 +38,8 @@ func NewPriceHandler(service PriceService) *PriceHandler {
 	return &PriceHandler{priceService: service}
 }
 
-// GetPrices GET /prices/company/:companyId
-// Retrieves all prices for a given company.
+// GetPrices GET /pricing/company/:companyId
+// Retrieves the catalog prices for a given company. Pass ?includeArchived=true to include archived items.
 // Response: 200 [Price] | 400/404/500 generic error text
 func (h *PriceHandler) GetPrices(c *gin.Context) {
 	companyId := c.Param("companyId")
@@ -35,7 +48,8 @@ func (h *PriceHandler) GetPrices(c *gin.Context) {
 		return
 	}
 
-	prices, err := h.priceService.FindPricesByCompanyId(c.Request.Context(), companyId)
+	includeArchived := c.Query("includeArchived") == "true"
+	prices, err := h.priceService.FindPricesByCompanyId(c.Request.Context(), companyId, includeArchived)
 
 	if err != nil {
 		log.Println(err.Error())
@@ -46,10 +60,12 @@ func (h *PriceHandler) GetPrices(c *gin.Context) {
 	c.JSON(http.StatusOK, prices)
 }
 
-// PutPrices PUT /prices
-// Creates or sets multiple prices.
+// PutPrices PUT /pricing/company/:companyId (and the legacy PUT /pricing)
+// Creates or updates catalog prices. Prices without an id are created; prices with an id are partially updated.
+// Set "archived": false on an archived item to restore it. On the legacy route each price belongs to its
+// "companyId" or, when updating, to the company of the stored item; new prices need a "companyId".
 // Request: [Price] (array of prices)
-// Response: 204 | 400/500 generic error text
+// Response: 204 | 400 validation error text | 404 price not found
 func (h *PriceHandler) PutPrices(c *gin.Context) {
 	var body []*model.Price
 	if err := c.ShouldBindJSON(&body); err != nil {
@@ -58,11 +74,335 @@ func (h *PriceHandler) PutPrices(c *gin.Context) {
 		return
 	}
 
-	if err := h.priceService.SetPrice(c.Request.Context(), body); err != nil {
+	var err error
+	if companyId := c.Param("companyId"); companyId != "" {
+		err = h.priceService.SetPrice(c.Request.Context(), companyId, body)
+	} else {
+		err = h.priceService.SetLegacyPrices(c.Request.Context(), body)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, err.Error())
+			return
+		}
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeletePrice DELETE /pricing/company/:companyId/:priceId
+// Deletes one of the company's catalog items, or archives it when tows were charged for it.
+// Response: 200 { "archived": bool } | 400/404 generic error text
+func (h *PriceHandler) DeletePrice(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+	priceId := c.Param("priceId")
+	if priceId == "" {
+		c.String(http.StatusBadRequest, "price id is required")
+		return
+	}
+
+	archived, err := h.priceService.DeletePrice(c.Request.Context(), companyId, priceId)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "price not found")
+			return
+		}
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"archived": archived})
+}
+
+// PostPriceListDraft POST /pricing/lists/company/:companyId
+// Starts a new draft price list version. Omit "prices" to start from the list currently in effect.
+// Request Body: { "prices": [Price], "notes": "..." }
+// Response: 201 PriceList | 400 generic error text
+func (h *PriceHandler) PostPriceListDraft(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	var body struct {
+		Prices []*model.Price `json:"prices"`
+		Notes  *string        `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	list, err := h.priceService.CreatePriceListDraft(c.Request.Context(), companyId, body.Prices, body.Notes)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusCreated, list)
+}
+
+// GetPriceLists GET /pricing/lists/company/:companyId
+// Response: 200 [PriceList] newest version first | 400/500 generic error text
+func (h *PriceHandler) GetPriceLists(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	lists, err := h.priceService.FindPriceListsByCompanyId(c.Request.Context(), companyId)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, lists)
+}
+
+// GetPriceList GET /pricing/lists/company/:companyId/:priceListId
+// Response: 200 PriceList | 400/404/500 generic error text
+func (h *PriceHandler) GetPriceList(c *gin.Context) {
+	companyId := c.Param("companyId")
+	priceListId := c.Param("priceListId")
+	if companyId == "" || priceListId == "" {
+		c.String(http.StatusBadRequest, "company id and price list id are required")
+		return
+	}
+
+	list, err := h.priceService.FindPriceListById(c.Request.Context(), companyId, priceListId)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "price list not found")
+			return
+		}
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// PutPriceListDraft PUT /pricing/lists/company/:companyId/:priceListId
+// Request Body: { "prices": [Price], "notes": "..." }
+// Response: 200 PriceList | 400/404 generic error text
+func (h *PriceHandler) PutPriceListDraft(c *gin.Context) {
+	companyId := c.Param("companyId")
+	priceListId := c.Param("priceListId")
+	if companyId == "" || priceListId == "" {
+		c.String(http.StatusBadRequest, "company id and price list id are required")
+		return
+	}
+
+	var body struct {
+		Prices []*model.Price `json:"prices"`
+		Notes  *string        `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	list, err := h.priceService.UpdatePriceListDraft(c.Request.Context(), companyId, priceListId, body.Prices, body.Notes)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "price list not found")
+			return
+		}
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// PostPublishPriceList POST /pricing/lists/company/:companyId/:priceListId/publish
+// Request Body: { "effectiveFrom": unix seconds } (optional; defaults to now)
+// Response: 200 PriceList | 400/404 generic error text
+func (h *PriceHandler) PostPublishPriceList(c *gin.Context) {
+	companyId := c.Param("companyId")
+	priceListId := c.Param("priceListId")
+	if companyId == "" || priceListId == "" {
+		c.String(http.StatusBadRequest, "company id and price list id are required")
+		return
+	}
+
+	var body struct {
+		EffectiveFrom int64 `json:"effectiveFrom"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.String(http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+	}
+
+	list, err := h.priceService.PublishPriceList(c.Request.Context(), companyId, priceListId, body.EffectiveFrom, c.GetHeader("X-User-Id"))
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "price list not found")
+			return
+		}
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// PostPriceSimulation POST /pricing/lists/company/:companyId/simulate
+// Replays a proposed price list over the company's tows completed in [from, to) and compares old and new prices
+// before sales tax and promo discounts. Give either a draft or published "priceListId" or inline "prices".
+// Request Body: { "priceListId": "...", "prices": [Price], "from": unix seconds, "to": unix seconds (optional; defaults to now) }
+// Response: 200 PriceSimulation | 400/404 generic error text
+func (h *PriceHandler) PostPriceSimulation(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	var body struct {
+		PriceListID string         `json:"priceListId"`
+		Prices      []*model.Price `json:"prices"`
+		From        int64          `json:"from"`
+		To          int64          `json:"to"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	simulation, err := h.priceService.SimulatePriceList(c.Request.Context(), companyId, body.PriceListID, body.Prices, body.From, body.To)
+	if err != nil {
 		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, err.Error())
+			return
+		}
 		c.String(http.StatusBadRequest, "something went wrong")
 		return
 	}
 
+	c.JSON(http.StatusOK, simulation)
+}
+
+// PostPricingZone POST /pricing/zones/company/:companyId
+// Request: PricingZone payload in JSON body
+// Response: 201 PricingZone | 400 generic error text
+func (h *PriceHandler) PostPricingZone(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	var body model.PricingZone
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	zone, err := h.priceService.CreatePricingZone(c.Request.Context(), companyId, &body)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusCreated, zone)
+}
+
+// GetPricingZones GET /pricing/zones/company/:companyId
+// Response: 200 [PricingZone] | 400/500 generic error text
+func (h *PriceHandler) GetPricingZones(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	zones, err := h.priceService.FindPricingZonesByCompanyId(c.Request.Context(), companyId)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, zones)
+}
+
+// PutPricingZone PUT /pricing/zones/company/:companyId/:zoneId
+// Request: full PricingZone payload in JSON body
+// Response: 204 | 400/404 generic error text
+func (h *PriceHandler) PutPricingZone(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+	zoneId := c.Param("zoneId")
+	if zoneId == "" {
+		c.String(http.StatusBadRequest, "pricing zone id is required")
+		return
+	}
+
+	var body model.PricingZone
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.priceService.UpdatePricingZone(c.Request.Context(), companyId, zoneId, &body); err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "pricing zone not found")
+			return
+		}
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeletePricingZone DELETE /pricing/zones/company/:companyId/:zoneId
+// Response: 204 | 404/500 generic error text
+func (h *PriceHandler) DeletePricingZone(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+	zoneId := c.Param("zoneId")
+	if zoneId == "" {
+		c.String(http.StatusBadRequest, "pricing zone id is required")
+		return
+	}
+
+	if err := h.priceService.DeletePricingZone(c.Request.Context(), companyId, zoneId); err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "pricing zone not found")
+			return
+		}
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
 	c.Status(http.StatusNoContent)
 }
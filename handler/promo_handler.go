@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"tow-management-system-api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromoService defines the contract for promo code management and reporting.
+type PromoService interface {
+	CreatePromoCode(ctx context.Context, companyId string, promo *model.PromoCode) (*model.PromoCode, error)
+	UpdatePromoCode(ctx context.Context, companyId string, promoId string, update *model.PromoCode) (*model.PromoCode, error)
+	FindPromoCodesByCompanyId(ctx context.Context, companyId string) ([]*model.PromoCode, error)
+	FindRedemptions(ctx context.Context, companyId string, promoId string) ([]*model.PromoRedemption, error)
+}
+
+// PromoHandler handles HTTP routes for company promo codes.
+type PromoHandler struct {
+	promoService PromoService
+}
+
+// NewPromoHandler creates a new PromoHandler instance.
+func NewPromoHandler(service PromoService) *PromoHandler {
+	return &PromoHandler{promoService: service}
+}
+
+// PostPromoCode POST /promos/company/:companyId
+// Request: PromoCode payload in JSON body
+// Response: 201 PromoCode | 400 generic error text
+func (h *PromoHandler) PostPromoCode(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	var body model.PromoCode
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	promo, err := h.promoService.CreatePromoCode(c.Request.Context(), companyId, &body)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusCreated, promo)
+}
+
+// GetPromoCodes GET /promos/company/:companyId
+// Response: 200 [PromoCode] including use counts | 400/500 generic error text
+func (h *PromoHandler) GetPromoCodes(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	promos, err := h.promoService.FindPromoCodesByCompanyId(c.Request.Context(), companyId)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, promos)
+}
+
+// PutPromoCode PUT /promos/company/:companyId/:promoId
+// Request: partial PromoCode fields in JSON body, e.g. { "active": false }
+// Response: 200 PromoCode | 400/404 generic error text
+func (h *PromoHandler) PutPromoCode(c *gin.Context) {
+	companyId := c.Param("companyId")
+	promoId := c.Param("promoId")
+	if companyId == "" || promoId == "" {
+		c.String(http.StatusBadRequest, "company id and promo code id are required")
+		return
+	}
+
+	var body model.PromoCode
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	promo, err := h.promoService.UpdatePromoCode(c.Request.Context(), companyId, promoId, &body)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "promo code not found")
+			return
+		}
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, promo)
+}
+
+// GetPromoRedemptions GET /promos/company/:companyId/:promoId/redemptions
+// Response: 200 [PromoRedemption] newest first | 400/404/500 generic error text
+func (h *PromoHandler) GetPromoRedemptions(c *gin.Context) {
+	companyId := c.Param("companyId")
+	promoId := c.Param("promoId")
+	if companyId == "" || promoId == "" {
+		c.String(http.StatusBadRequest, "company id and promo code id are required")
+		return
+	}
+
+	redemptions, err := h.promoService.FindRedemptions(c.Request.Context(), companyId, promoId)
+	if err != nil {
+		log.Println(err.Error())
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "promo code not found")
+			return
+		}
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, redemptions)
+}
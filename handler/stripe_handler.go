@@ -2,19 +2,21 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strings"
+	"tow-management-system-api/model"
 
 	"github.com/gin-gonic/gin"
-	"github.com/stripe/stripe-go/v83"
 )
 
 // StripePaymentService defines the contract required for Stripe webhook processing.
 type StripePaymentService interface {
-	ProcessCheckoutSuccessEvent(ctx context.Context, event *stripe.Event) error
+	HandleWebhookEvent(ctx context.Context, payload []byte, signature string) error
+	FindWebhookEvents(ctx context.Context, userId string, status string) ([]*model.WebhookEvent, error)
+	ReplayWebhookEvent(ctx context.Context, userId string, eventId string) (*model.WebhookEvent, error)
 }
 
 // StripeHandler handles Stripe webhook endpoints.
@@ -30,10 +32,11 @@ func NewStripeHandler(service StripePaymentService) *StripeHandler {
 }
 
 // PostWebhook POST /webhooks/stripe
-// Handles Stripe webhook events, processes checkout session success events.
-// Response: 200 OK | 400 Bad Request | 500 Internal Server Error
+// Verifies the Stripe-Signature header and routes the event to the handler for its type.
+// Event types without a handler are acknowledged with 200 so Stripe stops retrying them.
+// Response: 200 OK | 400 Bad Request (unreadable body or invalid signature) | 500 Internal Server Error (Stripe retries)
 func (h *StripeHandler) PostWebhook(c *gin.Context) {
-	// Read the request body
+	// Read the request body; the signature covers the raw bytes, so it must not be re-encoded
 	payload, err := io.ReadAll(c.Request.Body)
 	if err != nil {
 		log.Printf("Failed to read webhook body: %v\n", err)
@@ -41,20 +44,70 @@ func (h *StripeHandler) PostWebhook(c *gin.Context) {
 		return
 	}
 
-	// Unmarshal the payload into a stripe.Event
-	event := stripe.Event{}
-	if err := json.Unmarshal(payload, &event); err != nil {
-		log.Printf("Failed to parse webhook body json: %v\n", err)
-		c.String(http.StatusBadRequest, "Failed to parse webhook body")
+	if err := h.paymentService.HandleWebhookEvent(c.Request.Context(), payload, c.GetHeader("Stripe-Signature")); err != nil {
+		log.Printf("Failed to process webhook event: %v\n", err)
+		if strings.Contains(err.Error(), "signature") || strings.Contains(err.Error(), "STRIPE_WEBHOOK_SECRET") {
+			c.String(http.StatusBadRequest, "Invalid webhook signature")
+			return
+		}
+		c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to process event: %v", err))
 		return
 	}
 
-	// Process the checkout success event
-	if err := h.paymentService.ProcessCheckoutSuccessEvent(c.Request.Context(), &event); err != nil {
-		log.Printf("Failed to process checkout success event: %v\n", err)
-		c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to process event: %v", err))
+	c.String(http.StatusOK, "Event processed successfully")
+}
+
+// GetWebhookEvents GET /webhooks/stripe/events
+// Admin: lists recorded Stripe webhook events without their payloads, newest first, for the platform admin in
+// the X-User-Id header. Pass ?status=failed to list failed events.
+// Response: 200 [WebhookEvent] | 401 without X-User-Id | 403 for other users | 500 generic error text
+func (h *StripeHandler) GetWebhookEvents(c *gin.Context) {
+	events, err := h.paymentService.FindWebhookEvents(c.Request.Context(), c.GetHeader("X-User-Id"), c.Query("status"))
+	if err != nil {
+		log.Println(err.Error())
+		switch {
+		case strings.Contains(err.Error(), "user id is required"):
+			c.String(http.StatusUnauthorized, err.Error())
+		case strings.Contains(err.Error(), "not allowed"):
+			c.String(http.StatusForbidden, err.Error())
+		default:
+			c.String(http.StatusInternalServerError, "something went wrong")
+		}
 		return
 	}
 
-	c.String(http.StatusOK, "Event processed successfully")
+	c.JSON(http.StatusOK, events)
+}
+
+// PostReplayWebhookEvent POST /webhooks/stripe/events/:eventId/replay
+// Admin: runs a failed or unfinished webhook event through its handler again, for the platform admin in the
+// X-User-Id header.
+// Response: 200 WebhookEvent (status processed or failed with lastError) | 401 without X-User-Id | 403 for other
+// users | 404 not found | 409 already processed or in progress
+func (h *StripeHandler) PostReplayWebhookEvent(c *gin.Context) {
+	eventId := c.Param("eventId")
+	if eventId == "" {
+		c.String(http.StatusBadRequest, "event id is required")
+		return
+	}
+
+	event, err := h.paymentService.ReplayWebhookEvent(c.Request.Context(), c.GetHeader("X-User-Id"), eventId)
+	if err != nil {
+		log.Println(err.Error())
+		switch {
+		case strings.Contains(err.Error(), "user id is required"):
+			c.String(http.StatusUnauthorized, err.Error())
+		case strings.Contains(err.Error(), "not allowed"):
+			c.String(http.StatusForbidden, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			c.String(http.StatusNotFound, "webhook event not found")
+		case strings.Contains(err.Error(), "already"):
+			c.String(http.StatusConflict, err.Error())
+		default:
+			c.String(http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
 }
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"tow-management-system-api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaxService defines the contract for sales tax configuration.
+type TaxService interface {
+	FindTaxRates(ctx context.Context, companyId string) ([]*model.TaxRate, error)
+	SetTaxRates(ctx context.Context, companyId string, rates []*model.TaxRate) error
+}
+
+// TaxHandler handles HTTP routes for a company's sales tax rates.
+type TaxHandler struct {
+	taxService TaxService
+}
+
+// NewTaxHandler creates a new TaxHandler instance.
+func NewTaxHandler(service TaxService) *TaxHandler {
+	return &TaxHandler{taxService: service}
+}
+
+// GetTaxRates GET /tax/rates/company/:companyId
+// Response: 200 [TaxRate] | 400/500 generic error text
+func (h *TaxHandler) GetTaxRates(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	rates, err := h.taxService.FindTaxRates(c.Request.Context(), companyId)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// PutTaxRates PUT /tax/rates/company/:companyId
+// Replaces the company's tax rate table; rates left out of the list are removed.
+// Request: [TaxRate]
+// Response: 204 | 400 generic error text
+func (h *TaxHandler) PutTaxRates(c *gin.Context) {
+	companyId := c.Param("companyId")
+	if companyId == "" {
+		c.String(http.StatusBadRequest, "company id is required")
+		return
+	}
+
+	var body []*model.TaxRate
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.taxService.SetTaxRates(c.Request.Context(), companyId, body); err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusBadRequest, "something went wrong")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -2,8 +2,11 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"tow-management-system-api/model"
 
 	"github.com/gin-gonic/gin"
@@ -13,8 +16,12 @@ import (
 type TowService interface {
 	ScheduleTow(ctx context.Context, towRequest *model.Tow, schedulingLink string) (*model.Tow, error)
 	FindTowsByCompanyId(ctx context.Context, companyId string) ([]*model.Tow, error)
-	UpdateTow(ctx context.Context, towId string, update *model.Tow) error
-	GetEstimate(ctx context.Context, companyId string, pickup string, dropoff string) (int64, error)
+	UpdateTow(ctx context.Context, towId string, update *model.Tow) ([]string, error)
+	GetEstimate(ctx context.Context, companyId string, request *model.Tow) (*model.Quote, error)
+	CreateQuote(ctx context.Context, companyId string, request *model.Tow) (*model.Quote, error)
+	FindQuoteById(ctx context.Context, quoteId string) (*model.Quote, error)
+	RegeneratePaymentLink(ctx context.Context, towId string) (*model.Tow, error)
+	ResendPaymentLink(ctx context.Context, towId string) (*model.Tow, error)
 }
 
 // TowHandler handles HTTP routes for Tow-related operations.
@@ -49,8 +56,9 @@ func (h *TowHandler) GetTowHistory(c *gin.Context) {
 
 // PostTow POST /tows/:companyId
 // Create a new tow request for the given company.
-// Request: Tow payload in JSON body
-// Response: 201 [Tow] | 400/404/500 generic error text
+// Request: Tow payload in JSON body; include "quoteId" to book a saved quote at its price and "promoCode" to apply a discount
+// Response: 201 [Tow] | 409 when the quote has expired or was already booked | 400 with the reason when the promo code
+// cannot be used | 400/404/500 generic error text
 func (h *TowHandler) PostTow(c *gin.Context) {
 	schedulingLink := c.Param("schedulingLink")
 	if schedulingLink == "" {
@@ -71,6 +79,14 @@ func (h *TowHandler) PostTow(c *gin.Context) {
 	tow, err := h.towService.ScheduleTow(c.Request.Context(), &towBody, schedulingLink)
 	if err != nil {
 		log.Println(err.Error())
+		if strings.Contains(err.Error(), "quote has expired") || strings.Contains(err.Error(), "quote has already been booked") {
+			c.String(http.StatusConflict, err.Error())
+			return
+		}
+		if message, ok := promoCodeMessage(err); ok {
+			c.String(http.StatusBadRequest, message)
+			return
+		}
 		c.String(http.StatusBadRequest, "something went wrong")
 		return
 	}
@@ -79,9 +95,11 @@ func (h *TowHandler) PostTow(c *gin.Context) {
 }
 
 // PutUpdateTow PUT /tows/:towId
-// Partially updates a tow by ID.
+// Partially updates a tow by ID. Vehicle fields are updated one by one; a vehicle class set here does not reprice the tow.
 // Request: partial Tow fields in JSON body
-// Response: 204 | 400/404/500 generic error text
+// Payment, refund, dispute, checkout and invoice fields are kept by the payment ledger and are rejected.
+// Response: 204 | 200 { "warnings": [...] } when the update needs dispatcher attention | 400 when a ledger field is
+// set | 400/404/500 generic error text
 func (h *TowHandler) PutUpdateTow(c *gin.Context) {
 	towId := c.Param("towId")
 	if towId == "" {
@@ -95,43 +113,241 @@ func (h *TowHandler) PutUpdateTow(c *gin.Context) {
 		return
 	}
 
-	if err := h.towService.UpdateTow(c.Request.Context(), towId, &body); err != nil {
+	warnings, err := h.towService.UpdateTow(c.Request.Context(), towId, &body)
+	if err != nil {
 		log.Println(err.Error())
+		if strings.Contains(err.Error(), "kept by the payment ledger") {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
 		c.String(http.StatusBadRequest, "something went wrong")
 		return
 	}
 
+	if len(warnings) > 0 {
+		c.JSON(http.StatusOK, gin.H{"warnings": warnings})
+		return
+	}
+
 	c.Status(http.StatusNoContent)
 }
 
-// GetEstimate GET /tow/estimates?pickup=123&dropoff=456&company=0009990
-// Calculates and returns a price estimate for a tow request.
-// Query parameters: pickup (required), dropoff (required), company (required)
-// Response: 200 { "estimate": int } | 400/404/500 generic error text
+// GetEstimate GET /tows/estimates?pickup=123&dropoff=456&company=0009990&scheduledAt=1767225600&make=Ford&model=F-350&addOns=dolly
+// Calculates a price estimate for a tow request. Nothing is saved; use POST /quotes for a quote that can be booked.
+// Query parameters: pickup (required), dropoff (required), company (required), scheduledAt (optional unix seconds),
+// year, make, model (optional; the vehicle class is always inferred from make/model), addOns (optional, comma separated),
+// promoCode (optional)
+// Response: 200 { "estimate": int, "lineItems": [PayableLineItem], "miles": float, "enRouteMiles": float, "returnMiles": float,
+// "minutes": float, "taxAmount": int, "discount": int } | 400 with the reason when the promo code cannot be used |
+// 400/404/500 generic error text
 func (h *TowHandler) GetEstimate(c *gin.Context) {
+	company, request, ok := estimateRequest(c)
+	if !ok {
+		return
+	}
+
+	quote, err := h.towService.GetEstimate(c.Request.Context(), company, request)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusBadRequest, estimateErrorMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, estimateResponse(quote))
+}
+
+// PostQuote POST /quotes?pickup=123&dropoff=456&company=0009990&scheduledAt=1767225600&make=Ford&model=F-350&addOns=dolly
+// Prices a tow request like GET /tows/estimates and saves it as a quote that can be booked until it expires.
+// Query parameters: same as GET /tows/estimates
+// Response: 201 { estimate fields, "quoteId": string, "quoteLink": string, "expiresAt": unix seconds } |
+// 400 with the reason when the promo code cannot be used | 400/404/500 generic error text
+func (h *TowHandler) PostQuote(c *gin.Context) {
+	company, request, ok := estimateRequest(c)
+	if !ok {
+		return
+	}
+
+	quote, err := h.towService.CreateQuote(c.Request.Context(), company, request)
+	if err != nil {
+		log.Println(err.Error())
+		c.String(http.StatusBadRequest, estimateErrorMessage(err))
+		return
+	}
+
+	response := estimateResponse(quote)
+	response["quoteId"] = quote.ID
+	response["quoteLink"] = quote.Link
+	response["expiresAt"] = quote.ExpiresAt
+	c.JSON(http.StatusCreated, response)
+}
+
+// estimateRequest reads the estimate query parameters shared by GetEstimate and PostQuote. It writes the 400 response
+// and returns false when a required parameter is missing or malformed.
+func estimateRequest(c *gin.Context) (string, *model.Tow, bool) {
 	pickup := c.Query("pickup")
 	dropoff := c.Query("dropoff")
 	company := c.Query("company")
 
 	if pickup == "" {
 		c.String(http.StatusBadRequest, "pickup is required")
-		return
+		return "", nil, false
 	}
 	if dropoff == "" {
 		c.String(http.StatusBadRequest, "dropoff is required")
-		return
+		return "", nil, false
 	}
 	if company == "" {
 		c.String(http.StatusBadRequest, "company is required")
+		return "", nil, false
+	}
+
+	var scheduledAt *int64
+	if raw := c.Query("scheduledAt"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.String(http.StatusBadRequest, "scheduledAt must be unix seconds")
+			return "", nil, false
+		}
+		scheduledAt = &parsed
+	}
+
+	request := &model.Tow{
+		Pickup:      &pickup,
+		Destination: &dropoff,
+		ScheduledAt: scheduledAt,
+		Vehicle: &model.Vehicle{
+			Year:  optionalQuery(c, "year"),
+			Make:  optionalQuery(c, "make"),
+			Model: optionalQuery(c, "model"),
+		},
+	}
+	if addOns := c.Query("addOns"); addOns != "" {
+		request.AddOns = strings.Split(addOns, ",")
+	}
+	request.PromoCode = optionalQuery(c, "promoCode")
+
+	return company, request, true
+}
+
+// estimateErrorMessage returns the promo code reason when there is one, and generic text otherwise.
+func estimateErrorMessage(err error) string {
+	if message, ok := promoCodeMessage(err); ok {
+		return message
+	}
+	return "something went wrong"
+}
+
+// promoCodeMessage returns the reason a promo code was refused, if that is what err is. Only the innermost error
+// is considered, so a wrapped database error that mentions the promo code is not passed on to the customer.
+func promoCodeMessage(err error) (string, bool) {
+	for errors.Unwrap(err) != nil {
+		err = errors.Unwrap(err)
+	}
+	message := err.Error()
+	if strings.HasPrefix(message, "promo code ") || message == "primary contact email is required to use this promo code" {
+		return message, true
+	}
+	return "", false
+}
+
+// estimateResponse is the priced breakdown returned by GetEstimate and PostQuote.
+func estimateResponse(quote *model.Quote) gin.H {
+	return gin.H{
+		"estimate":     quote.Breakdown.Total,
+		"lineItems":    quote.Breakdown.LineItems,
+		"miles":        quote.Breakdown.Miles,
+		"billedMiles":  quote.Breakdown.BilledMiles,
+		"enRouteMiles": quote.Breakdown.EnRouteMiles,
+		"returnMiles":  quote.Breakdown.ReturnMiles,
+		"minutes":      quote.Breakdown.Minutes,
+		"pricedAt":     quote.Breakdown.PricedAt,
+		"taxAmount":    quote.Breakdown.TaxAmount,
+		"discount":     quote.Breakdown.Discount,
+	}
+}
+
+// GetQuote GET /quotes/:quoteId
+// Returns a saved quote for the shareable quote page.
+// Response: 200 Quote | 400/404/500 generic error text
+func (h *TowHandler) GetQuote(c *gin.Context) {
+	quoteId := c.Param("quoteId")
+	if quoteId == "" {
+		c.String(http.StatusBadRequest, "quote id is required")
 		return
 	}
 
-	estimate, err := h.towService.GetEstimate(c.Request.Context(), company, pickup, dropoff)
+	quote, err := h.towService.FindQuoteById(c.Request.Context(), quoteId)
 	if err != nil {
 		log.Println(err.Error())
-		c.String(http.StatusBadRequest, "something went wrong")
+		if strings.Contains(err.Error(), "not found") {
+			c.String(http.StatusNotFound, "quote not found")
+			return
+		}
+		c.String(http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// optionalQuery returns a pointer to the query parameter's value, or nil when it is absent or empty.
+func optionalQuery(c *gin.Context, key string) *string {
+	value := c.Query(key)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// PostRegeneratePaymentLink POST /payments/tows/:towId/checkout
+// Replaces an unpaid tow's checkout link with a new one for the tow's current price. The old link stops working.
+// Response: 200 [Tow] | 409 when the tow is already paid or its checkout was completed | 400/404/500 generic error text
+func (h *TowHandler) PostRegeneratePaymentLink(c *gin.Context) {
+	towId := c.Param("towId")
+	if towId == "" {
+		c.String(http.StatusBadRequest, "tow id is required")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"estimate": estimate})
+	tow, err := h.towService.RegeneratePaymentLink(c.Request.Context(), towId)
+	if err != nil {
+		writePaymentLinkError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tow)
+}
+
+// PostResendPaymentLink POST /payments/tows/:towId/checkout/resend
+// Emails an unpaid tow's checkout link to its primary contact again, regenerating the link first if it expired.
+// Response: 200 [Tow] | 409 when the tow is already paid or its checkout was completed | 400/404/500 generic error text
+func (h *TowHandler) PostResendPaymentLink(c *gin.Context) {
+	towId := c.Param("towId")
+	if towId == "" {
+		c.String(http.StatusBadRequest, "tow id is required")
+		return
+	}
+
+	tow, err := h.towService.ResendPaymentLink(c.Request.Context(), towId)
+	if err != nil {
+		writePaymentLinkError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tow)
+}
+
+// writePaymentLinkError maps payment link errors to a response.
+func writePaymentLinkError(c *gin.Context, err error) {
+	log.Println(err.Error())
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		c.String(http.StatusNotFound, err.Error())
+	case strings.Contains(err.Error(), "no longer needs a payment link"), strings.Contains(err.Error(), "already completed"):
+		c.String(http.StatusConflict, err.Error())
+	case strings.Contains(err.Error(), "no balance to collect"), strings.Contains(err.Error(), "cannot accept payments"), strings.Contains(err.Error(), "stripe account id"):
+		c.String(http.StatusBadRequest, err.Error())
+	default:
+		c.String(http.StatusInternalServerError, "something went wrong")
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	_ "time/tzdata" // embed zoneinfo; the Lambda base image does not ship it and company timezones need it
 
 	"github.com/gin-gonic/gin"
 
@@ -32,9 +33,21 @@ func buildApp() (*gin.Engine, error) {
 	companyRepo := db.CreateCompanyRepository()
 	towRepo := db.CreateTowRepository()
 	priceRepo := db.CreatePriceRepository()
-
-	// 2.5) Stripe Client
-	stripeClient, err := utilities.NewStripeClient()
+	commissionRuleRepo := db.CreateCommissionRuleRepository()
+	payrollRunRepo := db.CreatePayrollRunRepository()
+	truckRepo := db.CreateTruckRepository()
+	inspectionRepo := db.CreateInspectionRepository()
+	workOrderRepo := db.CreateWorkOrderRepository()
+	quoteRepo := db.CreateQuoteRepository()
+	priceListRepo := db.CreatePriceListRepository()
+	taxRateRepo := db.CreateTaxRateRepository()
+	promoCodeRepo := db.CreatePromoCodeRepository()
+	promoRedemptionRepo := db.CreatePromoRedemptionRepository()
+	pricingZoneRepo := db.CreatePricingZoneRepository()
+	webhookEventRepo := db.CreateWebhookEventRepository()
+
+	// 2.5) Payment Gateway (Stripe)
+	paymentGateway, err := utilities.NewStripeClient()
 	if err != nil {
 		return nil, err
 	}
@@ -53,12 +66,17 @@ func buildApp() (*gin.Engine, error) {
 
 	// 3) Services
 	userSvc := service.NewUserServiceWithMongo(userRepo)
-	companySvc := service.NewCompanyService(companyRepo, stripeClient)
-	towSvc := service.NewTowService(towRepo, priceRepo, companyRepo, locationUtility, stripeClient, emailUtility)
-	paymentSvc := service.NewPaymentService(towRepo, companyRepo, stripeClient)
+	companySvc := service.NewCompanyService(companyRepo, paymentGateway)
+	towSvc := service.NewTowService(towRepo, priceRepo, priceListRepo, companyRepo, truckRepo, quoteRepo, taxRateRepo, promoCodeRepo, promoRedemptionRepo, pricingZoneRepo, locationUtility, paymentGateway, emailUtility)
+	paymentSvc := service.NewPaymentService(towRepo, companyRepo, webhookEventRepo, userRepo, paymentGateway, emailUtility)
 	metricSvc := service.NewMetricService(towRepo)
-	priceSvc := service.NewPriceService(priceRepo)
+	priceSvc := service.NewPriceService(priceRepo, priceListRepo, pricingZoneRepo, towRepo, companyRepo)
 	locationSvc := service.NewLocationService(locationUtility)
+	payrollSvc := service.NewPayrollService(commissionRuleRepo, payrollRunRepo, towRepo, userRepo)
+	fleetSvc := service.NewFleetService(truckRepo, inspectionRepo, workOrderRepo, companyRepo)
+	taxSvc := service.NewTaxService(taxRateRepo)
+	promoSvc := service.NewPromoService(promoCodeRepo, promoRedemptionRepo)
+	invoiceSvc := service.NewInvoiceService(towRepo, companyRepo, userRepo)
 
 	// 4) Handlers
 	userHandler := handler.NewUserHandler(userSvc)
@@ -69,9 +87,14 @@ func buildApp() (*gin.Engine, error) {
 	paymentHandler := handler.NewPaymentHandler(paymentSvc)
 	stripeHandler := handler.NewStripeHandler(paymentSvc)
 	locationHandler := handler.NewLocationHandler(locationSvc)
+	payrollHandler := handler.NewPayrollHandler(payrollSvc)
+	fleetHandler := handler.NewFleetHandler(fleetSvc)
+	taxHandler := handler.NewTaxHandler(taxSvc)
+	promoHandler := handler.NewPromoHandler(promoSvc)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceSvc)
 
 	// 5) Router
-	router := utilities.NewRouter(userHandler, companyHandler, towHandler, metricHandler, priceHandler, paymentHandler, stripeHandler, locationHandler)
+	router := utilities.NewRouter(userHandler, companyHandler, towHandler, metricHandler, priceHandler, paymentHandler, stripeHandler, locationHandler, payrollHandler, fleetHandler, taxHandler, promoHandler, invoiceHandler)
 	engine := router.InitializeRouter()
 	return engine, nil
 }
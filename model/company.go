@@ -1,16 +1,40 @@
 package model
 
 type Company struct {
-	ID              *string `json:"id,omitempty" bson:"_id,omitempty"`
-	Website         *string `json:"website,omitempty" bson:"website,omitempty"`
-	Name            *string `json:"name,omitempty" bson:"name,omitempty"`
-	Status          *string `json:"status,omitempty" bson:"status,omitempty"`
-	Street          *string `json:"street,omitempty" bson:"street,omitempty"`
-	City            *string `json:"city,omitempty" bson:"city,omitempty"`
-	ZipCode         *string `json:"zipCode,omitempty" bson:"zipCode,omitempty"`
-	State           *string `json:"state,omitempty" bson:"state,omitempty"`
-	PhoneNumber     *string `json:"phoneNumber,omitempty" bson:"phoneNumber,omitempty"`
-	CreatedDate     int64   `json:"createdDate,omitempty" bson:"createdDate,omitempty"`
-	SchedulingLink  *string `json:"schedulingLink,omitempty" bson:"schedulingLink,omitempty"`
-	StripeAccountId *string `json:"stripeAccountId,omitempty" bson:"stripeAccountId,omitempty"`
+	ID                    *string   `json:"id,omitempty" bson:"_id,omitempty"`
+	Website               *string   `json:"website,omitempty" bson:"website,omitempty"`
+	Name                  *string   `json:"name,omitempty" bson:"name,omitempty"`
+	Status                *string   `json:"status,omitempty" bson:"status,omitempty"`
+	Street                *string   `json:"street,omitempty" bson:"street,omitempty"`
+	City                  *string   `json:"city,omitempty" bson:"city,omitempty"`
+	ZipCode               *string   `json:"zipCode,omitempty" bson:"zipCode,omitempty"`
+	State                 *string   `json:"state,omitempty" bson:"state,omitempty"`
+	PhoneNumber           *string   `json:"phoneNumber,omitempty" bson:"phoneNumber,omitempty"`
+	CreatedDate           int64     `json:"createdDate,omitempty" bson:"createdDate,omitempty"`
+	SchedulingLink        *string   `json:"schedulingLink,omitempty" bson:"schedulingLink,omitempty"`
+	StripeAccountId       *string   `json:"stripeAccountId,omitempty" bson:"stripeAccountId,omitempty"`
+	ChargesEnabled        *bool     `json:"chargesEnabled,omitempty" bson:"chargesEnabled,omitempty"`               // Stripe account can accept payments; kept in sync by account.updated
+	PayoutsEnabled        *bool     `json:"payoutsEnabled,omitempty" bson:"payoutsEnabled,omitempty"`               // Stripe account can receive payouts; kept in sync by account.updated
+	DetailsSubmitted      *bool     `json:"detailsSubmitted,omitempty" bson:"detailsSubmitted,omitempty"`           // Stripe onboarding is complete; kept in sync by account.updated
+	Plan                  *string   `json:"plan,omitempty" bson:"plan,omitempty"`                                   // platform plan that sets the application fee: standard (default), pro
+	ApplicationFeePercent *float64  `json:"applicationFeePercent,omitempty" bson:"applicationFeePercent,omitempty"` // overrides the plan's fee percent of each tow payment
+	ApplicationFeeFixed   *int      `json:"applicationFeeFixed,omitempty" bson:"applicationFeeFixed,omitempty"`     // overrides the plan's fixed fee in cents per tow payment
+	CheckoutSuccessUrl    *string   `json:"checkoutSuccessUrl,omitempty" bson:"checkoutSuccessUrl,omitempty"`       // page customers return to after paying; defaults to the platform page
+	CheckoutCancelUrl     *string   `json:"checkoutCancelUrl,omitempty" bson:"checkoutCancelUrl,omitempty"`         // page customers return to when they leave checkout; defaults to the platform page
+	BrandColor            *string   `json:"brandColor,omitempty" bson:"brandColor,omitempty"`                       // hex color of the invoice header, e.g. #1F4E79
+	InvoicePrefix         *string   `json:"invoicePrefix,omitempty" bson:"invoicePrefix,omitempty"`                 // put before invoice numbers; defaults to INV-
+	InvoiceSequence       *int      `json:"invoiceSequence,omitempty" bson:"invoiceSequence,omitempty"`             // last invoice number issued; only changed by invoicing
+	InspectionChecklist   []string  `json:"inspectionChecklist,omitempty" bson:"inspectionChecklist,omitempty"`     // items every DVIR must cover
+	Timezone              *string   `json:"timezone,omitempty" bson:"timezone,omitempty"`                           // IANA name, e.g. America/New_York; time-based prices are evaluated here
+	Holidays              []string  `json:"holidays,omitempty" bson:"holidays,omitempty"`                           // YYYY-MM-DD dates in the company's timezone
+	MileageRounding       *string   `json:"mileageRounding,omitempty" bson:"mileageRounding,omitempty"`             // exact, tenth_mile, whole_mile; defaults to exact
+	QuoteValidMinutes     *int      `json:"quoteValidMinutes,omitempty" bson:"quoteValidMinutes,omitempty"`         // how long an estimate can be booked at its price; defaults to 24 hours
+	YardCoordinates       []float64 `json:"yardCoordinates,omitempty" bson:"yardCoordinates,omitempty"`             // geocoded [longitude, latitude] of the street address, used for en-route pricing
+	YardGeocodedAddress   *string   `json:"yardGeocodedAddress,omitempty" bson:"yardGeocodedAddress,omitempty"`     // address YardCoordinates was geocoded from
 }
+
+// Platform plans. Each plan sets the application fee the platform keeps from a company's tow payments.
+const (
+	PlanStandard = "standard"
+	PlanPro      = "pro"
+)
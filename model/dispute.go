@@ -0,0 +1,46 @@
+package model
+
+// Dispute is a chargeback the customer opened against a tow's card payment, mirrored from the Stripe dispute.
+// Amount is in cents.
+type Dispute struct {
+	ID              *string `json:"id,omitempty" bson:"id,omitempty"`                           // Stripe dispute ID, e.g. dp_...
+	PaymentIntentID *string `json:"paymentIntentId,omitempty" bson:"paymentIntentId,omitempty"` // disputed payment
+	Status          *string `json:"status,omitempty" bson:"status,omitempty"`                   // needs_response, under_review, won, lost, warning_needs_response, ...
+	Amount          *int    `json:"amount,omitempty" bson:"amount,omitempty"`                   // cents held from the company while the dispute is open
+	Reason          *string `json:"reason,omitempty" bson:"reason,omitempty"`                   // Stripe reason, e.g. fraudulent, product_not_received
+	EvidenceDueBy   *int64  `json:"evidenceDueBy,omitempty" bson:"evidenceDueBy,omitempty"`     // unix seconds; evidence must be submitted in Stripe before then
+	CreatedAt       *int64  `json:"createdAt,omitempty" bson:"createdAt,omitempty"`             // unix seconds the customer opened the dispute
+	UpdatedAt       *int64  `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`             // unix seconds of the last dispute event
+}
+
+// DisputeEvidence is what a company needs to answer a dispute: what was done for the customer and when, what
+// they were charged and paid, the files attached to the tow, and the invoice.
+type DisputeEvidence struct {
+	TowID         string            `json:"towId"`
+	Dispute       *Dispute          `json:"dispute"`
+	CustomerName  string            `json:"customerName,omitempty"`
+	CustomerEmail string            `json:"customerEmail,omitempty"`
+	CustomerPhone string            `json:"customerPhone,omitempty"`
+	Vehicle       *Vehicle          `json:"vehicle,omitempty"`
+	Pickup        string            `json:"pickup,omitempty"`
+	Destination   string            `json:"destination,omitempty"`
+	Miles         *float64          `json:"miles,omitempty"`
+	ServiceDate   *int64            `json:"serviceDate,omitempty"` // unix seconds the tow was completed, or scheduled if it was not
+	Price         int               `json:"price"`                 // cents
+	LineItems     []PayableLineItem `json:"lineItems,omitempty"`
+	Payments      []Payment         `json:"payments,omitempty"`
+	Refunds       []Refund          `json:"refunds,omitempty"`
+	Timeline      []EvidenceEvent   `json:"timeline"`              // oldest first
+	Attachments   []string          `json:"attachments,omitempty"` // photos, signatures and other files attached to the tow
+	Notes         string            `json:"notes,omitempty"`
+	History       []string          `json:"history,omitempty"`
+	InvoiceNumber string            `json:"invoiceNumber"`
+	InvoiceFile   string            `json:"invoiceFile"` // file name of InvoicePDF
+	InvoicePDF    []byte            `json:"invoicePdf"`  // base64 in JSON
+}
+
+// EvidenceEvent is one dated step of a tow in a dispute timeline.
+type EvidenceEvent struct {
+	At          int64  `json:"at"` // unix seconds
+	Description string `json:"description"`
+}
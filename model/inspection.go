@@ -0,0 +1,29 @@
+package model
+
+// Inspection is a driver vehicle inspection report (DVIR) completed before or after a trip.
+type Inspection struct {
+	ID        *string          `json:"id,omitempty" bson:"_id,omitempty"`
+	CompanyID *string          `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	TruckID   *string          `json:"truckId,omitempty" bson:"truckId,omitempty"`
+	DriverID  *string          `json:"driverId,omitempty" bson:"driverId,omitempty"`
+	Type      *string          `json:"type,omitempty" bson:"type,omitempty"` // pre_trip, post_trip
+	Odometer  *int             `json:"odometer,omitempty" bson:"odometer,omitempty"`
+	Items     []InspectionItem `json:"items,omitempty" bson:"items,omitempty"`
+	Defects   []Defect         `json:"defects,omitempty" bson:"defects,omitempty"`
+	CreatedAt *int64           `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+}
+
+// InspectionItem is the result for one entry of the company's inspection checklist.
+type InspectionItem struct {
+	Name   *string `json:"name,omitempty" bson:"name,omitempty"`
+	Passed *bool   `json:"passed,omitempty" bson:"passed,omitempty"`
+	Notes  *string `json:"notes,omitempty" bson:"notes,omitempty"`
+}
+
+// Defect is a problem found during an inspection. OutOfService defects take the truck out of service
+// until the work order opened for them is completed.
+type Defect struct {
+	Item         *string `json:"item,omitempty" bson:"item,omitempty"`
+	Description  *string `json:"description,omitempty" bson:"description,omitempty"`
+	OutOfService *bool   `json:"outOfService,omitempty" bson:"outOfService,omitempty"`
+}
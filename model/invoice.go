@@ -0,0 +1,8 @@
+package model
+
+// Invoice is a rendered tow invoice, ready to download.
+type Invoice struct {
+	Number   string // e.g. INV-000042
+	FileName string // e.g. INV-000042.pdf
+	PDF      []byte
+}
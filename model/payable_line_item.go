@@ -1,9 +1,24 @@
 package model
 
 // PayableLineItem represents a single item the customer is paying for.
-// Amount is in the smallest currency unit (e.g. USD cents).
+// Amount is in the smallest currency unit (e.g. USD cents); discounts are negative.
 type PayableLineItem struct {
-	Name     string
-	Amount   int64
-	Quantity int64
+	Name     string `json:"name" bson:"name"`
+	Amount   int64  `json:"amount" bson:"amount"`
+	Quantity int64  `json:"quantity" bson:"quantity"`
+}
+
+// PriceBreakdown is the itemized result of pricing a tow. Total is always the sum of the line item amounts.
+type PriceBreakdown struct {
+	LineItems    []PayableLineItem `json:"lineItems" bson:"lineItems"`
+	Total        int64             `json:"total" bson:"total"`
+	Miles        float64           `json:"miles" bson:"miles"`               // routed distance
+	BilledMiles  float64           `json:"billedMiles" bson:"billedMiles"`   // distance after the company's mileage rounding
+	EnRouteMiles float64           `json:"enRouteMiles" bson:"enRouteMiles"` // routed distance from the company yard to the pickup; 0 unless the company charges it
+	ReturnMiles  float64           `json:"returnMiles" bson:"returnMiles"`   // routed distance from the destination back to the yard; 0 unless the company charges it
+	Minutes      float64           `json:"minutes" bson:"minutes"`
+	PricedAt     int64             `json:"pricedAt" bson:"pricedAt"`   // unix seconds the time-based rules were evaluated at
+	TaxRate      float64           `json:"taxRate" bson:"taxRate"`     // percent applied to the taxable items, 0 when untaxed
+	TaxAmount    int64             `json:"taxAmount" bson:"taxAmount"` // included in Total as its own line item
+	Discount     int64             `json:"discount" bson:"discount"`   // promo code discount, included in Total as a negative line item
 }
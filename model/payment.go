@@ -0,0 +1,27 @@
+package model
+
+// Ways a tow payment can be collected. Stripe payments are recorded from checkout; the rest are entered by staff.
+const (
+	PaymentMethodStripe    = "stripe"     // online checkout
+	PaymentMethodCash      = "cash"       // collected by the driver
+	PaymentMethodCheck     = "check"      // Reference is the check number
+	PaymentMethodCard      = "card"       // card taken outside Stripe checkout, e.g. on a terminal in the truck
+	PaymentMethodMotorClub = "motor_club" // paid by a motor club; Reference is its PO or dispatch number
+	PaymentMethodInsurance = "insurance"  // paid by an insurer; Reference is the claim number
+	PaymentMethodOther     = "other"
+)
+
+// Payment is one entry of a tow's payment ledger. A tow can be paid in parts, e.g. a motor club pays its
+// coverage and the customer pays the rest in cash. Entries are never removed; a mistaken entry is voided.
+type Payment struct {
+	ID              *string `json:"id,omitempty" bson:"id,omitempty"`
+	Method          *string `json:"method,omitempty" bson:"method,omitempty"`                   // stripe, cash, check, card, motor_club, insurance, other
+	Amount          *int    `json:"amount,omitempty" bson:"amount,omitempty"`                   // cents
+	Reference       *string `json:"reference,omitempty" bson:"reference,omitempty"`             // check number, PO or claim number, or the Stripe checkout session ID
+	PaymentIntentID *string `json:"paymentIntentId,omitempty" bson:"paymentIntentId,omitempty"` // Stripe payment intent of a checkout payment; refunds and disputes refer to it
+	Note            *string `json:"note,omitempty" bson:"note,omitempty"`
+	CollectedBy     *string `json:"collectedBy,omitempty" bson:"collectedBy,omitempty"` // user id, or "stripe" for checkout payments
+	CollectedAt     *int64  `json:"collectedAt,omitempty" bson:"collectedAt,omitempty"` // unix seconds
+	VoidedBy        *string `json:"voidedBy,omitempty" bson:"voidedBy,omitempty"`       // user id of whoever voided the entry
+	VoidedAt        *int64  `json:"voidedAt,omitempty" bson:"voidedAt,omitempty"`       // unix seconds; voided entries do not count toward the amount paid
+}
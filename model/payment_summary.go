@@ -0,0 +1,42 @@
+package model
+
+// PaymentSummary adds up a company's payments over a period. Amounts are in cents. Collected amounts, fees and
+// refunds come from the tows' payment ledgers; balances come from the company's Stripe account at the time of
+// the request.
+type PaymentSummary struct {
+	CompanyID            string `json:"companyId"`
+	From                 int64  `json:"from,omitempty"`       // unix seconds, inclusive; 0 when unbounded
+	To                   int64  `json:"to,omitempty"`         // unix seconds, exclusive; 0 when unbounded
+	TowCount             int    `json:"towCount"`             // tows with a payment or refund in the period
+	GrossCollected       int    `json:"grossCollected"`       // all payments not voided, online and offline
+	StripeCollected      int    `json:"stripeCollected"`      // paid through Stripe checkout
+	OfflineCollected     int    `json:"offlineCollected"`     // cash, checks, motor clubs and other recorded payments
+	PlatformFees         int    `json:"platformFees"`         // kept by the platform from Stripe payments
+	Refunds              int    `json:"refunds"`              // refunded to customers, pending refunds included
+	PlatformFeesRefunded int    `json:"platformFeesRefunded"` // share of the platform fees returned with refunds
+	Net                  int    `json:"net"`                  // gross collected less platform fees and refunds
+	StripeNet            int    `json:"stripeNet"`            // what Stripe payments leave the company after fees and refunds
+	AvailableBalance     int64  `json:"availableBalance"`     // in the Stripe account, ready to be paid out
+	PendingBalance       int64  `json:"pendingBalance"`       // in the Stripe account, not settled yet
+	PaidOut              int64  `json:"paidOut"`              // paid out to the company's bank in the period
+	InTransit            int64  `json:"inTransit"`            // payouts of the period on their way to the bank
+	BalanceUnavailable   bool   `json:"balanceUnavailable"`   // Stripe could not be reached; balances and payouts are zero
+}
+
+// Payout is a transfer from a company's Stripe account to its bank, with the tows whose payments it covered.
+type Payout struct {
+	ID          string      `json:"id"`          // Stripe payout ID, e.g. po_...
+	Amount      int64       `json:"amount"`      // cents
+	Currency    string      `json:"currency"`    // e.g. usd
+	Status      string      `json:"status"`      // pending, in_transit, paid, failed, canceled
+	ArrivalDate int64       `json:"arrivalDate"` // unix seconds the money is expected in the bank
+	CreatedAt   int64       `json:"createdAt"`   // unix seconds
+	Tows        []PayoutTow `json:"tows"`
+	OtherAmount int64       `json:"otherAmount"` // net cents not traced to a tow, e.g. payments made before tracking or Stripe adjustments
+}
+
+// PayoutTow is the net amount of a tow's payments and refunds settled by a payout. Amount is in cents.
+type PayoutTow struct {
+	TowID  string `json:"towId"`
+	Amount int64  `json:"amount"`
+}
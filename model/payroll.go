@@ -0,0 +1,42 @@
+package model
+
+// CommissionRule describes how a company pays its drivers for completed tows.
+// A rule without a DriverID is the company default; a rule with a DriverID overrides it for that driver.
+// All amounts are in cents.
+type CommissionRule struct {
+	ID         *string  `json:"id,omitempty" bson:"_id,omitempty"`
+	CompanyID  *string  `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	DriverID   *string  `json:"driverId,omitempty" bson:"driverId,omitempty"`
+	Type       *string  `json:"type,omitempty" bson:"type,omitempty"`             // percentage, flat
+	Percentage *float64 `json:"percentage,omitempty" bson:"percentage,omitempty"` // percent of the tow price, e.g. 25 for 25%
+	FlatAmount *int     `json:"flatAmount,omitempty" bson:"flatAmount,omitempty"` // paid per completed tow
+	HourlyRate *int     `json:"hourlyRate,omitempty" bson:"hourlyRate,omitempty"` // paid on top of the commission for dispatched-to-completed time
+}
+
+// PayrollRun is the set of driver earnings computed for a company over a pay period.
+type PayrollRun struct {
+	ID          *string        `json:"id,omitempty" bson:"_id,omitempty"`
+	CompanyID   *string        `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	PeriodStart *int64         `json:"periodStart,omitempty" bson:"periodStart,omitempty"` // unix seconds, inclusive
+	PeriodEnd   *int64         `json:"periodEnd,omitempty" bson:"periodEnd,omitempty"`     // unix seconds, exclusive
+	Status      *string        `json:"status,omitempty" bson:"status,omitempty"`           // draft, approved
+	Entries     []PayrollEntry `json:"entries,omitempty" bson:"entries,omitempty"`
+	CreatedAt   *int64         `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	ApprovedAt  *int64         `json:"approvedAt,omitempty" bson:"approvedAt,omitempty"`
+	ApprovedBy  *string        `json:"approvedBy,omitempty" bson:"approvedBy,omitempty"`
+}
+
+// PayrollEntry holds one driver's earnings within a PayrollRun. Amounts are in cents.
+type PayrollEntry struct {
+	DriverID       *string  `json:"driverId,omitempty" bson:"driverId,omitempty"`
+	DriverName     *string  `json:"driverName,omitempty" bson:"driverName,omitempty"`
+	TowIDs         []string `json:"towIds,omitempty" bson:"towIds,omitempty"`
+	TowCount       int      `json:"towCount" bson:"towCount"`
+	GrossRevenue   int      `json:"grossRevenue" bson:"grossRevenue"`
+	Commission     int      `json:"commission" bson:"commission"`
+	Hours          float64  `json:"hours" bson:"hours"`
+	HourlyPay      int      `json:"hourlyPay" bson:"hourlyPay"`
+	Adjustment     int      `json:"adjustment" bson:"adjustment"`
+	AdjustmentNote *string  `json:"adjustmentNote,omitempty" bson:"adjustmentNote,omitempty"`
+	Total          int      `json:"total" bson:"total"`
+}
@@ -1,8 +1,71 @@
 package model
 
+// Price rule types understood by the pricing engine.
+const (
+	PriceTypeFlat       = "flat"              // fixed amount per tow, e.g. a hook-up fee
+	PriceTypePerMile    = "per_mile"          // amount per mile from pickup to destination
+	PriceTypePerMinute  = "per_minute"        // amount per minute of drive time from pickup to destination
+	PriceTypePercentage = "percentage"        // percent of the subtotal of the flat and rate-based items
+	PriceTypeMinimum    = "minimum"           // minimum total charge for a tow
+	PriceTypeEnRoute    = "en_route_per_mile" // amount per mile from the company yard to the pickup
+	PriceTypeReturn     = "return_per_mile"   // amount per mile from the destination back to the company yard
+)
+
+// Price is a single rule of a company's price list. Amount is in cents; Percent is only used by percentage rules.
+// A rule without a Type is treated as a legacy "Hook Up Fee" or "Per Mile Amount" item.
 type Price struct {
-	ID        *string `json:"id,omitempty" bson:"_id,omitempty"`
-	ItemName  *string `json:"itemName,omitempty" bson:"itemName,omitempty"`
-	Amount    *int    `json:"amount,omitempty" bson:"amount,omitempty"`
-	CompanyID *string `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	ID        *string         `json:"id,omitempty" bson:"_id,omitempty"`
+	ItemName  *string         `json:"itemName,omitempty" bson:"itemName,omitempty"`
+	Amount    *int            `json:"amount,omitempty" bson:"amount,omitempty"`
+	CompanyID *string         `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	Type      *string         `json:"type,omitempty" bson:"type,omitempty"`       // flat, per_mile, per_minute, percentage, minimum, en_route_per_mile, return_per_mile
+	Percent   *float64        `json:"percent,omitempty" bson:"percent,omitempty"` // e.g. 10 for 10%
+	Condition *PriceCondition `json:"condition,omitempty" bson:"condition,omitempty"`
+	// IncludedMiles and Tiers only apply to per-mile rules (per_mile, en_route_per_mile, return_per_mile)
+	IncludedMiles *float64      `json:"includedMiles,omitempty" bson:"includedMiles,omitempty"` // miles charged at no cost before the rate starts, e.g. a free en-route radius
+	Tiers         []MileageTier `json:"tiers,omitempty" bson:"tiers,omitempty"`                 // replaces Amount with banded rates
+	Taxable       *bool         `json:"taxable,omitempty" bson:"taxable,omitempty"`             // include the item in the sales tax base
+	Archived      *bool         `json:"archived,omitempty" bson:"archived,omitempty"`           // retired from the catalog but kept because tows were charged for it
+}
+
+// MileageTier charges Amount cents per mile for the miles up to UpToMiles (counted from zero, after the
+// previous tier). Miles beyond the last tier are charged at the last tier's rate.
+type MileageTier struct {
+	UpToMiles *float64 `json:"upToMiles,omitempty" bson:"upToMiles,omitempty"` // nil for an open-ended last tier
+	Amount    *int     `json:"amount,omitempty" bson:"amount,omitempty"`
+}
+
+// Mileage rounding rules applied to the routed distance before pricing.
+const (
+	MileageRoundingExact = "exact"      // bill the routed distance as-is
+	MileageRoundingTenth = "tenth_mile" // round up to the next tenth of a mile
+	MileageRoundingWhole = "whole_mile" // round up to the next whole mile
+)
+
+// Vehicle classes used to price tows by vehicle size and type.
+const (
+	VehicleClassLight      = "light"
+	VehicleClassMedium     = "medium"
+	VehicleClassHeavy      = "heavy"
+	VehicleClassMotorcycle = "motorcycle"
+	VehicleClassExotic     = "exotic"
+)
+
+// PriceCondition limits when a price rule applies. An empty condition always applies.
+// When several rules share an item name and type, the most specific applicable rule wins.
+type PriceCondition struct {
+	MinMiles       *float64     `json:"minMiles,omitempty" bson:"minMiles,omitempty"`             // applies when the trip is at least this long
+	MaxMiles       *float64     `json:"maxMiles,omitempty" bson:"maxMiles,omitempty"`             // applies when the trip is shorter than this
+	TimeWindows    []TimeWindow `json:"timeWindows,omitempty" bson:"timeWindows,omitempty"`       // applies when the tow time falls in any window
+	Holiday        *bool        `json:"holiday,omitempty" bson:"holiday,omitempty"`               // applies only on the company's holidays
+	VehicleClasses []string     `json:"vehicleClasses,omitempty" bson:"vehicleClasses,omitempty"` // applies when the vehicle is one of these classes
+	AddOns         []string     `json:"addOns,omitempty" bson:"addOns,omitempty"`                 // applies when the tow uses any of these add-ons
+}
+
+// TimeWindow is a daily period in the company's timezone, e.g. 18:00-06:00 on weekdays.
+// A window whose End is before its Start runs past midnight and belongs to the day it starts on.
+type TimeWindow struct {
+	Days  []string `json:"days,omitempty" bson:"days,omitempty"`   // mon, tue, wed, thu, fri, sat, sun; empty means every day
+	Start *string  `json:"start,omitempty" bson:"start,omitempty"` // HH:MM, inclusive
+	End   *string  `json:"end,omitempty" bson:"end,omitempty"`     // HH:MM, exclusive
 }
@@ -0,0 +1,16 @@
+package model
+
+// PriceList is a versioned snapshot of a company's price rules. Drafts can be edited freely; once published
+// a version is immutable and applies to tows priced at or after EffectiveFrom, until a later version takes effect.
+type PriceList struct {
+	ID            *string  `json:"id,omitempty" bson:"_id,omitempty"`
+	CompanyID     *string  `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	Version       *int     `json:"version,omitempty" bson:"version,omitempty"` // increments per company
+	Status        *string  `json:"status,omitempty" bson:"status,omitempty"`   // draft, published
+	Prices        []*Price `json:"prices,omitempty" bson:"prices,omitempty"`
+	Notes         *string  `json:"notes,omitempty" bson:"notes,omitempty"`
+	EffectiveFrom *int64   `json:"effectiveFrom,omitempty" bson:"effectiveFrom,omitempty"` // unix seconds; set when published
+	CreatedAt     *int64   `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	PublishedAt   *int64   `json:"publishedAt,omitempty" bson:"publishedAt,omitempty"`
+	PublishedBy   *string  `json:"publishedBy,omitempty" bson:"publishedBy,omitempty"` // user id
+}
@@ -0,0 +1,29 @@
+package model
+
+// PriceSimulation is the result of replaying a proposed price list over a company's completed tows.
+// Prices are compared before sales tax and promo discounts, so the delta is the change in list revenue.
+// All amounts are in cents.
+type PriceSimulation struct {
+	CompanyID    *string              `json:"companyId,omitempty"`
+	PriceListID  *string              `json:"priceListId,omitempty"` // draft or published list that was replayed; empty for inline prices
+	From         int64                `json:"from"`                  // unix seconds, inclusive
+	To           int64                `json:"to"`                    // unix seconds, exclusive
+	Tows         []PriceSimulationTow `json:"tows"`
+	TowCount     int                  `json:"towCount"`     // tows that were repriced
+	SkippedCount int                  `json:"skippedCount"` // tows without a stored route or price, or that the proposed list could not price
+	OldRevenue   int64                `json:"oldRevenue"`
+	NewRevenue   int64                `json:"newRevenue"`
+	RevenueDelta int64                `json:"revenueDelta"`
+	DeltaPercent float64              `json:"deltaPercent"` // RevenueDelta as a percent of OldRevenue
+}
+
+// PriceSimulationTow compares what one tow was charged with what the proposed price list would charge.
+type PriceSimulationTow struct {
+	TowID       *string           `json:"towId,omitempty"`
+	CompletedAt int64             `json:"completedAt"`
+	OldPrice    int64             `json:"oldPrice"`
+	NewPrice    int64             `json:"newPrice"`
+	Delta       int64             `json:"delta"`
+	LineItems   []PayableLineItem `json:"lineItems,omitempty"` // itemized new price
+	Skipped     *string           `json:"skipped,omitempty"`   // reason the tow could not be repriced
+}
@@ -0,0 +1,21 @@
+package model
+
+// PricingZone is an area with its own pricing, defined by a GeoJSON polygon, a list of ZIP codes, or both.
+// A zone applies to a tow when both the pickup and the destination are inside it. Amounts are in cents.
+type PricingZone struct {
+	ID            *string         `json:"id,omitempty" bson:"_id,omitempty"`
+	CompanyID     *string         `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	Name          *string         `json:"name,omitempty" bson:"name,omitempty"`
+	Polygon       *GeoJSONPolygon `json:"polygon,omitempty" bson:"polygon,omitempty"`
+	PostalCodes   []string        `json:"postalCodes,omitempty" bson:"postalCodes,omitempty"`
+	FlatPrice     *int            `json:"flatPrice,omitempty" bson:"flatPrice,omitempty"`         // replaces the base hook-up and distance/time charges
+	PerMileAmount *int            `json:"perMileAmount,omitempty" bson:"perMileAmount,omitempty"` // replaces the base per-mile rate
+	Priority      *int            `json:"priority,omitempty" bson:"priority,omitempty"`           // higher wins when zones overlap
+}
+
+// GeoJSONPolygon is a GeoJSON Polygon geometry. Positions are [longitude, latitude]; the first ring is the
+// boundary and any further rings are holes.
+type GeoJSONPolygon struct {
+	Type        string        `json:"type" bson:"type"` // always "Polygon"
+	Coordinates [][][]float64 `json:"coordinates" bson:"coordinates"`
+}
@@ -0,0 +1,38 @@
+package model
+
+// Promo code discount types.
+const (
+	PromoTypePercentage = "percentage" // Percent off the pre-tax total
+	PromoTypeFixed      = "fixed"      // Amount cents off the pre-tax total
+)
+
+// PromoCode is a company-defined discount customers can apply when booking online. Amounts are in cents.
+type PromoCode struct {
+	ID                 *string  `json:"id,omitempty" bson:"_id,omitempty"`
+	CompanyID          *string  `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	Code               *string  `json:"code,omitempty" bson:"code,omitempty"`               // stored uppercase; matched case-insensitively
+	Description        *string  `json:"description,omitempty" bson:"description,omitempty"` // e.g. the partner running the promotion
+	Type               *string  `json:"type,omitempty" bson:"type,omitempty"`               // percentage, fixed
+	Percent            *float64 `json:"percent,omitempty" bson:"percent,omitempty"`
+	Amount             *int     `json:"amount,omitempty" bson:"amount,omitempty"`
+	MinOrder           *int     `json:"minOrder,omitempty" bson:"minOrder,omitempty"`                     // minimum pre-tax total the code applies to
+	MaxUses            *int     `json:"maxUses,omitempty" bson:"maxUses,omitempty"`                       // total bookings allowed; unlimited when unset
+	MaxUsesPerCustomer *int     `json:"maxUsesPerCustomer,omitempty" bson:"maxUsesPerCustomer,omitempty"` // bookings allowed per customer email
+	ValidFrom          *int64   `json:"validFrom,omitempty" bson:"validFrom,omitempty"`                   // unix seconds
+	ValidUntil         *int64   `json:"validUntil,omitempty" bson:"validUntil,omitempty"`                 // unix seconds, exclusive
+	Active             *bool    `json:"active,omitempty" bson:"active,omitempty"`
+	Uses               *int     `json:"uses,omitempty" bson:"uses,omitempty"` // bookings made with the code
+	CreatedAt          *int64   `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+}
+
+// PromoRedemption records a booking made with a promo code, for redemption reporting and per-customer limits.
+type PromoRedemption struct {
+	ID             *string `json:"id,omitempty" bson:"_id,omitempty"`
+	CompanyID      *string `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	PromoCodeID    *string `json:"promoCodeId,omitempty" bson:"promoCodeId,omitempty"`
+	Code           *string `json:"code,omitempty" bson:"code,omitempty"`
+	TowID          *string `json:"towId,omitempty" bson:"towId,omitempty"`
+	CustomerEmail  *string `json:"customerEmail,omitempty" bson:"customerEmail,omitempty"` // lowercased
+	DiscountAmount *int    `json:"discountAmount,omitempty" bson:"discountAmount,omitempty"`
+	RedeemedAt     *int64  `json:"redeemedAt,omitempty" bson:"redeemedAt,omitempty"`
+}
@@ -0,0 +1,23 @@
+package model
+
+// Quote is a saved price estimate. A customer can book it at the locked price until it expires.
+type Quote struct {
+	ID                     *string         `json:"id,omitempty" bson:"_id,omitempty"`
+	CompanyID              *string         `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	Pickup                 *string         `json:"pickup,omitempty" bson:"pickup,omitempty"`
+	Destination            *string         `json:"destination,omitempty" bson:"destination,omitempty"`
+	PickupCoordinates      []float64       `json:"pickupCoordinates,omitempty" bson:"pickupCoordinates,omitempty"`           // [longitude, latitude]
+	DestinationCoordinates []float64       `json:"destinationCoordinates,omitempty" bson:"destinationCoordinates,omitempty"` // [longitude, latitude]
+	Vehicle                *Vehicle        `json:"vehicle,omitempty" bson:"vehicle,omitempty"`
+	AddOns                 []string        `json:"addOns,omitempty" bson:"addOns,omitempty"`
+	ScheduledAt            *int64          `json:"scheduledAt,omitempty" bson:"scheduledAt,omitempty"`
+	Breakdown              *PriceBreakdown `json:"breakdown,omitempty" bson:"breakdown,omitempty"`
+	PriceListID            *string         `json:"priceListId,omitempty" bson:"priceListId,omitempty"`
+	PriceListVersion       *int            `json:"priceListVersion,omitempty" bson:"priceListVersion,omitempty"`
+	PromoCode              *string         `json:"promoCode,omitempty" bson:"promoCode,omitempty"`
+	PricingZoneID          *string         `json:"pricingZoneId,omitempty" bson:"pricingZoneId,omitempty"`
+	Link                   *string         `json:"link,omitempty" bson:"link,omitempty"` // shareable page for the quote
+	CreatedAt              *int64          `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	ExpiresAt              *int64          `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+	TowID                  *string         `json:"towId,omitempty" bson:"towId,omitempty"` // set once the quote has been booked
+}
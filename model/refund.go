@@ -0,0 +1,12 @@
+package model
+
+// Refund is money returned to the customer for a tow, mirrored from the Stripe refund. Amount is in cents.
+type Refund struct {
+	ID              *string `json:"id,omitempty" bson:"id,omitempty"`                           // Stripe refund ID, e.g. re_...
+	Amount          *int    `json:"amount,omitempty" bson:"amount,omitempty"`                   // cents
+	Reason          *string `json:"reason,omitempty" bson:"reason,omitempty"`                   // free text from whoever issued it, or the Stripe reason
+	IssuedBy        *string `json:"issuedBy,omitempty" bson:"issuedBy,omitempty"`               // user id, or "stripe" for refunds made in the Stripe dashboard
+	Status          *string `json:"status,omitempty" bson:"status,omitempty"`                   // pending, succeeded, failed, canceled, requires_action
+	CreatedAt       *int64  `json:"createdAt,omitempty" bson:"createdAt,omitempty"`             // unix seconds
+	PaymentIntentID *string `json:"paymentIntentId,omitempty" bson:"paymentIntentId,omitempty"` // Stripe payment the money was returned from
+}
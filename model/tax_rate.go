@@ -0,0 +1,13 @@
+package model
+
+// TaxRate is a sales tax rate a company collects in a jurisdiction. A rate matches a tow's pickup address by
+// ZIP code, county or state; the most specific match wins, so rates should be the combined rate for the area.
+type TaxRate struct {
+	ID         *string  `json:"id,omitempty" bson:"_id,omitempty"`
+	CompanyID  *string  `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	Name       *string  `json:"name,omitempty" bson:"name,omitempty"`             // shown on the line item, e.g. "Travis County Sales Tax"
+	State      *string  `json:"state,omitempty" bson:"state,omitempty"`           // two letter state code, e.g. TX
+	County     *string  `json:"county,omitempty" bson:"county,omitempty"`         // county name without the "County" suffix; requires State
+	PostalCode *string  `json:"postalCode,omitempty" bson:"postalCode,omitempty"` // five digit ZIP code
+	Rate       *float64 `json:"rate,omitempty" bson:"rate,omitempty"`             // percent, e.g. 8.25 for 8.25%
+}
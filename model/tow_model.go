@@ -6,6 +6,7 @@ type Vehicle struct {
 	Model       *string `json:"model,omitempty" bson:"model,omitempty"`
 	State       *string `json:"state,omitempty" bson:"state,omitempty"`
 	PlateNumber *string `json:"plateNumber,omitempty" bson:"plateNumber,omitempty"`
+	Class       *string `json:"class,omitempty" bson:"class,omitempty"` // light, medium, heavy, motorcycle, exotic; inferred from make/model at booking, only dispatch may override it
 }
 
 type PrimaryContact struct {
@@ -16,19 +17,52 @@ type PrimaryContact struct {
 }
 
 type Tow struct {
-	ID               *string         `json:"id,omitempty" bson:"_id,omitempty"`
-	Destination      *string         `json:"destination,omitempty" bson:"destination,omitempty"`
-	Pickup           *string         `json:"pickup,omitempty" bson:"pickup,omitempty"`
-	Vehicle          *Vehicle        `json:"vehicle,omitempty" bson:"vehicle,omitempty"`
-	PrimaryContact   *PrimaryContact `json:"primaryContact,omitempty" bson:"primaryContact,omitempty"`
-	Attachments      []string        `json:"attachments,omitempty" bson:"attachments,omitempty"`
-	Notes            *string         `json:"notes,omitempty" bson:"notes,omitempty"`
-	History          []string        `json:"history,omitempty" bson:"history,omitempty"`
-	Status           *string         `json:"status,omitempty" bson:"status,omitempty"`                     // pending, accepted, dispatched, arrived_pickup, in_transit, completed, cancelled
-	PaymentStatus    *string         `json:"paymentStatus,omitempty" bson:"paymentStatus,omitempty"`       // unpaid, paid
-	PaymentReference *string         `json:"paymentReference,omitempty" bson:"paymentReference,omitempty"` // payment reference id from stripe
-	CheckoutUrl      *string         `json:"checkoutUrl,omitempty" bson:"checkoutUrl,omitempty"`           // Stripe checkout session URL
-	CompanyID        *string         `json:"companyId,omitempty" bson:"companyId,omitempty"`
-	CreatedAt        *int64          `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
-	Price            *int            `json:"price,omitempty" bson:"price,omitempty"`
+	ID                     *string           `json:"id,omitempty" bson:"_id,omitempty"`
+	Destination            *string           `json:"destination,omitempty" bson:"destination,omitempty"`
+	Pickup                 *string           `json:"pickup,omitempty" bson:"pickup,omitempty"`
+	Vehicle                *Vehicle          `json:"vehicle,omitempty" bson:"vehicle,omitempty"`
+	PrimaryContact         *PrimaryContact   `json:"primaryContact,omitempty" bson:"primaryContact,omitempty"`
+	Attachments            []string          `json:"attachments,omitempty" bson:"attachments,omitempty"`
+	Notes                  *string           `json:"notes,omitempty" bson:"notes,omitempty"`
+	History                []string          `json:"history,omitempty" bson:"history,omitempty"`
+	Status                 *string           `json:"status,omitempty" bson:"status,omitempty"`                     // pending, accepted, dispatched, arrived_pickup, in_transit, completed, cancelled
+	PaymentStatus          *string           `json:"paymentStatus,omitempty" bson:"paymentStatus,omitempty"`       // unpaid, partial, paid, overpaid, failed, expired, refunded, partially_refunded, disputed, dispute_lost; derived from the payment ledger
+	PaymentReference       *string           `json:"paymentReference,omitempty" bson:"paymentReference,omitempty"` // payment reference id from stripe
+	PaymentIntentID        *string           `json:"paymentIntentId,omitempty" bson:"paymentIntentId,omitempty"`   // Stripe payment intent of the latest checkout; each Stripe payment in the ledger keeps its own
+	ApplicationFee         *int              `json:"applicationFee,omitempty" bson:"applicationFee,omitempty"`     // platform fee kept from Price; the rest is transferred to the company
+	Refunds                []Refund          `json:"refunds,omitempty" bson:"refunds,omitempty"`                   // refunds issued against the payment, synced from Stripe
+	RefundedAmount         *int              `json:"refundedAmount,omitempty" bson:"refundedAmount,omitempty"`     // cents refunded so far; pending refunds included
+	Dispute                *Dispute          `json:"dispute,omitempty" bson:"dispute,omitempty"`                   // latest chargeback on the tow's payment, synced from Stripe
+	Payments               []Payment         `json:"payments,omitempty" bson:"payments,omitempty"`                 // payment ledger across Stripe and offline methods
+	PaidAmount             *int              `json:"paidAmount,omitempty" bson:"paidAmount,omitempty"`             // cents collected by payments that were not voided
+	BalanceDue             *int              `json:"balanceDue,omitempty" bson:"balanceDue,omitempty"`             // Price minus PaidAmount plus RefundedAmount; negative when overpaid
+	CheckoutUrl            *string           `json:"checkoutUrl,omitempty" bson:"checkoutUrl,omitempty"`           // Stripe checkout session URL
+	CheckoutEventAt        *int64            `json:"checkoutEventAt,omitempty" bson:"checkoutEventAt,omitempty"`   // creation time of the last Stripe checkout event applied; older deliveries are ignored
+	AccessToken            *string           `json:"-" bson:"accessToken,omitempty"`                               // secret in the checkout return URL that lets the customer view the payment outcome; never sent in API responses
+	InvoiceNumber          *string           `json:"invoiceNumber,omitempty" bson:"invoiceNumber,omitempty"`       // sequential per company, assigned when the first invoice is generated
+	InvoicedAt             *int64            `json:"invoicedAt,omitempty" bson:"invoicedAt,omitempty"`             // unix seconds the invoice number was assigned
+	CompanyID              *string           `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	CreatedAt              *int64            `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	Price                  *int              `json:"price,omitempty" bson:"price,omitempty"`
+	DriverID               *string           `json:"driverId,omitempty" bson:"driverId,omitempty"`         // user id of the driver who ran the tow
+	DispatchedAt           *int64            `json:"dispatchedAt,omitempty" bson:"dispatchedAt,omitempty"` // set when the tow moves to dispatched
+	CompletedAt            *int64            `json:"completedAt,omitempty" bson:"completedAt,omitempty"`   // set when the tow moves to completed
+	TruckID                *string           `json:"truckId,omitempty" bson:"truckId,omitempty"`           // truck assigned to the tow
+	LineItems              []PayableLineItem `json:"lineItems,omitempty" bson:"lineItems,omitempty"`       // itemized price the customer was charged
+	Miles                  *float64          `json:"miles,omitempty" bson:"miles,omitempty"`               // routed distance from pickup to destination
+	Minutes                *float64          `json:"minutes,omitempty" bson:"minutes,omitempty"`           // routed drive time from pickup to destination
+	BilledMiles            *float64          `json:"billedMiles,omitempty" bson:"billedMiles,omitempty"`   // distance charged after the company's mileage rounding
+	ScheduledAt            *int64            `json:"scheduledAt,omitempty" bson:"scheduledAt,omitempty"`   // requested service time; time-based prices use it instead of the request time
+	AddOns                 []string          `json:"addOns,omitempty" bson:"addOns,omitempty"`             // extra services used on the tow, e.g. awd, dolly
+	QuoteID                *string           `json:"quoteId,omitempty" bson:"quoteId,omitempty"`           // quote the tow was booked from, at the quoted price
+	PriceListID            *string           `json:"priceListId,omitempty" bson:"priceListId,omitempty"`   // published price list the tow was priced with; empty for the legacy catalog
+	PriceListVersion       *int              `json:"priceListVersion,omitempty" bson:"priceListVersion,omitempty"`
+	EnRouteMiles           *float64          `json:"enRouteMiles,omitempty" bson:"enRouteMiles,omitempty"`                     // routed distance from the company yard to the pickup
+	ReturnMiles            *float64          `json:"returnMiles,omitempty" bson:"returnMiles,omitempty"`                       // routed distance from the destination back to the yard
+	PricingZoneID          *string           `json:"pricingZoneId,omitempty" bson:"pricingZoneId,omitempty"`                   // zone whose pricing applied to the tow
+	TaxAmount              *int              `json:"taxAmount,omitempty" bson:"taxAmount,omitempty"`                           // sales tax included in Price
+	PromoCode              *string           `json:"promoCode,omitempty" bson:"promoCode,omitempty"`                           // discount code applied by the customer
+	DiscountAmount         *int              `json:"discountAmount,omitempty" bson:"discountAmount,omitempty"`                 // discount taken off Price by the promo code
+	PickupCoordinates      []float64         `json:"pickupCoordinates,omitempty" bson:"pickupCoordinates,omitempty"`           // [longitude, latitude]
+	DestinationCoordinates []float64         `json:"destinationCoordinates,omitempty" bson:"destinationCoordinates,omitempty"` // [longitude, latitude]
 }
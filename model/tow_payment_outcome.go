@@ -0,0 +1,11 @@
+package model
+
+// TowPaymentOutcome is what a checkout return page shows the customer about their payment.
+type TowPaymentOutcome struct {
+	TowID         string `json:"towId"`
+	PaymentStatus string `json:"paymentStatus"`         // the tow's payment status after syncing with Stripe
+	CheckoutState string `json:"checkoutState"`         // open, complete or expired
+	AmountTotal   int64  `json:"amountTotal"`           // cents charged at checkout
+	BalanceDue    int    `json:"balanceDue"`            // cents still owed after all payments, e.g. when part was paid in cash
+	CheckoutUrl   string `json:"checkoutUrl,omitempty"` // set while the checkout can still be paid
+}
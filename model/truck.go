@@ -0,0 +1,37 @@
+package model
+
+type Truck struct {
+	ID               *string           `json:"id,omitempty" bson:"_id,omitempty"`
+	CompanyID        *string           `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	UnitNumber       *string           `json:"unitNumber,omitempty" bson:"unitNumber,omitempty"`
+	VIN              *string           `json:"vin,omitempty" bson:"vin,omitempty"`
+	Year             *string           `json:"year,omitempty" bson:"year,omitempty"`
+	Make             *string           `json:"make,omitempty" bson:"make,omitempty"`
+	Model            *string           `json:"model,omitempty" bson:"model,omitempty"`
+	PlateNumber      *string           `json:"plateNumber,omitempty" bson:"plateNumber,omitempty"`
+	Odometer         *int              `json:"odometer,omitempty" bson:"odometer,omitempty"`         // miles, updated from inspections and work orders
+	Status           *string           `json:"status,omitempty" bson:"status,omitempty"`             // in_service, out_of_service
+	StatusReason     *string           `json:"statusReason,omitempty" bson:"statusReason,omitempty"` // why the truck is out of service: manual, defects
+	ServiceIntervals []ServiceInterval `json:"serviceIntervals,omitempty" bson:"serviceIntervals,omitempty"`
+	CreatedAt        *int64            `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+}
+
+// ServiceInterval is a recurring maintenance item that falls due by mileage, by elapsed days, or both.
+type ServiceInterval struct {
+	Name                *string `json:"name,omitempty" bson:"name,omitempty"` // e.g. "Oil Change"
+	EveryMiles          *int    `json:"everyMiles,omitempty" bson:"everyMiles,omitempty"`
+	EveryDays           *int    `json:"everyDays,omitempty" bson:"everyDays,omitempty"`
+	LastServiceOdometer *int    `json:"lastServiceOdometer,omitempty" bson:"lastServiceOdometer,omitempty"`
+	LastServiceAt       *int64  `json:"lastServiceAt,omitempty" bson:"lastServiceAt,omitempty"`
+}
+
+// MaintenanceDue describes a service interval that is due or overdue on a truck.
+type MaintenanceDue struct {
+	TruckID       *string `json:"truckId,omitempty"`
+	UnitNumber    *string `json:"unitNumber,omitempty"`
+	Interval      *string `json:"interval,omitempty"`
+	DueOdometer   *int    `json:"dueOdometer,omitempty"`
+	DueAt         *int64  `json:"dueAt,omitempty"`
+	CurrentMiles  *int    `json:"currentMiles,omitempty"`
+	OverdueByDays *int    `json:"overdueByDays,omitempty"`
+}
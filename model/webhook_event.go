@@ -0,0 +1,24 @@
+package model
+
+// Processing statuses of a WebhookEvent.
+const (
+	WebhookEventStatusPending    = "pending"    // recorded, not yet handled
+	WebhookEventStatusProcessing = "processing" // claimed by an invocation until LockedUntil
+	WebhookEventStatusProcessed  = "processed"
+	WebhookEventStatusFailed     = "failed" // the handler returned an error; Stripe's retry or a replay picks it up
+)
+
+// WebhookEvent is a Stripe webhook delivery as received, keyed by the Stripe event ID so redeliveries are
+// recognized. Payload is the raw, already verified request body.
+type WebhookEvent struct {
+	ID             *string `json:"id,omitempty" bson:"_id,omitempty"` // Stripe event ID, e.g. evt_...
+	Type           *string `json:"type,omitempty" bson:"type,omitempty"`
+	Payload        *string `json:"payload,omitempty" bson:"payload,omitempty"`
+	EventCreatedAt *int64  `json:"eventCreatedAt,omitempty" bson:"eventCreatedAt,omitempty"` // when Stripe created the event; deliveries may arrive out of this order, so tows keep the time of the last checkout event applied
+	ReceivedAt     *int64  `json:"receivedAt,omitempty" bson:"receivedAt,omitempty"`
+	Status         *string `json:"status,omitempty" bson:"status,omitempty"` // pending, processing, processed, failed
+	Attempts       *int    `json:"attempts,omitempty" bson:"attempts,omitempty"`
+	LastError      *string `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	LockedUntil    *int64  `json:"lockedUntil,omitempty" bson:"lockedUntil,omitempty"` // end of the current processing lease
+	ProcessedAt    *int64  `json:"processedAt,omitempty" bson:"processedAt,omitempty"`
+}
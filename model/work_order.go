@@ -0,0 +1,15 @@
+package model
+
+type WorkOrder struct {
+	ID              *string `json:"id,omitempty" bson:"_id,omitempty"`
+	CompanyID       *string `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	TruckID         *string `json:"truckId,omitempty" bson:"truckId,omitempty"`
+	InspectionID    *string `json:"inspectionId,omitempty" bson:"inspectionId,omitempty"`       // set when opened from an inspection defect
+	ServiceInterval *string `json:"serviceInterval,omitempty" bson:"serviceInterval,omitempty"` // name of the service interval this work order performs
+	Description     *string `json:"description,omitempty" bson:"description,omitempty"`
+	Status          *string `json:"status,omitempty" bson:"status,omitempty"` // open, completed
+	Odometer        *int    `json:"odometer,omitempty" bson:"odometer,omitempty"`
+	Notes           *string `json:"notes,omitempty" bson:"notes,omitempty"`
+	CreatedAt       *int64  `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	CompletedAt     *int64  `json:"completedAt,omitempty" bson:"completedAt,omitempty"`
+}
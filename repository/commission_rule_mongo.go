@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"tow-management-system-api/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CommissionRuleMongoRepository handles MongoDB operations for the CommissionRule model.
+type CommissionRuleMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoCommissionRuleRepository creates a new CommissionRuleMongoRepository instance.
+func NewMongoCommissionRuleRepository(db *mongo.Database, collectionName string) *CommissionRuleMongoRepository {
+	return &CommissionRuleMongoRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Create inserts a new commission rule document into MongoDB.
+func (r *CommissionRuleMongoRepository) Create(ctx context.Context, rule *model.CommissionRule) error {
+	_, err := r.collection.InsertOne(ctx, rule)
+	if err != nil {
+		return fmt.Errorf("failed to create commission rule: %w", err)
+	}
+	return nil
+}
+
+// Find retrieves commission rules matching the provided filter struct.
+func (r *CommissionRuleMongoRepository) Find(ctx context.Context, filterModel *model.CommissionRule) ([]*model.CommissionRule, error) {
+	bsonBytes, err := bson.Marshal(filterModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal commission rule filter: %w", err)
+	}
+
+	var filter bson.M
+	if err := bson.Unmarshal(bsonBytes, &filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal commission rule filter: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find commission rules: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.CommissionRule
+	for cursor.Next(ctx) {
+		var item model.CommissionRule
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode commission rule document: %w", err)
+		}
+		results = append(results, &item)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// Update modifies a commission rule document by ID.
+func (r *CommissionRuleMongoRepository) Update(ctx context.Context, id string, updateData *model.CommissionRule) error {
+	bsonBytes, err := bson.Marshal(updateData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal commission rule update data: %w", err)
+	}
+
+	var updateFields bson.M
+	if err := bson.Unmarshal(bsonBytes, &updateFields); err != nil {
+		return fmt.Errorf("failed to unmarshal commission rule update fields: %w", err)
+	}
+
+	// Never allow updating the _id via $set
+	delete(updateFields, "_id")
+
+	update := bson.M{"$set": updateFields}
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update commission rule: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("commission rule with id %s not found", id)
+	}
+
+	return nil
+}
+
+// Delete removes a commission rule document by ID.
+func (r *CommissionRuleMongoRepository) Delete(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id}
+
+	_, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete commission rule: %w", err)
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // CompanyMongoRepository handles MongoDB operations for the Company model.
@@ -102,6 +103,28 @@ func (r *CompanyMongoRepository) Update(ctx context.Context, id string, updateDa
 
 }
 
+// NextInvoiceNumber atomically increments the company's invoice sequence and returns the new number, so
+// concurrent invoices never share a number.
+func (r *CompanyMongoRepository) NextInvoiceNumber(ctx context.Context, id string) (int, error) {
+	filter := bson.M{"_id": id}
+	update := bson.M{"$inc": bson.M{"invoiceSequence": 1}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var company model.Company
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&company)
+	if err == mongo.ErrNoDocuments {
+		return 0, fmt.Errorf("company with id %s not found", id)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment invoice sequence: %w", err)
+	}
+	if company.InvoiceSequence == nil {
+		return 0, fmt.Errorf("invoice sequence was not incremented")
+	}
+
+	return *company.InvoiceSequence, nil
+}
+
 // Delete removes a company document by ID.
 func (r *CompanyMongoRepository) Delete(ctx context.Context, id string) error {
 
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"tow-management-system-api/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// InspectionMongoRepository handles MongoDB operations for the Inspection model.
+type InspectionMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoInspectionRepository creates a new InspectionMongoRepository instance.
+func NewMongoInspectionRepository(db *mongo.Database, collectionName string) *InspectionMongoRepository {
+	return &InspectionMongoRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Create inserts a new inspection document into MongoDB.
+func (r *InspectionMongoRepository) Create(ctx context.Context, inspection *model.Inspection) error {
+	_, err := r.collection.InsertOne(ctx, inspection)
+	if err != nil {
+		return fmt.Errorf("failed to create inspection: %w", err)
+	}
+	return nil
+}
+
+// Find retrieves inspections matching the provided filter struct.
+func (r *InspectionMongoRepository) Find(ctx context.Context, filterModel *model.Inspection) ([]*model.Inspection, error) {
+	bsonBytes, err := bson.Marshal(filterModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal inspection filter: %w", err)
+	}
+
+	var filter bson.M
+	if err := bson.Unmarshal(bsonBytes, &filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inspection filter: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find inspections: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.Inspection
+	for cursor.Next(ctx) {
+		var item model.Inspection
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode inspection document: %w", err)
+		}
+		results = append(results, &item)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// Update modifies a inspection document by ID.
+func (r *InspectionMongoRepository) Update(ctx context.Context, id string, updateData *model.Inspection) error {
+	bsonBytes, err := bson.Marshal(updateData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal inspection update data: %w", err)
+	}
+
+	var updateFields bson.M
+	if err := bson.Unmarshal(bsonBytes, &updateFields); err != nil {
+		return fmt.Errorf("failed to unmarshal inspection update fields: %w", err)
+	}
+
+	// Never allow updating the _id via $set
+	delete(updateFields, "_id")
+
+	update := bson.M{"$set": updateFields}
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update inspection: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("inspection with id %s not found", id)
+	}
+
+	return nil
+}
+
+// Delete removes a inspection document by ID.
+func (r *InspectionMongoRepository) Delete(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id}
+
+	_, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete inspection: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"tow-management-system-api/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PayrollRunMongoRepository handles MongoDB operations for the PayrollRun model.
+type PayrollRunMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoPayrollRunRepository creates a new PayrollRunMongoRepository instance.
+func NewMongoPayrollRunRepository(db *mongo.Database, collectionName string) *PayrollRunMongoRepository {
+	return &PayrollRunMongoRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Create inserts a new payroll run document into MongoDB.
+func (r *PayrollRunMongoRepository) Create(ctx context.Context, run *model.PayrollRun) error {
+	_, err := r.collection.InsertOne(ctx, run)
+	if err != nil {
+		return fmt.Errorf("failed to create payroll run: %w", err)
+	}
+	return nil
+}
+
+// Find retrieves payroll runs matching the provided filter struct.
+func (r *PayrollRunMongoRepository) Find(ctx context.Context, filterModel *model.PayrollRun) ([]*model.PayrollRun, error) {
+	bsonBytes, err := bson.Marshal(filterModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payroll run filter: %w", err)
+	}
+
+	var filter bson.M
+	if err := bson.Unmarshal(bsonBytes, &filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payroll run filter: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find payroll runs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.PayrollRun
+	for cursor.Next(ctx) {
+		var item model.PayrollRun
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode payroll run document: %w", err)
+		}
+		results = append(results, &item)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// Update modifies a payroll run document by ID.
+func (r *PayrollRunMongoRepository) Update(ctx context.Context, id string, updateData *model.PayrollRun) error {
+	bsonBytes, err := bson.Marshal(updateData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payroll run update data: %w", err)
+	}
+
+	var updateFields bson.M
+	if err := bson.Unmarshal(bsonBytes, &updateFields); err != nil {
+		return fmt.Errorf("failed to unmarshal payroll run update fields: %w", err)
+	}
+
+	// Never allow updating the _id via $set
+	delete(updateFields, "_id")
+
+	update := bson.M{"$set": updateFields}
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update payroll run: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("payroll run with id %s not found", id)
+	}
+
+	return nil
+}
+
+// Delete removes a payroll run document by ID.
+func (r *PayrollRunMongoRepository) Delete(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id}
+
+	_, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete payroll run: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"tow-management-system-api/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PriceListMongoRepository handles MongoDB operations for the PriceList model.
+type PriceListMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoPriceListRepository creates a new PriceListMongoRepository instance.
+func NewMongoPriceListRepository(db *mongo.Database, collectionName string) *PriceListMongoRepository {
+	return &PriceListMongoRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Create inserts a new price list document into MongoDB.
+func (r *PriceListMongoRepository) Create(ctx context.Context, priceList *model.PriceList) error {
+	_, err := r.collection.InsertOne(ctx, priceList)
+	if err != nil {
+		return fmt.Errorf("failed to create price list: %w", err)
+	}
+	return nil
+}
+
+// Find retrieves price lists matching the provided filter struct.
+func (r *PriceListMongoRepository) Find(ctx context.Context, filterModel *model.PriceList) ([]*model.PriceList, error) {
+	bsonBytes, err := bson.Marshal(filterModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal price list filter: %w", err)
+	}
+
+	var filter bson.M
+	if err := bson.Unmarshal(bsonBytes, &filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal price list filter: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find price lists: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.PriceList
+	for cursor.Next(ctx) {
+		var item model.PriceList
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode price list document: %w", err)
+		}
+		results = append(results, &item)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// Update modifies a price list document by ID.
+func (r *PriceListMongoRepository) Update(ctx context.Context, id string, updateData *model.PriceList) error {
+	bsonBytes, err := bson.Marshal(updateData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal price list update data: %w", err)
+	}
+
+	var updateFields bson.M
+	if err := bson.Unmarshal(bsonBytes, &updateFields); err != nil {
+		return fmt.Errorf("failed to unmarshal price list update fields: %w", err)
+	}
+
+	// Never allow updating the _id via $set
+	delete(updateFields, "_id")
+
+	update := bson.M{"$set": updateFields}
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update price list: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("price list with id %s not found", id)
+	}
+
+	return nil
+}
+
+// Delete removes a price list document by ID.
+func (r *PriceListMongoRepository) Delete(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id}
+
+	_, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete price list: %w", err)
+	}
+
+	return nil
+}
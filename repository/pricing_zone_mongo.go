@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"tow-management-system-api/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PricingZoneMongoRepository handles MongoDB operations for the PricingZone model.
+type PricingZoneMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoPricingZoneRepository creates a new PricingZoneMongoRepository instance.
+func NewMongoPricingZoneRepository(db *mongo.Database, collectionName string) *PricingZoneMongoRepository {
+	return &PricingZoneMongoRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Create inserts a new pricing zone document into MongoDB.
+func (r *PricingZoneMongoRepository) Create(ctx context.Context, zone *model.PricingZone) error {
+	_, err := r.collection.InsertOne(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("failed to create pricing zone: %w", err)
+	}
+	return nil
+}
+
+// Find retrieves pricing zones matching the provided filter struct.
+func (r *PricingZoneMongoRepository) Find(ctx context.Context, filterModel *model.PricingZone) ([]*model.PricingZone, error) {
+	bsonBytes, err := bson.Marshal(filterModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pricing zone filter: %w", err)
+	}
+
+	var filter bson.M
+	if err := bson.Unmarshal(bsonBytes, &filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pricing zone filter: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pricing zones: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.PricingZone
+	for cursor.Next(ctx) {
+		var item model.PricingZone
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode pricing zone document: %w", err)
+		}
+		results = append(results, &item)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// Update modifies a pricing zone document by ID.
+func (r *PricingZoneMongoRepository) Update(ctx context.Context, id string, updateData *model.PricingZone) error {
+	bsonBytes, err := bson.Marshal(updateData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pricing zone update data: %w", err)
+	}
+
+	var updateFields bson.M
+	if err := bson.Unmarshal(bsonBytes, &updateFields); err != nil {
+		return fmt.Errorf("failed to unmarshal pricing zone update fields: %w", err)
+	}
+
+	// Never allow updating the _id via $set
+	delete(updateFields, "_id")
+
+	update := bson.M{"$set": updateFields}
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update pricing zone: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("pricing zone with id %s not found", id)
+	}
+
+	return nil
+}
+
+// Replace overwrites a pricing zone document by ID, so fields left out of zone are removed from the document.
+func (r *PricingZoneMongoRepository) Replace(ctx context.Context, id string, zone *model.PricingZone) error {
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.ReplaceOne(ctx, filter, zone)
+	if err != nil {
+		return fmt.Errorf("failed to replace pricing zone: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("pricing zone with id %s not found", id)
+	}
+
+	return nil
+}
+
+// Delete removes a pricing zone document by ID.
+func (r *PricingZoneMongoRepository) Delete(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id}
+
+	_, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete pricing zone: %w", err)
+	}
+
+	return nil
+}
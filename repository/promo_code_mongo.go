@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"tow-management-system-api/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PromoCodeMongoRepository handles MongoDB operations for the PromoCode model.
+type PromoCodeMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoPromoCodeRepository creates a new PromoCodeMongoRepository instance.
+func NewMongoPromoCodeRepository(db *mongo.Database, collectionName string) *PromoCodeMongoRepository {
+	return &PromoCodeMongoRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Create inserts a new promo code document into MongoDB.
+func (r *PromoCodeMongoRepository) Create(ctx context.Context, promoCode *model.PromoCode) error {
+	_, err := r.collection.InsertOne(ctx, promoCode)
+	if err != nil {
+		return fmt.Errorf("failed to create promo code: %w", err)
+	}
+	return nil
+}
+
+// Find retrieves promo codes matching the provided filter struct.
+func (r *PromoCodeMongoRepository) Find(ctx context.Context, filterModel *model.PromoCode) ([]*model.PromoCode, error) {
+	bsonBytes, err := bson.Marshal(filterModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal promo code filter: %w", err)
+	}
+
+	var filter bson.M
+	if err := bson.Unmarshal(bsonBytes, &filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal promo code filter: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find promo codes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.PromoCode
+	for cursor.Next(ctx) {
+		var item model.PromoCode
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode promo code document: %w", err)
+		}
+		results = append(results, &item)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// Update modifies a promo code document by ID.
+func (r *PromoCodeMongoRepository) Update(ctx context.Context, id string, updateData *model.PromoCode) error {
+	bsonBytes, err := bson.Marshal(updateData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal promo code update data: %w", err)
+	}
+
+	var updateFields bson.M
+	if err := bson.Unmarshal(bsonBytes, &updateFields); err != nil {
+		return fmt.Errorf("failed to unmarshal promo code update fields: %w", err)
+	}
+
+	// Never allow updating the _id via $set
+	delete(updateFields, "_id")
+
+	update := bson.M{"$set": updateFields}
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update promo code: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("promo code with id %s not found", id)
+	}
+
+	return nil
+}
+
+// ReserveUse counts one booking against a promo code. It returns false when the code has reached its
+// MaxUses, so concurrent bookings cannot exceed the limit.
+func (r *PromoCodeMongoRepository) ReserveUse(ctx context.Context, id string) (bool, error) {
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"maxUses": bson.M{"$exists": false}},
+			bson.M{"$expr": bson.M{"$lt": bson.A{bson.M{"$ifNull": bson.A{"$uses", 0}}, "$maxUses"}}},
+		},
+	}
+	update := bson.M{"$inc": bson.M{"uses": 1}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve promo code use: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// ReleaseUse gives back a use taken by ReserveUse when the booking did not go through.
+func (r *PromoCodeMongoRepository) ReleaseUse(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id, "uses": bson.M{"$gt": 0}}
+	update := bson.M{"$inc": bson.M{"uses": -1}}
+
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to release promo code use: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a promo code document by ID.
+func (r *PromoCodeMongoRepository) Delete(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id}
+
+	_, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete promo code: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"tow-management-system-api/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PromoRedemptionMongoRepository handles MongoDB operations for the PromoRedemption model.
+type PromoRedemptionMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoPromoRedemptionRepository creates a new PromoRedemptionMongoRepository instance.
+func NewMongoPromoRedemptionRepository(db *mongo.Database, collectionName string) *PromoRedemptionMongoRepository {
+	return &PromoRedemptionMongoRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Create inserts a new promo redemption document into MongoDB.
+func (r *PromoRedemptionMongoRepository) Create(ctx context.Context, redemption *model.PromoRedemption) error {
+	_, err := r.collection.InsertOne(ctx, redemption)
+	if err != nil {
+		return fmt.Errorf("failed to create promo redemption: %w", err)
+	}
+	return nil
+}
+
+// Reserve inserts a promo redemption unless one with the same ID exists, and reports whether it was inserted.
+// Redemptions counted against a per-customer limit have one ID per customer and use, so concurrent bookings
+// cannot record the same use twice.
+func (r *PromoRedemptionMongoRepository) Reserve(ctx context.Context, redemption *model.PromoRedemption) (bool, error) {
+	_, err := r.collection.InsertOne(ctx, redemption)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve promo redemption: %w", err)
+	}
+	return true, nil
+}
+
+// Find retrieves promo redemptions matching the provided filter struct.
+func (r *PromoRedemptionMongoRepository) Find(ctx context.Context, filterModel *model.PromoRedemption) ([]*model.PromoRedemption, error) {
+	bsonBytes, err := bson.Marshal(filterModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal promo redemption filter: %w", err)
+	}
+
+	var filter bson.M
+	if err := bson.Unmarshal(bsonBytes, &filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal promo redemption filter: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find promo redemptions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.PromoRedemption
+	for cursor.Next(ctx) {
+		var item model.PromoRedemption
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode promo redemption document: %w", err)
+		}
+		results = append(results, &item)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// Update modifies a promo redemption document by ID.
+func (r *PromoRedemptionMongoRepository) Update(ctx context.Context, id string, updateData *model.PromoRedemption) error {
+	bsonBytes, err := bson.Marshal(updateData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal promo redemption update data: %w", err)
+	}
+
+	var updateFields bson.M
+	if err := bson.Unmarshal(bsonBytes, &updateFields); err != nil {
+		return fmt.Errorf("failed to unmarshal promo redemption update fields: %w", err)
+	}
+
+	// Never allow updating the _id via $set
+	delete(updateFields, "_id")
+
+	update := bson.M{"$set": updateFields}
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update promo redemption: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("promo redemption with id %s not found", id)
+	}
+
+	return nil
+}
+
+// Delete removes a promo redemption document by ID.
+func (r *PromoRedemptionMongoRepository) Delete(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id}
+
+	_, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete promo redemption: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"tow-management-system-api/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// QuoteMongoRepository handles MongoDB operations for the Quote model.
+type QuoteMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoQuoteRepository creates a new QuoteMongoRepository instance.
+func NewMongoQuoteRepository(db *mongo.Database, collectionName string) *QuoteMongoRepository {
+	return &QuoteMongoRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Create inserts a new quote document into MongoDB.
+func (r *QuoteMongoRepository) Create(ctx context.Context, quote *model.Quote) error {
+	_, err := r.collection.InsertOne(ctx, quote)
+	if err != nil {
+		return fmt.Errorf("failed to create quote: %w", err)
+	}
+	return nil
+}
+
+// Find retrieves quotes matching the provided filter struct.
+func (r *QuoteMongoRepository) Find(ctx context.Context, filterModel *model.Quote) ([]*model.Quote, error) {
+	bsonBytes, err := bson.Marshal(filterModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal quote filter: %w", err)
+	}
+
+	var filter bson.M
+	if err := bson.Unmarshal(bsonBytes, &filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal quote filter: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find quotes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.Quote
+	for cursor.Next(ctx) {
+		var item model.Quote
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode quote document: %w", err)
+		}
+		results = append(results, &item)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// Update modifies a quote document by ID.
+func (r *QuoteMongoRepository) Update(ctx context.Context, id string, updateData *model.Quote) error {
+	bsonBytes, err := bson.Marshal(updateData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal quote update data: %w", err)
+	}
+
+	var updateFields bson.M
+	if err := bson.Unmarshal(bsonBytes, &updateFields); err != nil {
+		return fmt.Errorf("failed to unmarshal quote update fields: %w", err)
+	}
+
+	// Never allow updating the _id via $set
+	delete(updateFields, "_id")
+
+	update := bson.M{"$set": updateFields}
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update quote: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("quote with id %s not found", id)
+	}
+
+	return nil
+}
+
+// Claim marks an unbooked quote as booked by towId. It returns false when the quote does not exist or
+// was already booked, so two requests cannot book the same quote.
+func (r *QuoteMongoRepository) Claim(ctx context.Context, id string, towId string) (bool, error) {
+	filter := bson.M{"_id": id, "towId": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"towId": towId}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim quote: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// Release undoes a Claim by towId, e.g. when the tow could not be saved.
+func (r *QuoteMongoRepository) Release(ctx context.Context, id string, towId string) error {
+	filter := bson.M{"_id": id, "towId": towId}
+	update := bson.M{"$unset": bson.M{"towId": ""}}
+
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to release quote: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a quote document by ID.
+func (r *QuoteMongoRepository) Delete(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id}
+
+	_, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete quote: %w", err)
+	}
+
+	return nil
+}
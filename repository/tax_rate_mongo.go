@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"tow-management-system-api/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TaxRateMongoRepository handles MongoDB operations for the TaxRate model.
+type TaxRateMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoTaxRateRepository creates a new TaxRateMongoRepository instance.
+func NewMongoTaxRateRepository(db *mongo.Database, collectionName string) *TaxRateMongoRepository {
+	return &TaxRateMongoRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Create inserts a new tax rate document into MongoDB.
+func (r *TaxRateMongoRepository) Create(ctx context.Context, taxRate *model.TaxRate) error {
+	_, err := r.collection.InsertOne(ctx, taxRate)
+	if err != nil {
+		return fmt.Errorf("failed to create tax rate: %w", err)
+	}
+	return nil
+}
+
+// Find retrieves tax rates matching the provided filter struct.
+func (r *TaxRateMongoRepository) Find(ctx context.Context, filterModel *model.TaxRate) ([]*model.TaxRate, error) {
+	bsonBytes, err := bson.Marshal(filterModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tax rate filter: %w", err)
+	}
+
+	var filter bson.M
+	if err := bson.Unmarshal(bsonBytes, &filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tax rate filter: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tax rates: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.TaxRate
+	for cursor.Next(ctx) {
+		var item model.TaxRate
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode tax rate document: %w", err)
+		}
+		results = append(results, &item)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// Update modifies a tax rate document by ID.
+func (r *TaxRateMongoRepository) Update(ctx context.Context, id string, updateData *model.TaxRate) error {
+	bsonBytes, err := bson.Marshal(updateData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tax rate update data: %w", err)
+	}
+
+	var updateFields bson.M
+	if err := bson.Unmarshal(bsonBytes, &updateFields); err != nil {
+		return fmt.Errorf("failed to unmarshal tax rate update fields: %w", err)
+	}
+
+	// Never allow updating the _id via $set
+	delete(updateFields, "_id")
+
+	update := bson.M{"$set": updateFields}
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update tax rate: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("tax rate with id %s not found", id)
+	}
+
+	return nil
+}
+
+// Delete removes a tax rate document by ID.
+func (r *TaxRateMongoRepository) Delete(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id}
+
+	_, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete tax rate: %w", err)
+	}
+
+	return nil
+}
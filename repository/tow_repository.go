@@ -79,6 +79,14 @@ func (r *TowMongoRepository) Update(ctx context.Context, id string, updateData *
 	// Never allow updating the _id via $set
 	delete(updateFields, "_id")
 
+	// A vehicle only sets the fields it carries, so a class override keeps the make, model and plate
+	if vehicle, ok := updateFields["vehicle"].(bson.M); ok {
+		delete(updateFields, "vehicle")
+		for field, value := range vehicle {
+			updateFields["vehicle."+field] = value
+		}
+	}
+
 	update := bson.M{"$set": updateFields}
 	filter := bson.M{"_id": id}
 
@@ -93,6 +101,89 @@ func (r *TowMongoRepository) Update(ctx context.Context, id string, updateData *
 	return nil
 }
 
+// AddPayment appends a payment to the tow's ledger. It returns false when the ledger already has a payment with
+// the same reference, so a payment recorded twice (e.g. by a redelivered webhook) is only counted once.
+func (r *TowMongoRepository) AddPayment(ctx context.Context, id string, payment *model.Payment) (bool, error) {
+	filter := bson.M{"_id": id}
+	if payment.Reference != nil && *payment.Reference != "" {
+		filter["payments.reference"] = bson.M{"$ne": *payment.Reference}
+	}
+	update := bson.M{"$push": bson.M{"payments": payment}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to add tow payment: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// VoidPayment marks a ledger payment as voided. It returns false when the tow has no such payment or the
+// payment was already voided.
+func (r *TowMongoRepository) VoidPayment(ctx context.Context, id string, paymentId string, voidedBy string, voidedAt int64) (bool, error) {
+	filter := bson.M{
+		"_id":      id,
+		"payments": bson.M{"$elemMatch": bson.M{"id": paymentId, "voidedAt": bson.M{"$exists": false}}},
+	}
+	update := bson.M{"$set": bson.M{"payments.$.voidedBy": voidedBy, "payments.$.voidedAt": voidedAt}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to void tow payment: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// FindByPaymentIntent retrieves the tows paid with a Stripe payment intent: the intent of the tow's latest
+// checkout or of any payment in its ledger.
+func (r *TowMongoRepository) FindByPaymentIntent(ctx context.Context, paymentIntentId string) ([]*model.Tow, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"paymentIntentId": paymentIntentId},
+		bson.M{"payments.paymentIntentId": paymentIntentId},
+	}}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tows: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.Tow
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode tow documents: %w", err)
+	}
+
+	return results, nil
+}
+
+// SetPaymentIntent records the Stripe payment intent of a ledger payment recorded before payments kept their
+// intent.
+func (r *TowMongoRepository) SetPaymentIntent(ctx context.Context, id string, paymentId string, paymentIntentId string) error {
+	filter := bson.M{"_id": id, "payments.id": paymentId}
+	update := bson.M{"$set": bson.M{"payments.$.paymentIntentId": paymentIntentId}}
+
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to set tow payment intent: %w", err)
+	}
+
+	return nil
+}
+
+// SetInvoiceNumber assigns an invoice number to a tow that does not have one yet. It returns false when the
+// tow already has a number, e.g. because two invoices were requested at once.
+func (r *TowMongoRepository) SetInvoiceNumber(ctx context.Context, id string, invoiceNumber string, invoicedAt int64) (bool, error) {
+	filter := bson.M{"_id": id, "invoiceNumber": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"invoiceNumber": invoiceNumber, "invoicedAt": invoicedAt}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to set tow invoice number: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
 // Delete removes a tow document by ID.
 func (r *TowMongoRepository) Delete(ctx context.Context, id string) error {
 	filter := bson.M{"_id": id}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"tow-management-system-api/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TruckMongoRepository handles MongoDB operations for the Truck model.
+type TruckMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoTruckRepository creates a new TruckMongoRepository instance.
+func NewMongoTruckRepository(db *mongo.Database, collectionName string) *TruckMongoRepository {
+	return &TruckMongoRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Create inserts a new truck document into MongoDB.
+func (r *TruckMongoRepository) Create(ctx context.Context, truck *model.Truck) error {
+	_, err := r.collection.InsertOne(ctx, truck)
+	if err != nil {
+		return fmt.Errorf("failed to create truck: %w", err)
+	}
+	return nil
+}
+
+// Find retrieves trucks matching the provided filter struct.
+func (r *TruckMongoRepository) Find(ctx context.Context, filterModel *model.Truck) ([]*model.Truck, error) {
+	bsonBytes, err := bson.Marshal(filterModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal truck filter: %w", err)
+	}
+
+	var filter bson.M
+	if err := bson.Unmarshal(bsonBytes, &filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal truck filter: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find trucks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.Truck
+	for cursor.Next(ctx) {
+		var item model.Truck
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode truck document: %w", err)
+		}
+		results = append(results, &item)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// Update modifies a truck document by ID.
+func (r *TruckMongoRepository) Update(ctx context.Context, id string, updateData *model.Truck) error {
+	bsonBytes, err := bson.Marshal(updateData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal truck update data: %w", err)
+	}
+
+	var updateFields bson.M
+	if err := bson.Unmarshal(bsonBytes, &updateFields); err != nil {
+		return fmt.Errorf("failed to unmarshal truck update fields: %w", err)
+	}
+
+	// Never allow updating the _id via $set
+	delete(updateFields, "_id")
+
+	update := bson.M{"$set": updateFields}
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update truck: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("truck with id %s not found", id)
+	}
+
+	return nil
+}
+
+// Delete removes a truck document by ID.
+func (r *TruckMongoRepository) Delete(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id}
+
+	_, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete truck: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"tow-management-system-api/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WebhookEventMongoRepository handles MongoDB operations for the WebhookEvent model.
+type WebhookEventMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoWebhookEventRepository creates a new WebhookEventMongoRepository instance.
+func NewMongoWebhookEventRepository(db *mongo.Database, collectionName string) *WebhookEventMongoRepository {
+	return &WebhookEventMongoRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Create inserts a new webhook event document into MongoDB. It returns false without an error when an event
+// with the same ID was already recorded, which makes recording a redelivered Stripe event a no-op.
+func (r *WebhookEventMongoRepository) Create(ctx context.Context, event *model.WebhookEvent) (bool, error) {
+	_, err := r.collection.InsertOne(ctx, event)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create webhook event: %w", err)
+	}
+	return true, nil
+}
+
+// Claim marks an event as processing until leaseUntil and counts the attempt. Only pending or failed events,
+// or processing events whose lease ran out (e.g. the invocation timed out), can be claimed, so concurrent
+// deliveries of the same event never run its handler at the same time.
+func (r *WebhookEventMongoRepository) Claim(ctx context.Context, id string, now int64, leaseUntil int64) (bool, error) {
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"status": bson.M{"$in": bson.A{model.WebhookEventStatusPending, model.WebhookEventStatusFailed}}},
+			bson.M{"status": model.WebhookEventStatusProcessing, "lockedUntil": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": model.WebhookEventStatusProcessing, "lockedUntil": leaseUntil},
+		"$inc": bson.M{"attempts": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim webhook event: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// Find retrieves webhook events matching the provided filter struct.
+func (r *WebhookEventMongoRepository) Find(ctx context.Context, filterModel *model.WebhookEvent) ([]*model.WebhookEvent, error) {
+	bsonBytes, err := bson.Marshal(filterModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook event filter: %w", err)
+	}
+
+	var filter bson.M
+	if err := bson.Unmarshal(bsonBytes, &filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook event filter: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.WebhookEvent
+	for cursor.Next(ctx) {
+		var item model.WebhookEvent
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode webhook event document: %w", err)
+		}
+		results = append(results, &item)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// Update modifies a webhook event document by ID.
+func (r *WebhookEventMongoRepository) Update(ctx context.Context, id string, updateData *model.WebhookEvent) error {
+	bsonBytes, err := bson.Marshal(updateData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook event update data: %w", err)
+	}
+
+	var updateFields bson.M
+	if err := bson.Unmarshal(bsonBytes, &updateFields); err != nil {
+		return fmt.Errorf("failed to unmarshal webhook event update fields: %w", err)
+	}
+
+	// Never allow updating the _id via $set
+	delete(updateFields, "_id")
+
+	update := bson.M{"$set": updateFields}
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook event: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("webhook event with id %s not found", id)
+	}
+
+	return nil
+}
+
+// Delete removes a webhook event document by ID.
+func (r *WebhookEventMongoRepository) Delete(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id}
+
+	_, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"tow-management-system-api/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WorkOrderMongoRepository handles MongoDB operations for the WorkOrder model.
+type WorkOrderMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoWorkOrderRepository creates a new WorkOrderMongoRepository instance.
+func NewMongoWorkOrderRepository(db *mongo.Database, collectionName string) *WorkOrderMongoRepository {
+	return &WorkOrderMongoRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Create inserts a new work order document into MongoDB.
+func (r *WorkOrderMongoRepository) Create(ctx context.Context, workOrder *model.WorkOrder) error {
+	_, err := r.collection.InsertOne(ctx, workOrder)
+	if err != nil {
+		return fmt.Errorf("failed to create work order: %w", err)
+	}
+	return nil
+}
+
+// Find retrieves work orders matching the provided filter struct.
+func (r *WorkOrderMongoRepository) Find(ctx context.Context, filterModel *model.WorkOrder) ([]*model.WorkOrder, error) {
+	bsonBytes, err := bson.Marshal(filterModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal work order filter: %w", err)
+	}
+
+	var filter bson.M
+	if err := bson.Unmarshal(bsonBytes, &filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal work order filter: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find work orders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.WorkOrder
+	for cursor.Next(ctx) {
+		var item model.WorkOrder
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode work order document: %w", err)
+		}
+		results = append(results, &item)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// Update modifies a work order document by ID.
+func (r *WorkOrderMongoRepository) Update(ctx context.Context, id string, updateData *model.WorkOrder) error {
+	bsonBytes, err := bson.Marshal(updateData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal work order update data: %w", err)
+	}
+
+	var updateFields bson.M
+	if err := bson.Unmarshal(bsonBytes, &updateFields); err != nil {
+		return fmt.Errorf("failed to unmarshal work order update fields: %w", err)
+	}
+
+	// Never allow updating the _id via $set
+	delete(updateFields, "_id")
+
+	update := bson.M{"$set": updateFields}
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update work order: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("work order with id %s not found", id)
+	}
+
+	return nil
+}
+
+// Delete removes a work order document by ID.
+func (r *WorkOrderMongoRepository) Delete(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id}
+
+	_, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete work order: %w", err)
+	}
+
+	return nil
+}
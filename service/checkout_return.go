@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"tow-management-system-api/model"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+// checkoutReturnURLs returns the pages Stripe sends the customer to after checkout: the company's own pages when
+// configured, otherwise the platform defaults. The tow ID and access token are added so the page can look up
+// the payment outcome; Stripe fills in the checkout session ID.
+func checkoutReturnURLs(company *model.Company, towId, accessToken string) (string, string) {
+	successURL := stringValue(company.CheckoutSuccessUrl)
+	if successURL == "" {
+		successURL = os.Getenv("STRIPE_CHECKOUT_SUCCESS_URL")
+	}
+	if successURL == "" {
+		successURL = platformWebsite() + "/checkout/success"
+	}
+
+	cancelURL := stringValue(company.CheckoutCancelUrl)
+	if cancelURL == "" {
+		cancelURL = os.Getenv("STRIPE_CHECKOUT_CANCEL_URL")
+	}
+	if cancelURL == "" {
+		cancelURL = platformWebsite() + "/checkout/cancel"
+	}
+
+	query := url.Values{"towId": {towId}, "token": {accessToken}}.Encode()
+	// The placeholder must reach Stripe unescaped
+	successURL = withQuery(successURL, query+"&session_id={CHECKOUT_SESSION_ID}")
+	cancelURL = withQuery(cancelURL, query)
+
+	return successURL, cancelURL
+}
+
+// withQuery appends an encoded query string to a URL that may already have one.
+func withQuery(rawURL, query string) string {
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
+	}
+	return rawURL + separator + query
+}
+
+// validateCheckoutURL checks that a company's checkout page is an absolute http(s) URL.
+func validateCheckoutURL(field string, rawURL *string) error {
+	if rawURL == nil {
+		return nil
+	}
+	parsed, err := url.Parse(*rawURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("%s must be an absolute http or https URL", field)
+	}
+	if parsed.Fragment != "" {
+		return fmt.Errorf("%s must not have a fragment", field)
+	}
+	return nil
+}
+
+// newAccessToken returns a random token that grants access to a single tow's payment outcome.
+func newAccessToken() (string, error) {
+	token := make([]byte, 24)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// GetTowPaymentOutcome returns the payment outcome of a tow for its checkout return page. The outcome is read
+// from Stripe rather than trusted from the return URL, and a payment Stripe reports as paid is recorded on the
+// tow if its webhook has not arrived yet.
+func (s *PaymentService) GetTowPaymentOutcome(ctx context.Context, towId, accessToken string) (*model.TowPaymentOutcome, error) {
+	if towId == "" {
+		return nil, fmt.Errorf("tow id is required")
+	}
+
+	tow, err := s.findTow(ctx, towId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Report a wrong token as not found so tow IDs cannot be probed
+	expected := stringValue(tow.AccessToken)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(accessToken)) != 1 {
+		return nil, fmt.Errorf("tow not found")
+	}
+
+	outcome := &model.TowPaymentOutcome{TowID: towId, PaymentStatus: stringValue(tow.PaymentStatus), BalanceDue: balanceDue(tow)}
+	if tow.PaymentReference == nil || *tow.PaymentReference == "" {
+		return outcome, nil
+	}
+
+	session, err := s.paymentGateway.GetCheckoutSession(ctx, *tow.PaymentReference)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome.CheckoutState = string(session.Status)
+	outcome.AmountTotal = session.AmountTotal
+	if session.Status == stripe.CheckoutSessionStatusOpen {
+		outcome.CheckoutUrl = session.URL
+	}
+
+	if session.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid {
+		if tow, err = s.recordStripePayment(ctx, tow, session); err != nil {
+			return nil, err
+		}
+		outcome.PaymentStatus = stringValue(tow.PaymentStatus)
+		outcome.BalanceDue = balanceDue(tow)
+	}
+
+	return outcome, nil
+}
@@ -7,7 +7,6 @@ import (
 	"strings"
 	"time"
 	"tow-management-system-api/model"
-	"tow-management-system-api/utilities"
 
 	"github.com/google/uuid"
 )
@@ -20,13 +19,13 @@ type CompanyRepository interface {
 
 type CompanyService struct {
 	companyRepository CompanyRepository
-	stripeClient      *utilities.StripeUtility
+	paymentGateway    PaymentGateway
 }
 
-func NewCompanyService(companyRepo CompanyRepository, stripeClient *utilities.StripeUtility) *CompanyService {
+func NewCompanyService(companyRepo CompanyRepository, paymentGateway PaymentGateway) *CompanyService {
 	return &CompanyService{
 		companyRepository: companyRepo,
-		stripeClient:      stripeClient,
+		paymentGateway:    paymentGateway,
 	}
 }
 
@@ -41,8 +40,10 @@ func (s *CompanyService) CreateCompany(ctx context.Context, company *model.Compa
 	company.ID = &id
 	company.CreatedDate = time.Now().UTC().Unix()
 	company.SchedulingLink = generateSchedulingLinkSlug(company.Name)
+	company.InvoiceSequence = nil
+	clearPlatformFields(company)
 
-	account, err := s.stripeClient.CreateConnectedAccount()
+	account, err := s.paymentGateway.CreateConnectedAccount(ctx)
 
 	company.StripeAccountId = &account
 
@@ -83,6 +84,36 @@ func (s *CompanyService) UpdateCompany(ctx context.Context, companyId string, up
 	if update == nil {
 		return fmt.Errorf("update body is required")
 	}
+	if update.Timezone != nil {
+		if _, err := time.LoadLocation(*update.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q", *update.Timezone)
+		}
+	}
+	for _, holiday := range update.Holidays {
+		if _, err := time.Parse("2006-01-02", holiday); err != nil {
+			return fmt.Errorf("invalid holiday %q, expected YYYY-MM-DD", holiday)
+		}
+	}
+	if update.QuoteValidMinutes != nil && *update.QuoteValidMinutes <= 0 {
+		return fmt.Errorf("quote validity must be greater than zero")
+	}
+	if update.MileageRounding != nil {
+		if _, err := roundMiles(0, *update.MileageRounding); err != nil {
+			return err
+		}
+	}
+	if err := validateCheckoutURL("checkout success url", update.CheckoutSuccessUrl); err != nil {
+		return err
+	}
+	if err := validateCheckoutURL("checkout cancel url", update.CheckoutCancelUrl); err != nil {
+		return err
+	}
+	if err := validateInvoiceBranding(update); err != nil {
+		return err
+	}
+	// Invoice numbers must stay sequential, so only invoicing moves the sequence
+	update.InvoiceSequence = nil
+	clearPlatformFields(update)
 
 	if err := s.companyRepository.Update(ctx, companyId, update); err != nil {
 		return fmt.Errorf("update company failed: %w", err)
@@ -90,6 +121,45 @@ func (s *CompanyService) UpdateCompany(ctx context.Context, companyId string, up
 	return nil
 }
 
+// UpdateCompanyPlan sets a company's plan and application fee overrides for a platform admin. Only Plan,
+// ApplicationFeePercent and ApplicationFeeFixed are read from plan.
+func (s *CompanyService) UpdateCompanyPlan(ctx context.Context, userId string, companyId string, plan *model.Company) error {
+	if err := authorizePlatformAdmin(userId); err != nil {
+		return err
+	}
+	if companyId == "" {
+		return fmt.Errorf("company id is required")
+	}
+	if plan == nil {
+		return fmt.Errorf("plan is required")
+	}
+	if err := validateApplicationFee(plan); err != nil {
+		return err
+	}
+
+	update := &model.Company{
+		Plan:                  plan.Plan,
+		ApplicationFeePercent: plan.ApplicationFeePercent,
+		ApplicationFeeFixed:   plan.ApplicationFeeFixed,
+	}
+	if err := s.companyRepository.Update(ctx, companyId, update); err != nil {
+		return fmt.Errorf("update company plan failed: %w", err)
+	}
+	return nil
+}
+
+// clearPlatformFields drops what a company cannot set on itself: its plan and fees, set by platform admins, and
+// its Stripe account and status, set by the platform and kept in sync by account.updated.
+func clearPlatformFields(company *model.Company) {
+	company.Plan = nil
+	company.ApplicationFeePercent = nil
+	company.ApplicationFeeFixed = nil
+	company.StripeAccountId = nil
+	company.ChargesEnabled = nil
+	company.PayoutsEnabled = nil
+	company.DetailsSubmitted = nil
+}
+
 func generateSchedulingLinkSlug(companyName *string) *string {
 	// Convert to lowercase
 	slug := strings.ToLower(*companyName)
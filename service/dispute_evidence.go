@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"tow-management-system-api/model"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+// GetDisputeEvidence assembles what a user of the company that ran a disputed tow needs to answer the dispute:
+// the customer, vehicle and trip, a dated timeline of the tow and its payments, the tow's attachments and the
+// invoice PDF.
+func (s *InvoiceService) GetDisputeEvidence(ctx context.Context, towId, userId string) (*model.DisputeEvidence, error) {
+	tow, err := s.findCompanyTow(ctx, towId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if tow.Dispute == nil {
+		return nil, fmt.Errorf("tow has no dispute")
+	}
+
+	invoice, err := s.invoice(ctx, tow)
+	if err != nil {
+		return nil, err
+	}
+
+	evidence := &model.DisputeEvidence{
+		TowID:         *tow.ID,
+		Dispute:       tow.Dispute,
+		Vehicle:       tow.Vehicle,
+		Pickup:        stringValue(tow.Pickup),
+		Destination:   stringValue(tow.Destination),
+		Miles:         tow.Miles,
+		ServiceDate:   invoiceServiceDate(tow),
+		Price:         intValue(tow.Price),
+		LineItems:     tow.LineItems,
+		Payments:      tow.Payments,
+		Refunds:       tow.Refunds,
+		Timeline:      disputeTimeline(tow),
+		Attachments:   tow.Attachments,
+		Notes:         stringValue(tow.Notes),
+		History:       tow.History,
+		InvoiceNumber: invoice.Number,
+		InvoiceFile:   invoice.FileName,
+		InvoicePDF:    invoice.PDF,
+	}
+	if contact := tow.PrimaryContact; contact != nil {
+		evidence.CustomerName = strings.TrimSpace(stringValue(contact.FirstName) + " " + stringValue(contact.LastName))
+		evidence.CustomerEmail = stringValue(contact.Email)
+		evidence.CustomerPhone = stringValue(contact.Phone)
+	}
+
+	return evidence, nil
+}
+
+// disputeTimeline lists the dated steps of a tow, from booking through service, invoicing, payments and
+// refunds to the dispute, oldest first.
+func disputeTimeline(tow *model.Tow) []model.EvidenceEvent {
+	timeline := []model.EvidenceEvent{}
+	add := func(ts *int64, description string) {
+		if ts != nil && *ts > 0 {
+			timeline = append(timeline, model.EvidenceEvent{At: *ts, Description: description})
+		}
+	}
+
+	add(tow.CreatedAt, "Tow booked")
+	add(tow.ScheduledAt, "Service scheduled")
+	add(tow.DispatchedAt, "Driver dispatched")
+	add(tow.CompletedAt, "Tow completed")
+	if number := stringValue(tow.InvoiceNumber); number != "" {
+		add(tow.InvoicedAt, "Invoice "+number+" issued")
+	}
+	for _, p := range tow.Payments {
+		description := fmt.Sprintf("%s payment of %s", paymentMethodLabel(stringValue(p.Method)), formatDollars(intValue(p.Amount)))
+		if p.VoidedAt != nil {
+			description += " (later voided)"
+		}
+		add(p.CollectedAt, description)
+		add(p.VoidedAt, fmt.Sprintf("%s payment of %s voided", paymentMethodLabel(stringValue(p.Method)), formatDollars(intValue(p.Amount))))
+	}
+	for _, r := range tow.Refunds {
+		switch stripe.RefundStatus(stringValue(r.Status)) {
+		case stripe.RefundStatusSucceeded, stripe.RefundStatusPending:
+			add(r.CreatedAt, "Refund of "+formatDollars(intValue(r.Amount))+" issued")
+		}
+	}
+	if d := tow.Dispute; d != nil {
+		add(d.CreatedAt, fmt.Sprintf("Customer disputed %s (%s)", formatDollars(intValue(d.Amount)), disputeReasonLabel(stringValue(d.Reason))))
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].At < timeline[j].At
+	})
+	return timeline
+}
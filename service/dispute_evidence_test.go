@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+	"tow-management-system-api/model"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+func TestDisputeTimeline(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	at := func(ts int64) *int64 { return &ts }
+
+	tow := &model.Tow{
+		CreatedAt:     at(100),
+		DispatchedAt:  at(200),
+		CompletedAt:   at(300),
+		InvoiceNumber: str("INV-000007"),
+		InvoicedAt:    at(310),
+		Payments: []model.Payment{
+			{Method: str(model.PaymentMethodStripe), Amount: cents(12500), CollectedAt: at(320)},
+			{Method: str(model.PaymentMethodCash), Amount: cents(500), CollectedAt: at(150), VoidedAt: at(160)},
+		},
+		Refunds: []model.Refund{
+			{Amount: cents(2500), Status: str(string(stripe.RefundStatusSucceeded)), CreatedAt: at(400)},
+			{Amount: cents(9999), Status: str(string(stripe.RefundStatusFailed)), CreatedAt: at(410)},
+		},
+	}
+	tow.Dispute = disputeRecord(&stripe.Dispute{
+		ID:              "dp_1",
+		Amount:          10000,
+		Reason:          stripe.DisputeReasonProductNotReceived,
+		Status:          stripe.DisputeStatusNeedsResponse,
+		Created:         500,
+		EvidenceDetails: &stripe.DisputeEvidenceDetails{DueBy: 900},
+		PaymentIntent:   &stripe.PaymentIntent{ID: "pi_1"},
+	}, 505)
+
+	want := []model.EvidenceEvent{
+		{At: 100, Description: "Tow booked"},
+		{At: 150, Description: "Cash payment of $5.00 (later voided)"},
+		{At: 160, Description: "Cash payment of $5.00 voided"},
+		{At: 200, Description: "Driver dispatched"},
+		{At: 300, Description: "Tow completed"},
+		{At: 310, Description: "Invoice INV-000007 issued"},
+		{At: 320, Description: "Card (online) payment of $125.00"},
+		{At: 400, Description: "Refund of $25.00 issued"},
+		{At: 500, Description: "Customer disputed $100.00 (service not received)"},
+	}
+
+	got := disputeTimeline(tow)
+	if len(got) != len(want) {
+		t.Fatalf("disputeTimeline() returned %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("disputeTimeline()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if d := tow.Dispute; stringValue(d.PaymentIntentID) != "pi_1" || int64Value(d.EvidenceDueBy) != 900 || int64Value(d.UpdatedAt) != 505 {
+		t.Errorf("disputeRecord() = %+v, want payment intent pi_1, evidence due 900 and updated 505", *d)
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"tow-management-system-api/model"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+// disputeReasonLabels are how Stripe dispute reasons read in alerts and evidence.
+var disputeReasonLabels = map[stripe.DisputeReason]string{
+	stripe.DisputeReasonCreditNotProcessed:   "credit not processed",
+	stripe.DisputeReasonDuplicate:            "duplicate charge",
+	stripe.DisputeReasonFraudulent:           "fraudulent",
+	stripe.DisputeReasonGeneral:              "general",
+	stripe.DisputeReasonProductNotReceived:   "service not received",
+	stripe.DisputeReasonProductUnacceptable:  "service unacceptable",
+	stripe.DisputeReasonSubscriptionCanceled: "subscription canceled",
+	stripe.DisputeReasonUnrecognized:         "unrecognized charge",
+}
+
+// recordDispute stores the dispute on the tow and moves its payment status, and reports whether it did. Events
+// older than the last one recorded are ignored, since Stripe does not deliver them in order.
+func (s *PaymentService) recordDispute(ctx context.Context, tow *model.Tow, dispute *stripe.Dispute, eventCreated int64) (bool, error) {
+	if current := tow.Dispute; current != nil && stringValue(current.ID) == dispute.ID && int64Value(current.UpdatedAt) > eventCreated {
+		return false, nil
+	}
+
+	record := disputeRecord(dispute, eventCreated)
+	update := &model.Tow{Dispute: record}
+
+	status := paymentStatusDisputed
+	switch dispute.Status {
+	case stripe.DisputeStatusWon, stripe.DisputeStatusWarningClosed, stripe.DisputeStatusPrevented:
+		status = ledgerPaymentStatus(tow)
+	case stripe.DisputeStatusLost:
+		status = paymentStatusDisputeLost
+	}
+	update.PaymentStatus = &status
+
+	if err := s.towDataRepository.Update(ctx, *tow.ID, update); err != nil {
+		return false, fmt.Errorf("failed to update tow dispute: %w", err)
+	}
+
+	tow.Dispute = record
+	tow.PaymentStatus = &status
+	return true, nil
+}
+
+// alertDispute emails the company's users that a dispute was opened or closed on one of their tows. Failures
+// are logged rather than returned so Stripe does not redeliver the event and alert twice.
+func (s *PaymentService) alertDispute(ctx context.Context, tow *model.Tow, eventType stripe.EventType) {
+	companies, err := s.companyRepository.Find(ctx, &model.Company{ID: tow.CompanyID})
+	if err != nil || len(companies) == 0 {
+		log.Printf("dispute alert for tow %s: company %s not found: %v", *tow.ID, stringValue(tow.CompanyID), err)
+		return
+	}
+	company := companies[0]
+
+	users, err := s.userRepository.Find(ctx, &model.User{CompanyID: tow.CompanyID})
+	if err != nil {
+		log.Printf("dispute alert for tow %s: failed to find users: %v", *tow.ID, err)
+		return
+	}
+
+	location, err := companyLocation(company)
+	if err != nil {
+		location = time.UTC
+	}
+	subject, content := formatDisputeEmail(company, tow, eventType, location)
+	for _, user := range users {
+		email := stringValue(user.Email)
+		if email == "" {
+			continue
+		}
+		if err := s.emailUtility.SendEmail(ctx, email, subject, content); err != nil {
+			log.Printf("dispute alert for tow %s: failed to email %s: %v", *tow.ID, email, err)
+		}
+	}
+}
+
+// formatDisputeEmail returns the subject and body of a dispute alert.
+func formatDisputeEmail(company *model.Company, tow *model.Tow, eventType stripe.EventType, location *time.Location) (string, string) {
+	dispute := tow.Dispute
+	amount := formatDollars(intValue(dispute.Amount))
+	customer := ""
+	if contact := tow.PrimaryContact; contact != nil {
+		customer = strings.TrimSpace(stringValue(contact.FirstName) + " " + stringValue(contact.LastName))
+	}
+	if customer == "" {
+		customer = "A customer"
+	}
+
+	platformName := os.Getenv("PLATFORM_NAME")
+	if platformName == "" {
+		platformName = "Tow Management Platform"
+	}
+	signature := fmt.Sprintf(`
+
+Best regards,
+%s
+%s
+`, platformName, platformWebsite())
+
+	if eventType == stripe.EventTypeChargeDisputeClosed {
+		outcome := "closed"
+		switch stripe.DisputeStatus(stringValue(dispute.Status)) {
+		case stripe.DisputeStatusWon, stripe.DisputeStatusWarningClosed, stripe.DisputeStatusPrevented:
+			outcome = "closed in your favor; the held funds are returned to your account"
+		case stripe.DisputeStatusLost:
+			outcome = "lost; the disputed amount stays with the customer"
+		}
+		subject := fmt.Sprintf("Payment dispute %s – tow %s", stringValue(dispute.Status), *tow.ID)
+		content := fmt.Sprintf(`Hello %s,
+
+The dispute over the %s payment for tow %s was %s.`, companyDisplayName(company), amount, *tow.ID, outcome)
+		return subject, content + signature
+	}
+
+	dueBy := "as soon as possible"
+	if dispute.EvidenceDueBy != nil {
+		dueBy = "by " + time.Unix(*dispute.EvidenceDueBy, 0).In(location).Format("Jan 2, 2006 3:04 PM MST")
+	}
+	subject := fmt.Sprintf("Payment disputed – respond %s", dueBy)
+	content := fmt.Sprintf(`Hello %s,
+
+%s disputed the %s card payment for tow %s with their bank (reason: %s). The amount is held from your account while the dispute is open.
+
+To contest it, submit evidence %s. The tow's evidence package (timeline, attachments and invoice) is ready in %s to support your response.`,
+		companyDisplayName(company), customer, amount, *tow.ID, disputeReasonLabel(stringValue(dispute.Reason)), dueBy, platformName)
+	return subject, content + signature
+}
+
+// disputeRecord mirrors a Stripe dispute for storage on the tow.
+func disputeRecord(d *stripe.Dispute, updatedAt int64) *model.Dispute {
+	amount := int(d.Amount)
+	status := string(d.Status)
+	reason := string(d.Reason)
+	record := &model.Dispute{
+		ID:        &d.ID,
+		Status:    &status,
+		Amount:    &amount,
+		Reason:    &reason,
+		CreatedAt: &d.Created,
+		UpdatedAt: &updatedAt,
+	}
+	if d.PaymentIntent != nil && d.PaymentIntent.ID != "" {
+		record.PaymentIntentID = &d.PaymentIntent.ID
+	}
+	if d.EvidenceDetails != nil && d.EvidenceDetails.DueBy > 0 {
+		record.EvidenceDueBy = &d.EvidenceDetails.DueBy
+	}
+	return record
+}
+
+// disputeReasonLabel returns how a Stripe dispute reason reads to the company.
+func disputeReasonLabel(reason string) string {
+	if label, ok := disputeReasonLabels[stripe.DisputeReason(reason)]; ok {
+		return label
+	}
+	return strings.ReplaceAll(reason, "_", " ")
+}
@@ -0,0 +1,538 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"tow-management-system-api/model"
+
+	"github.com/google/uuid"
+)
+
+type TruckRepository interface {
+	Create(ctx context.Context, item *model.Truck) error
+	Find(ctx context.Context, filterModel *model.Truck) ([]*model.Truck, error)
+	Update(ctx context.Context, id string, updateData *model.Truck) error
+}
+
+type InspectionRepository interface {
+	Create(ctx context.Context, item *model.Inspection) error
+	Find(ctx context.Context, filterModel *model.Inspection) ([]*model.Inspection, error)
+}
+
+type WorkOrderRepository interface {
+	Create(ctx context.Context, item *model.WorkOrder) error
+	Find(ctx context.Context, filterModel *model.WorkOrder) ([]*model.WorkOrder, error)
+	Update(ctx context.Context, id string, updateData *model.WorkOrder) error
+}
+
+const (
+	truckStatusInService    = "in_service"
+	truckStatusOutOfService = "out_of_service"
+
+	// Only trucks taken out of service for defects go back in service when their work orders are completed
+	truckStatusReasonManual  = "manual"
+	truckStatusReasonDefects = "defects"
+
+	inspectionTypePreTrip  = "pre_trip"
+	inspectionTypePostTrip = "post_trip"
+
+	workOrderStatusOpen      = "open"
+	workOrderStatusCompleted = "completed"
+)
+
+// defaultInspectionChecklist is used when a company has not configured its own DVIR checklist.
+var defaultInspectionChecklist = []string{
+	"Service Brakes",
+	"Parking Brake",
+	"Steering Mechanism",
+	"Lighting Devices and Reflectors",
+	"Tires",
+	"Horn",
+	"Windshield Wipers",
+	"Rear Vision Mirrors",
+	"Coupling Devices",
+	"Wheels and Rims",
+	"Emergency Equipment",
+	"Winch, Boom and Wheel Lift",
+}
+
+// FleetService manages the truck registry, driver vehicle inspection reports and maintenance work orders.
+type FleetService struct {
+	truckRepository      TruckRepository
+	inspectionRepository InspectionRepository
+	workOrderRepository  WorkOrderRepository
+	companyRepository    CompanyRepository
+}
+
+// NewFleetService creates a new FleetService instance.
+func NewFleetService(truckRepo TruckRepository, inspectionRepo InspectionRepository, workOrderRepo WorkOrderRepository, companyRepo CompanyRepository) *FleetService {
+	return &FleetService{
+		truckRepository:      truckRepo,
+		inspectionRepository: inspectionRepo,
+		workOrderRepository:  workOrderRepo,
+		companyRepository:    companyRepo,
+	}
+}
+
+// CreateTruck registers a new in-service truck for a company.
+func (s *FleetService) CreateTruck(ctx context.Context, companyId string, truck *model.Truck) (*model.Truck, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+	if truck == nil {
+		return nil, fmt.Errorf("truck payload is nil")
+	}
+	if truck.UnitNumber == nil || *truck.UnitNumber == "" {
+		return nil, fmt.Errorf("unit number is required")
+	}
+
+	id := uuid.NewString()
+	status := truckStatusInService
+	now := time.Now().UTC().Unix()
+	truck.ID = &id
+	truck.CompanyID = &companyId
+	truck.Status = &status
+	truck.StatusReason = nil
+	truck.CreatedAt = &now
+
+	if err := s.truckRepository.Create(ctx, truck); err != nil {
+		return nil, fmt.Errorf("create truck failed: %w", err)
+	}
+
+	return truck, nil
+}
+
+// FindTrucksByCompanyId retrieves all trucks registered to a company.
+func (s *FleetService) FindTrucksByCompanyId(ctx context.Context, companyId string) ([]*model.Truck, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+
+	trucks, err := s.truckRepository.Find(ctx, &model.Truck{CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("find trucks failed: %w", err)
+	}
+
+	return trucks, nil
+}
+
+// FindTruckById retrieves a single truck.
+func (s *FleetService) FindTruckById(ctx context.Context, truckId string) (*model.Truck, error) {
+	if truckId == "" {
+		return nil, fmt.Errorf("truck id is required")
+	}
+
+	trucks, err := s.truckRepository.Find(ctx, &model.Truck{ID: &truckId})
+	if err != nil {
+		return nil, fmt.Errorf("find truck failed: %w", err)
+	}
+	if len(trucks) == 0 {
+		return nil, fmt.Errorf("truck not found")
+	}
+
+	return trucks[0], nil
+}
+
+// UpdateTruck updates a truck by its ID with the provided partial fields.
+func (s *FleetService) UpdateTruck(ctx context.Context, truckId string, update *model.Truck) error {
+	if truckId == "" {
+		return fmt.Errorf("truck id is required")
+	}
+	if update == nil {
+		return fmt.Errorf("update body is required")
+	}
+	if update.Status != nil && *update.Status != truckStatusInService && *update.Status != truckStatusOutOfService {
+		return fmt.Errorf("status must be %q or %q", truckStatusInService, truckStatusOutOfService)
+	}
+
+	// The owning company never changes, and the status reason follows the status
+	update.CompanyID = nil
+	update.StatusReason = nil
+	if update.Status != nil && *update.Status == truckStatusOutOfService {
+		reason := truckStatusReasonManual
+		update.StatusReason = &reason
+	}
+
+	// A truck with open out-of-service defects goes back in service when their work orders are completed
+	if update.Status != nil && *update.Status == truckStatusInService {
+		workOrders, err := s.workOrderRepository.Find(ctx, &model.WorkOrder{TruckID: &truckId})
+		if err != nil {
+			return fmt.Errorf("find work orders failed: %w", err)
+		}
+		for _, order := range workOrders {
+			if stringValue(order.Status) == workOrderStatusOpen && stringValue(order.InspectionID) != "" {
+				return fmt.Errorf("truck has open defects; complete their work orders to put it back in service")
+			}
+		}
+	}
+
+	if err := s.truckRepository.Update(ctx, truckId, update); err != nil {
+		return fmt.Errorf("update truck failed: %w", err)
+	}
+	return nil
+}
+
+// FindInspectionChecklist returns the company's DVIR checklist, or the default checklist if none is configured.
+func (s *FleetService) FindInspectionChecklist(ctx context.Context, companyId string) ([]string, error) {
+	company, err := s.findCompany(ctx, companyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(company.InspectionChecklist) == 0 {
+		return defaultInspectionChecklist, nil
+	}
+
+	return company.InspectionChecklist, nil
+}
+
+// SetInspectionChecklist replaces the company's DVIR checklist.
+func (s *FleetService) SetInspectionChecklist(ctx context.Context, companyId string, items []string) error {
+	if companyId == "" {
+		return fmt.Errorf("company id is required")
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("checklist must contain at least one item")
+	}
+
+	seen := map[string]struct{}{}
+	for i, item := range items {
+		if item == "" {
+			return fmt.Errorf("items[%d] is empty", i)
+		}
+		if _, ok := seen[item]; ok {
+			return fmt.Errorf("duplicate checklist item %q", item)
+		}
+		seen[item] = struct{}{}
+	}
+
+	if err := s.companyRepository.Update(ctx, companyId, &model.Company{InspectionChecklist: items}); err != nil {
+		return fmt.Errorf("update checklist failed: %w", err)
+	}
+	return nil
+}
+
+// SubmitInspection records a DVIR for a truck. Every checklist item must be reported; each out-of-service
+// defect opens a work order and takes the truck out of service, unless dispatch already took it out by hand.
+func (s *FleetService) SubmitInspection(ctx context.Context, truckId string, inspection *model.Inspection) (*model.Inspection, error) {
+	if inspection == nil {
+		return nil, fmt.Errorf("inspection payload is nil")
+	}
+	if inspection.Type == nil || (*inspection.Type != inspectionTypePreTrip && *inspection.Type != inspectionTypePostTrip) {
+		return nil, fmt.Errorf("type must be %q or %q", inspectionTypePreTrip, inspectionTypePostTrip)
+	}
+	if inspection.DriverID == nil || *inspection.DriverID == "" {
+		return nil, fmt.Errorf("driver id is required")
+	}
+
+	truck, err := s.FindTruckById(ctx, truckId)
+	if err != nil {
+		return nil, err
+	}
+
+	checklist, err := s.FindInspectionChecklist(ctx, stringValue(truck.CompanyID))
+	if err != nil {
+		return nil, err
+	}
+
+	reported := map[string]struct{}{}
+	for _, item := range inspection.Items {
+		if item.Name == nil || item.Passed == nil {
+			return nil, fmt.Errorf("each inspection item requires a name and a passed flag")
+		}
+		reported[*item.Name] = struct{}{}
+	}
+	for _, item := range checklist {
+		if _, ok := reported[item]; !ok {
+			return nil, fmt.Errorf("checklist item %q was not inspected", item)
+		}
+	}
+
+	id := uuid.NewString()
+	now := time.Now().UTC().Unix()
+	inspection.ID = &id
+	inspection.CompanyID = truck.CompanyID
+	inspection.TruckID = truck.ID
+	inspection.CreatedAt = &now
+
+	if err := s.inspectionRepository.Create(ctx, inspection); err != nil {
+		return nil, fmt.Errorf("create inspection failed: %w", err)
+	}
+
+	outOfService := false
+	for _, defect := range inspection.Defects {
+		if defect.OutOfService == nil || !*defect.OutOfService {
+			continue
+		}
+		outOfService = true
+
+		description := fmt.Sprintf("%s: %s", stringValue(defect.Item), stringValue(defect.Description))
+		if _, err := s.openWorkOrder(ctx, truck, &model.WorkOrder{
+			InspectionID: inspection.ID,
+			Description:  &description,
+			Odometer:     inspection.Odometer,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	truckUpdate := &model.Truck{}
+	if inspection.Odometer != nil && (truck.Odometer == nil || *inspection.Odometer > *truck.Odometer) {
+		truckUpdate.Odometer = inspection.Odometer
+	}
+	if outOfService && stringValue(truck.Status) != truckStatusOutOfService {
+		status := truckStatusOutOfService
+		reason := truckStatusReasonDefects
+		truckUpdate.Status = &status
+		truckUpdate.StatusReason = &reason
+	}
+	if truckUpdate.Odometer != nil || truckUpdate.Status != nil {
+		if err := s.truckRepository.Update(ctx, *truck.ID, truckUpdate); err != nil {
+			return nil, fmt.Errorf("update truck failed: %w", err)
+		}
+	}
+
+	return inspection, nil
+}
+
+// FindInspectionsByTruckId retrieves all inspections recorded for a truck.
+func (s *FleetService) FindInspectionsByTruckId(ctx context.Context, truckId string) ([]*model.Inspection, error) {
+	if truckId == "" {
+		return nil, fmt.Errorf("truck id is required")
+	}
+
+	inspections, err := s.inspectionRepository.Find(ctx, &model.Inspection{TruckID: &truckId})
+	if err != nil {
+		return nil, fmt.Errorf("find inspections failed: %w", err)
+	}
+
+	return inspections, nil
+}
+
+// CreateWorkOrder opens a maintenance work order on a truck.
+func (s *FleetService) CreateWorkOrder(ctx context.Context, truckId string, workOrder *model.WorkOrder) (*model.WorkOrder, error) {
+	if workOrder == nil {
+		return nil, fmt.Errorf("work order payload is nil")
+	}
+	if workOrder.Description == nil || *workOrder.Description == "" {
+		return nil, fmt.Errorf("description is required")
+	}
+
+	truck, err := s.FindTruckById(ctx, truckId)
+	if err != nil {
+		return nil, err
+	}
+
+	if workOrder.ServiceInterval != nil && findServiceInterval(truck, *workOrder.ServiceInterval) == nil {
+		return nil, fmt.Errorf("service interval %q not found on truck", *workOrder.ServiceInterval)
+	}
+
+	return s.openWorkOrder(ctx, truck, workOrder)
+}
+
+// FindWorkOrdersByTruckId retrieves all work orders opened on a truck.
+func (s *FleetService) FindWorkOrdersByTruckId(ctx context.Context, truckId string) ([]*model.WorkOrder, error) {
+	if truckId == "" {
+		return nil, fmt.Errorf("truck id is required")
+	}
+
+	workOrders, err := s.workOrderRepository.Find(ctx, &model.WorkOrder{TruckID: &truckId})
+	if err != nil {
+		return nil, fmt.Errorf("find work orders failed: %w", err)
+	}
+
+	return workOrders, nil
+}
+
+// CompleteWorkOrder closes a work order, resets the service interval it performed and, when the truck was taken
+// out of service for defects, returns it to service once it has no other open work orders.
+func (s *FleetService) CompleteWorkOrder(ctx context.Context, workOrderId string, odometer *int, notes string) (*model.WorkOrder, error) {
+	if workOrderId == "" {
+		return nil, fmt.Errorf("work order id is required")
+	}
+
+	workOrders, err := s.workOrderRepository.Find(ctx, &model.WorkOrder{ID: &workOrderId})
+	if err != nil {
+		return nil, fmt.Errorf("find work order failed: %w", err)
+	}
+	if len(workOrders) == 0 {
+		return nil, fmt.Errorf("work order not found")
+	}
+
+	workOrder := workOrders[0]
+	if stringValue(workOrder.Status) == workOrderStatusCompleted {
+		return nil, fmt.Errorf("work order is already completed")
+	}
+
+	truck, err := s.FindTruckById(ctx, stringValue(workOrder.TruckID))
+	if err != nil {
+		return nil, err
+	}
+
+	status := workOrderStatusCompleted
+	now := time.Now().UTC().Unix()
+	workOrder.Status = &status
+	workOrder.CompletedAt = &now
+	if odometer != nil {
+		workOrder.Odometer = odometer
+	}
+	if notes != "" {
+		workOrder.Notes = &notes
+	}
+
+	if err := s.workOrderRepository.Update(ctx, workOrderId, &model.WorkOrder{
+		Status:      workOrder.Status,
+		CompletedAt: workOrder.CompletedAt,
+		Odometer:    workOrder.Odometer,
+		Notes:       workOrder.Notes,
+	}); err != nil {
+		return nil, fmt.Errorf("update work order failed: %w", err)
+	}
+
+	truckUpdate := &model.Truck{}
+
+	if workOrder.Odometer != nil && (truck.Odometer == nil || *workOrder.Odometer > *truck.Odometer) {
+		truckUpdate.Odometer = workOrder.Odometer
+	}
+
+	if workOrder.ServiceInterval != nil {
+		if interval := findServiceInterval(truck, *workOrder.ServiceInterval); interval != nil {
+			interval.LastServiceAt = &now
+			if workOrder.Odometer != nil {
+				interval.LastServiceOdometer = workOrder.Odometer
+			}
+			truckUpdate.ServiceIntervals = truck.ServiceIntervals
+		}
+	}
+
+	if stringValue(truck.Status) == truckStatusOutOfService && stringValue(truck.StatusReason) == truckStatusReasonDefects {
+		openOrders, err := s.workOrderRepository.Find(ctx, &model.WorkOrder{TruckID: truck.ID})
+		if err != nil {
+			return nil, fmt.Errorf("find work orders failed: %w", err)
+		}
+
+		remaining := 0
+		for _, order := range openOrders {
+			if stringValue(order.Status) == workOrderStatusOpen && stringValue(order.ID) != workOrderId {
+				remaining++
+			}
+		}
+		if remaining == 0 {
+			inService := truckStatusInService
+			truckUpdate.Status = &inService
+		}
+	}
+
+	if truckUpdate.Odometer != nil || truckUpdate.ServiceIntervals != nil || truckUpdate.Status != nil {
+		if err := s.truckRepository.Update(ctx, *truck.ID, truckUpdate); err != nil {
+			return nil, fmt.Errorf("update truck failed: %w", err)
+		}
+	}
+
+	return workOrder, nil
+}
+
+// FindMaintenanceDue lists the service intervals that are due or overdue across a company's trucks,
+// by odometer or by elapsed days since the last service.
+func (s *FleetService) FindMaintenanceDue(ctx context.Context, companyId string) ([]*model.MaintenanceDue, error) {
+	trucks, err := s.FindTrucksByCompanyId(ctx, companyId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	due := []*model.MaintenanceDue{}
+
+	for _, truck := range trucks {
+		for i := range truck.ServiceIntervals {
+			interval := truck.ServiceIntervals[i]
+			item := &model.MaintenanceDue{
+				TruckID:      truck.ID,
+				UnitNumber:   truck.UnitNumber,
+				Interval:     interval.Name,
+				CurrentMiles: truck.Odometer,
+			}
+			isDue := false
+
+			if interval.EveryMiles != nil {
+				dueOdometer := *interval.EveryMiles
+				if interval.LastServiceOdometer != nil {
+					dueOdometer += *interval.LastServiceOdometer
+				}
+				item.DueOdometer = &dueOdometer
+				if truck.Odometer != nil && *truck.Odometer >= dueOdometer {
+					isDue = true
+				}
+			}
+
+			if interval.EveryDays != nil {
+				lastService := truck.CreatedAt
+				if interval.LastServiceAt != nil {
+					lastService = interval.LastServiceAt
+				}
+				if lastService != nil {
+					dueAt := time.Unix(*lastService, 0).UTC().AddDate(0, 0, *interval.EveryDays)
+					dueAtUnix := dueAt.Unix()
+					item.DueAt = &dueAtUnix
+					if !now.Before(dueAt) {
+						overdueDays := int(now.Sub(dueAt).Hours() / 24)
+						item.OverdueByDays = &overdueDays
+						isDue = true
+					}
+				}
+			}
+
+			if isDue {
+				due = append(due, item)
+			}
+		}
+	}
+
+	return due, nil
+}
+
+// openWorkOrder persists a new open work order for the truck.
+func (s *FleetService) openWorkOrder(ctx context.Context, truck *model.Truck, workOrder *model.WorkOrder) (*model.WorkOrder, error) {
+	id := uuid.NewString()
+	status := workOrderStatusOpen
+	now := time.Now().UTC().Unix()
+	workOrder.ID = &id
+	workOrder.CompanyID = truck.CompanyID
+	workOrder.TruckID = truck.ID
+	workOrder.Status = &status
+	workOrder.CreatedAt = &now
+	workOrder.CompletedAt = nil
+
+	if err := s.workOrderRepository.Create(ctx, workOrder); err != nil {
+		return nil, fmt.Errorf("create work order failed: %w", err)
+	}
+
+	return workOrder, nil
+}
+
+func (s *FleetService) findCompany(ctx context.Context, companyId string) (*model.Company, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+
+	companies, err := s.companyRepository.Find(ctx, &model.Company{ID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch company: %w", err)
+	}
+	if len(companies) == 0 {
+		return nil, fmt.Errorf("company not found")
+	}
+
+	return companies[0], nil
+}
+
+// findServiceInterval returns a pointer into the truck's intervals so callers can update it in place.
+func findServiceInterval(truck *model.Truck, name string) *model.ServiceInterval {
+	for i := range truck.ServiceIntervals {
+		if stringValue(truck.ServiceIntervals[i].Name) == name {
+			return &truck.ServiceIntervals[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"tow-management-system-api/model"
+)
+
+// newFleet returns a fleet service over one company with a two-item DVIR checklist and the given trucks
+// and work orders.
+func newFleet(t *testing.T, trucks []*model.Truck, workOrders []*model.WorkOrder) (*FleetService, *memoryStore[model.Truck], *memoryStore[model.WorkOrder]) {
+	t.Helper()
+	str := func(s string) *string { return &s }
+	ctx := context.Background()
+
+	companies := &memoryStore[model.Company]{}
+	if err := companies.Create(ctx, &model.Company{ID: str("company-1"), InspectionChecklist: []string{"Tires", "Horn"}}); err != nil {
+		t.Fatal(err)
+	}
+	truckStore := &memoryStore[model.Truck]{}
+	for _, truck := range trucks {
+		if err := truckStore.Create(ctx, truck); err != nil {
+			t.Fatal(err)
+		}
+	}
+	workOrderStore := &memoryStore[model.WorkOrder]{}
+	for _, workOrder := range workOrders {
+		if err := workOrderStore.Create(ctx, workOrder); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewFleetService(truckStore, &memoryStore[model.Inspection]{}, workOrderStore, companies), truckStore, workOrderStore
+}
+
+func TestSubmitInspection(t *testing.T) {
+	str := func(s string) *string { return &s }
+	flag := func(b bool) *bool { return &b }
+	miles := func(m int) *int { return &m }
+	items := []model.InspectionItem{{Name: str("Tires"), Passed: flag(true)}, {Name: str("Horn"), Passed: flag(false)}}
+
+	tests := []struct {
+		name           string
+		status         string
+		statusReason   string
+		inspection     *model.Inspection
+		wantErr        string
+		wantWorkOrders int
+		wantStatus     string
+		wantReason     string
+	}{
+		{
+			name:       "passed inspection",
+			status:     truckStatusInService,
+			inspection: &model.Inspection{Items: items, Odometer: miles(12500)},
+			wantStatus: truckStatusInService,
+		},
+		{
+			name:       "defect that does not take the truck out of service",
+			status:     truckStatusInService,
+			inspection: &model.Inspection{Items: items, Defects: []model.Defect{{Item: str("Horn"), Description: str("weak"), OutOfService: flag(false)}}},
+			wantStatus: truckStatusInService,
+		},
+		{
+			name:           "failed inspection opens a work order and takes the truck out of service",
+			status:         truckStatusInService,
+			inspection:     &model.Inspection{Items: items, Defects: []model.Defect{{Item: str("Horn"), Description: str("no sound"), OutOfService: flag(true)}}},
+			wantWorkOrders: 1,
+			wantStatus:     truckStatusOutOfService,
+			wantReason:     truckStatusReasonDefects,
+		},
+		{
+			name:           "truck taken out of service by hand stays out for dispatch",
+			status:         truckStatusOutOfService,
+			statusReason:   truckStatusReasonManual,
+			inspection:     &model.Inspection{Items: items, Defects: []model.Defect{{Item: str("Horn"), Description: str("no sound"), OutOfService: flag(true)}}},
+			wantWorkOrders: 1,
+			wantStatus:     truckStatusOutOfService,
+			wantReason:     truckStatusReasonManual,
+		},
+		{
+			name:       "checklist item not inspected",
+			status:     truckStatusInService,
+			inspection: &model.Inspection{Items: items[:1]},
+			wantErr:    `"Horn" was not inspected`,
+			wantStatus: truckStatusInService,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			truck := &model.Truck{ID: str("truck-1"), CompanyID: str("company-1"), Status: str(tt.status), Odometer: miles(12000)}
+			if tt.statusReason != "" {
+				truck.StatusReason = str(tt.statusReason)
+			}
+			svc, trucks, workOrders := newFleet(t, []*model.Truck{truck}, nil)
+
+			tt.inspection.Type = str(inspectionTypePreTrip)
+			tt.inspection.DriverID = str("driver-1")
+			_, err := svc.SubmitInspection(ctx, "truck-1", tt.inspection)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SubmitInspection() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("SubmitInspection() error = %v", err)
+			}
+
+			orders, _ := workOrders.Find(ctx, &model.WorkOrder{TruckID: str("truck-1")})
+			if len(orders) != tt.wantWorkOrders {
+				t.Errorf("%d work orders opened, want %d", len(orders), tt.wantWorkOrders)
+			}
+			for _, order := range orders {
+				if stringValue(order.Status) != workOrderStatusOpen || stringValue(order.InspectionID) == "" {
+					t.Errorf("work order = %+v, want an open work order for the inspection", order)
+				}
+			}
+
+			saved := trucks.get("truck-1")
+			if stringValue(saved.Status) != tt.wantStatus || stringValue(saved.StatusReason) != tt.wantReason {
+				t.Errorf("truck status = %q (%q), want %q (%q)", stringValue(saved.Status), stringValue(saved.StatusReason), tt.wantStatus, tt.wantReason)
+			}
+			if want := intValue(tt.inspection.Odometer); want > 12000 && intValue(saved.Odometer) != want {
+				t.Errorf("odometer = %d, want %d", intValue(saved.Odometer), want)
+			}
+		})
+	}
+}
+
+func TestCompleteWorkOrder(t *testing.T) {
+	str := func(s string) *string { return &s }
+	miles := func(m int) *int { return &m }
+	days := func(d int) *int { return &d }
+
+	tests := []struct {
+		name          string
+		statusReason  string
+		otherOpen     bool
+		wantStatus    string
+		wantLastMiles int
+	}{
+		{name: "last defect work order returns the truck to service", statusReason: truckStatusReasonDefects, wantStatus: truckStatusInService},
+		{name: "other open work orders keep the truck out", statusReason: truckStatusReasonDefects, otherOpen: true, wantStatus: truckStatusOutOfService},
+		{name: "truck taken out by hand stays out", statusReason: truckStatusReasonManual, wantStatus: truckStatusOutOfService},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			truck := &model.Truck{
+				ID:               str("truck-1"),
+				CompanyID:        str("company-1"),
+				Status:           str(truckStatusOutOfService),
+				StatusReason:     str(tt.statusReason),
+				Odometer:         miles(20000),
+				ServiceIntervals: []model.ServiceInterval{{Name: str("Oil Change"), EveryMiles: miles(5000), EveryDays: days(90), LastServiceOdometer: miles(15000)}},
+			}
+			orders := []*model.WorkOrder{{ID: str("order-1"), TruckID: str("truck-1"), InspectionID: str("inspection-1"), ServiceInterval: str("Oil Change"), Status: str(workOrderStatusOpen)}}
+			if tt.otherOpen {
+				orders = append(orders, &model.WorkOrder{ID: str("order-2"), TruckID: str("truck-1"), InspectionID: str("inspection-1"), Status: str(workOrderStatusOpen)})
+			}
+			svc, trucks, workOrders := newFleet(t, []*model.Truck{truck}, orders)
+
+			if _, err := svc.CompleteWorkOrder(ctx, "order-1", miles(20150), "replaced the horn"); err != nil {
+				t.Fatalf("CompleteWorkOrder() error = %v", err)
+			}
+
+			if order := workOrders.get("order-1"); stringValue(order.Status) != workOrderStatusCompleted || order.CompletedAt == nil {
+				t.Errorf("work order = %+v, want completed", order)
+			}
+			saved := trucks.get("truck-1")
+			if stringValue(saved.Status) != tt.wantStatus {
+				t.Errorf("truck status = %q, want %q", stringValue(saved.Status), tt.wantStatus)
+			}
+			interval := saved.ServiceIntervals[0]
+			if intValue(saved.Odometer) != 20150 || intValue(interval.LastServiceOdometer) != 20150 || interval.LastServiceAt == nil {
+				t.Errorf("odometer %d and interval %+v, want the service recorded at 20150 miles", intValue(saved.Odometer), interval)
+			}
+
+			if _, err := svc.CompleteWorkOrder(ctx, "order-1", nil, ""); err == nil {
+				t.Errorf("CompleteWorkOrder() of a completed work order expected an error")
+			}
+		})
+	}
+}
+
+func TestFindMaintenanceDue(t *testing.T) {
+	str := func(s string) *string { return &s }
+	miles := func(m int) *int { return &m }
+	days := func(d int) *int { return &d }
+	daysAgo := func(d int) *int64 {
+		at := time.Now().UTC().AddDate(0, 0, -d).Unix()
+		return &at
+	}
+
+	tests := []struct {
+		name            string
+		odometer        *int
+		createdAt       *int64
+		interval        model.ServiceInterval
+		wantDue         bool
+		wantDueOdometer int
+		wantOverdueDays int
+	}{
+		{
+			name:            "odometer past the interval",
+			odometer:        miles(20100),
+			interval:        model.ServiceInterval{EveryMiles: miles(5000), LastServiceOdometer: miles(15000)},
+			wantDue:         true,
+			wantDueOdometer: 20000,
+		},
+		{
+			name:            "odometer short of the interval",
+			odometer:        miles(19900),
+			interval:        model.ServiceInterval{EveryMiles: miles(5000), LastServiceOdometer: miles(15000)},
+			wantDueOdometer: 20000,
+		},
+		{
+			name:            "never serviced counts miles from zero",
+			odometer:        miles(5000),
+			interval:        model.ServiceInterval{EveryMiles: miles(5000)},
+			wantDue:         true,
+			wantDueOdometer: 5000,
+		},
+		{
+			name:            "days since the last service",
+			interval:        model.ServiceInterval{EveryDays: days(90), LastServiceAt: daysAgo(100)},
+			wantDue:         true,
+			wantOverdueDays: 10,
+		},
+		{
+			name:     "days not yet elapsed",
+			interval: model.ServiceInterval{EveryDays: days(90), LastServiceAt: daysAgo(30)},
+		},
+		{
+			name:            "never serviced counts days from registration",
+			createdAt:       daysAgo(95),
+			interval:        model.ServiceInterval{EveryDays: days(90)},
+			wantDue:         true,
+			wantOverdueDays: 5,
+		},
+		{
+			name:            "due by days before miles",
+			odometer:        miles(16000),
+			interval:        model.ServiceInterval{EveryMiles: miles(5000), LastServiceOdometer: miles(15000), EveryDays: days(90), LastServiceAt: daysAgo(91)},
+			wantDue:         true,
+			wantDueOdometer: 20000,
+			wantOverdueDays: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.interval.Name = str("Oil Change")
+			truck := &model.Truck{ID: str("truck-1"), CompanyID: str("company-1"), UnitNumber: str("T-1"), Odometer: tt.odometer, CreatedAt: tt.createdAt, ServiceIntervals: []model.ServiceInterval{tt.interval}}
+			svc, _, _ := newFleet(t, []*model.Truck{truck}, nil)
+
+			due, err := svc.FindMaintenanceDue(context.Background(), "company-1")
+			if err != nil {
+				t.Fatalf("FindMaintenanceDue() error = %v", err)
+			}
+			if !tt.wantDue {
+				if len(due) != 0 {
+					t.Errorf("FindMaintenanceDue() = %+v, want nothing due", due[0])
+				}
+				return
+			}
+			if len(due) != 1 {
+				t.Fatalf("FindMaintenanceDue() returned %d items, want 1", len(due))
+			}
+			item := due[0]
+			if stringValue(item.Interval) != "Oil Change" || stringValue(item.UnitNumber) != "T-1" {
+				t.Errorf("due item = %+v, want Oil Change on T-1", item)
+			}
+			if intValue(item.DueOdometer) != tt.wantDueOdometer {
+				t.Errorf("due odometer = %d, want %d", intValue(item.DueOdometer), tt.wantDueOdometer)
+			}
+			if intValue(item.OverdueByDays) != tt.wantOverdueDays {
+				t.Errorf("overdue by %d days, want %d", intValue(item.OverdueByDays), tt.wantOverdueDays)
+			}
+		})
+	}
+}
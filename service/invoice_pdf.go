@@ -0,0 +1,307 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"tow-management-system-api/model"
+	"tow-management-system-api/utilities"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+// Invoice page layout in points.
+const (
+	invoiceMargin    = 50.0
+	invoiceRight     = utilities.PDFPageWidth - invoiceMargin
+	invoiceColumn    = 340.0 // left edge of the right-hand column
+	invoiceBottom    = utilities.PDFPageHeight - 60
+	invoiceLineSize  = 10.0
+	invoiceLineSpace = 14.0
+)
+
+// defaultBrandColor is the invoice header color for companies without a brand color.
+const defaultBrandColor = "#1F2937"
+
+// paymentMethodLabels are how payment methods read on an invoice.
+var paymentMethodLabels = map[string]string{
+	model.PaymentMethodStripe:    "Card (online)",
+	model.PaymentMethodCash:      "Cash",
+	model.PaymentMethodCheck:     "Check",
+	model.PaymentMethodCard:      "Card",
+	model.PaymentMethodMotorClub: "Motor club",
+	model.PaymentMethodInsurance: "Insurance",
+	model.PaymentMethodOther:     "Other",
+}
+
+// invoiceLayout draws an invoice top to bottom, starting a new page when the current one is full.
+type invoiceLayout struct {
+	doc *utilities.PDFDocument
+	y   float64
+}
+
+// renderInvoice draws the tow's invoice: company header, customer and vehicle, the trip, itemized charges,
+// payments and refunds, and the balance due. Dates are shown in the company's timezone.
+func renderInvoice(company *model.Company, tow *model.Tow, location *time.Location) []byte {
+	l := &invoiceLayout{doc: utilities.NewPDFDocument()}
+
+	// Header bar in the company's brand color
+	r, g, b := brandColor(company)
+	l.doc.FillRect(0, 0, utilities.PDFPageWidth, 90, r, g, b)
+	l.doc.SetTextColor(1, 1, 1)
+	l.doc.Text(invoiceMargin, 52, 20, true, companyDisplayName(company))
+	l.doc.TextRight(invoiceRight, 52, 20, true, "INVOICE")
+	l.doc.SetTextColor(0, 0, 0)
+
+	// Company details on the left, invoice details on the right
+	l.y = 120
+	var companyLines []string
+	for _, line := range []string{
+		stringValue(company.Street),
+		strings.TrimSpace(fmt.Sprintf("%s, %s %s", stringValue(company.City), stringValue(company.State), stringValue(company.ZipCode))),
+		stringValue(company.PhoneNumber),
+		stringValue(company.Website),
+	} {
+		if line != "" && line != "," {
+			companyLines = append(companyLines, line)
+		}
+	}
+	details := [][2]string{
+		{"Invoice", stringValue(tow.InvoiceNumber)},
+		{"Invoice date", formatInvoiceDate(tow.InvoicedAt, location)},
+		{"Service date", formatInvoiceDate(invoiceServiceDate(tow), location)},
+		{"Tow", stringValue(tow.ID)},
+	}
+	top := l.y
+	for _, line := range companyLines {
+		l.doc.Text(invoiceMargin, l.y, invoiceLineSize, false, line)
+		l.y += invoiceLineSpace
+	}
+	left := l.y
+	l.y = top
+	for _, detail := range details {
+		l.doc.Text(invoiceColumn, l.y, invoiceLineSize, true, detail[0])
+		l.doc.TextRight(invoiceRight, l.y, invoiceLineSize, false, detail[1])
+		l.y += invoiceLineSpace
+	}
+	l.y = max(l.y, left) + invoiceLineSpace
+
+	// Customer on the left, vehicle on the right
+	top = l.y
+	l.doc.Text(invoiceMargin, l.y, 11, true, "Bill to")
+	l.y += invoiceLineSpace + 2
+	if contact := tow.PrimaryContact; contact != nil {
+		for _, line := range []string{
+			strings.TrimSpace(stringValue(contact.FirstName) + " " + stringValue(contact.LastName)),
+			stringValue(contact.Email),
+			stringValue(contact.Phone),
+		} {
+			if line != "" {
+				l.doc.Text(invoiceMargin, l.y, invoiceLineSize, false, line)
+				l.y += invoiceLineSpace
+			}
+		}
+	}
+	left = l.y
+	l.y = top
+	l.doc.Text(invoiceColumn, l.y, 11, true, "Vehicle")
+	l.y += invoiceLineSpace + 2
+	if vehicle := tow.Vehicle; vehicle != nil {
+		for _, line := range []string{
+			strings.Join(strings.Fields(stringValue(vehicle.Year)+" "+stringValue(vehicle.Make)+" "+stringValue(vehicle.Model)), " "),
+			strings.TrimSpace(stringValue(vehicle.State) + " " + stringValue(vehicle.PlateNumber)),
+		} {
+			if line != "" {
+				l.doc.Text(invoiceColumn, l.y, invoiceLineSize, false, line)
+				l.y += invoiceLineSpace
+			}
+		}
+	}
+	l.y = max(l.y, left) + invoiceLineSpace
+
+	// Trip
+	l.doc.Text(invoiceMargin, l.y, 11, true, "Service")
+	l.y += invoiceLineSpace + 2
+	l.labeledText("Pickup", stringValue(tow.Pickup))
+	l.labeledText("Destination", stringValue(tow.Destination))
+	if tow.Miles != nil {
+		l.labeledText("Distance", strconv.FormatFloat(*tow.Miles, 'f', 1, 64)+" miles")
+	}
+	l.y += invoiceLineSpace
+
+	// Itemized charges
+	l.tableHeader("Description", "Amount")
+	for _, item := range tow.LineItems {
+		l.amountRow(item.Name, formatDollars(int(item.Amount)), false)
+	}
+	// The price may have been edited after the tow was itemized
+	if adjustment := int64(intValue(tow.Price)) - utilities.LineItemsTotal(tow.LineItems); adjustment != 0 {
+		l.amountRow("Price adjustment", formatDollars(int(adjustment)), false)
+	}
+	l.rule()
+	l.amountRow("Total", formatDollars(intValue(tow.Price)), true)
+	l.y += invoiceLineSpace
+
+	// Payments and refunds
+	var rows [][2]string
+	for _, p := range tow.Payments {
+		if p.VoidedAt != nil {
+			continue
+		}
+		label := formatInvoiceDate(p.CollectedAt, location) + "  " + paymentMethodLabel(stringValue(p.Method))
+		if reference := stringValue(p.Reference); reference != "" && stringValue(p.Method) != model.PaymentMethodStripe {
+			label += " #" + reference
+		}
+		rows = append(rows, [2]string{label, formatDollars(-intValue(p.Amount))})
+	}
+	for _, refund := range tow.Refunds {
+		switch stripe.RefundStatus(stringValue(refund.Status)) {
+		case stripe.RefundStatusSucceeded, stripe.RefundStatusPending:
+			rows = append(rows, [2]string{formatInvoiceDate(refund.CreatedAt, location) + "  Refund", formatDollars(intValue(refund.Amount))})
+		}
+	}
+	paid := paidAmount(tow, "")
+	if len(rows) == 0 && paid > 0 {
+		// Paid before the payment ledger existed
+		rows = append(rows, [2]string{paymentMethodLabel(model.PaymentMethodStripe), formatDollars(-paid)})
+	}
+	if len(rows) > 0 {
+		l.tableHeader("Payments", "")
+		for _, row := range rows {
+			l.amountRow(row[0], row[1], false)
+		}
+		l.rule()
+	}
+
+	balance := balanceDue(tow)
+	switch {
+	case balance < 0:
+		l.amountRow("Credit", formatDollars(-balance), true)
+	case balance == 0:
+		l.amountRow("Balance due", formatDollars(0)+"  PAID", true)
+	default:
+		l.amountRow("Balance due", formatDollars(balance), true)
+	}
+
+	// Footer below the content area of the last page
+	platformName := os.Getenv("PLATFORM_NAME")
+	if platformName == "" {
+		platformName = "Tow Management Platform"
+	}
+	l.doc.SetTextColor(0.4, 0.4, 0.4)
+	l.doc.Text(invoiceMargin, invoiceBottom+20, 9, false, "Thank you for your business.")
+	l.doc.Text(invoiceMargin, invoiceBottom+32, 9, false, "Payments processed by "+platformName+" on behalf of "+companyDisplayName(company)+".")
+	l.doc.SetTextColor(0, 0, 0)
+
+	return l.doc.Bytes()
+}
+
+// labeledText draws "label: text", wrapping long text such as addresses under itself.
+func (l *invoiceLayout) labeledText(label, text string) {
+	if text == "" {
+		return
+	}
+	indent := invoiceMargin + 80
+	lines := utilities.PDFWrapText(text, invoiceRight-indent, invoiceLineSize, false)
+	l.ensureSpace(float64(len(lines)) * invoiceLineSpace)
+	l.doc.Text(invoiceMargin, l.y, invoiceLineSize, true, label)
+	for _, line := range lines {
+		l.doc.Text(indent, l.y, invoiceLineSize, false, line)
+		l.y += invoiceLineSpace
+	}
+}
+
+// tableHeader draws a shaded header row.
+func (l *invoiceLayout) tableHeader(left, right string) {
+	l.ensureSpace(3 * invoiceLineSpace)
+	l.doc.FillRect(invoiceMargin-6, l.y-13, invoiceRight-invoiceMargin+12, 19, 0.93, 0.93, 0.93)
+	l.doc.Text(invoiceMargin, l.y, invoiceLineSize, true, left)
+	if right != "" {
+		l.doc.TextRight(invoiceRight, l.y, invoiceLineSize, true, right)
+	}
+	l.y += invoiceLineSpace + 6
+}
+
+// amountRow draws a description, wrapped if needed, with an amount on the right.
+func (l *invoiceLayout) amountRow(description, amount string, bold bool) {
+	lines := utilities.PDFWrapText(description, invoiceColumn+60-invoiceMargin, invoiceLineSize, bold)
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+	l.ensureSpace(float64(len(lines)) * invoiceLineSpace)
+	l.doc.TextRight(invoiceRight, l.y, invoiceLineSize, bold, amount)
+	for _, line := range lines {
+		l.doc.Text(invoiceMargin, l.y, invoiceLineSize, bold, line)
+		l.y += invoiceLineSpace
+	}
+}
+
+// rule draws a thin line across the table.
+func (l *invoiceLayout) rule() {
+	l.ensureSpace(invoiceLineSpace)
+	l.doc.Line(invoiceMargin, l.y-8, invoiceRight, l.y-8, 0.5)
+	l.y += 4
+}
+
+// ensureSpace starts a new page when height does not fit above the footer.
+func (l *invoiceLayout) ensureSpace(height float64) {
+	if l.y+height <= invoiceBottom {
+		return
+	}
+	l.doc.AddPage()
+	l.y = 60
+}
+
+// brandColor returns the company's invoice header color as RGB components from 0 to 1.
+func brandColor(company *model.Company) (float64, float64, float64) {
+	hex := stringValue(company.BrandColor)
+	if !brandColorPattern.MatchString(hex) {
+		hex = defaultBrandColor
+	}
+	rgb, _ := strconv.ParseUint(hex[1:], 16, 32)
+	return float64(rgb>>16&0xFF) / 255, float64(rgb>>8&0xFF) / 255, float64(rgb&0xFF) / 255
+}
+
+// companyDisplayName returns the company's name for documents sent to customers.
+func companyDisplayName(company *model.Company) string {
+	if name := stringValue(company.Name); name != "" {
+		return name
+	}
+	return "the service provider"
+}
+
+// invoiceServiceDate returns when the tow was done, or when it was scheduled or booked if it is not done yet.
+func invoiceServiceDate(tow *model.Tow) *int64 {
+	for _, ts := range []*int64{tow.CompletedAt, tow.ScheduledAt, tow.CreatedAt} {
+		if ts != nil {
+			return ts
+		}
+	}
+	return nil
+}
+
+// formatInvoiceDate renders unix seconds as a date in the company's timezone, e.g. Jan 2, 2026.
+func formatInvoiceDate(ts *int64, location *time.Location) string {
+	if ts == nil {
+		return ""
+	}
+	return time.Unix(*ts, 0).In(location).Format("Jan 2, 2006")
+}
+
+// formatDollars renders cents as a signed dollar amount, e.g. -1250 -> "-$12.50".
+func formatDollars(cents int) string {
+	if cents < 0 {
+		return "-$" + formatCents(-cents)
+	}
+	return "$" + formatCents(cents)
+}
+
+// paymentMethodLabel returns how a payment method reads on an invoice.
+func paymentMethodLabel(method string) string {
+	if label, ok := paymentMethodLabels[method]; ok {
+		return label
+	}
+	return method
+}
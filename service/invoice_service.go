@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"regexp"
+	"time"
+	"tow-management-system-api/model"
+)
+
+// InvoiceTowRepository is the minimal dependency InvoiceService needs to load tows and number their invoices.
+type InvoiceTowRepository interface {
+	Find(ctx context.Context, filterModel *model.Tow) ([]*model.Tow, error)
+	SetInvoiceNumber(ctx context.Context, id string, invoiceNumber string, invoicedAt int64) (bool, error)
+}
+
+// InvoiceCompanyRepository is the minimal dependency InvoiceService needs to load companies and issue
+// sequential invoice numbers.
+type InvoiceCompanyRepository interface {
+	Find(ctx context.Context, filterModel *model.Company) ([]*model.Company, error)
+	NextInvoiceNumber(ctx context.Context, id string) (int, error)
+}
+
+// defaultInvoicePrefix is put before invoice numbers when the company has not set its own prefix.
+const defaultInvoicePrefix = "INV-"
+
+// brandColorPattern matches a #RRGGBB hex color.
+var brandColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// InvoiceService renders tow invoices as PDFs.
+type InvoiceService struct {
+	towRepository     InvoiceTowRepository
+	companyRepository InvoiceCompanyRepository
+	userRepository    UserFinder
+}
+
+// NewInvoiceService creates a new InvoiceService.
+func NewInvoiceService(towRepo InvoiceTowRepository, companyRepo InvoiceCompanyRepository, userRepo UserFinder) *InvoiceService {
+	return &InvoiceService{
+		towRepository:     towRepo,
+		companyRepository: companyRepo,
+		userRepository:    userRepo,
+	}
+}
+
+// GetInvoice renders a tow's invoice for a user of the company that ran the tow.
+func (s *InvoiceService) GetInvoice(ctx context.Context, towId, userId string) (*model.Invoice, error) {
+	tow, err := s.findCompanyTow(ctx, towId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.invoice(ctx, tow)
+}
+
+// GetPublicInvoice renders a tow's invoice for the customer, who proves access with the token from their tow link.
+func (s *InvoiceService) GetPublicInvoice(ctx context.Context, towId, accessToken string) (*model.Invoice, error) {
+	tow, err := s.findTow(ctx, towId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Report a wrong token as not found so tow IDs cannot be probed
+	expected := stringValue(tow.AccessToken)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(accessToken)) != 1 {
+		return nil, fmt.Errorf("tow not found")
+	}
+
+	return s.invoice(ctx, tow)
+}
+
+// invoice numbers the tow's invoice if needed and renders it.
+func (s *InvoiceService) invoice(ctx context.Context, tow *model.Tow) (*model.Invoice, error) {
+	if intValue(tow.Price) <= 0 {
+		return nil, fmt.Errorf("tow has no price to invoice")
+	}
+
+	companies, err := s.companyRepository.Find(ctx, &model.Company{ID: tow.CompanyID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch company: %w", err)
+	}
+	if len(companies) == 0 {
+		return nil, fmt.Errorf("company not found")
+	}
+	company := companies[0]
+
+	location, err := companyLocation(company)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.assignInvoiceNumber(ctx, tow, company); err != nil {
+		return nil, err
+	}
+
+	number := stringValue(tow.InvoiceNumber)
+	return &model.Invoice{
+		Number:   number,
+		FileName: number + ".pdf",
+		PDF:      renderInvoice(company, tow, location),
+	}, nil
+}
+
+// assignInvoiceNumber gives the tow the company's next invoice number the first time its invoice is rendered;
+// later renders reuse it. When two first renders race, the loser's number is left unused and it reads the
+// winner's.
+func (s *InvoiceService) assignInvoiceNumber(ctx context.Context, tow *model.Tow, company *model.Company) error {
+	if stringValue(tow.InvoiceNumber) != "" {
+		return nil
+	}
+
+	sequence, err := s.companyRepository.NextInvoiceNumber(ctx, *company.ID)
+	if err != nil {
+		return err
+	}
+
+	prefix := defaultInvoicePrefix
+	if company.InvoicePrefix != nil {
+		prefix = *company.InvoicePrefix
+	}
+	number := fmt.Sprintf("%s%06d", prefix, sequence)
+	invoicedAt := time.Now().UTC().Unix()
+
+	assigned, err := s.towRepository.SetInvoiceNumber(ctx, *tow.ID, number, invoicedAt)
+	if err != nil {
+		return err
+	}
+	if assigned {
+		tow.InvoiceNumber = &number
+		tow.InvoicedAt = &invoicedAt
+		return nil
+	}
+
+	current, err := s.findTow(ctx, *tow.ID)
+	if err != nil {
+		return err
+	}
+	tow.InvoiceNumber = current.InvoiceNumber
+	tow.InvoicedAt = current.InvoicedAt
+	return nil
+}
+
+// findCompanyTow returns a tow the user may see because they belong to the company that ran it.
+func (s *InvoiceService) findCompanyTow(ctx context.Context, towId, userId string) (*model.Tow, error) {
+	if userId == "" {
+		return nil, fmt.Errorf("user id is required")
+	}
+
+	tow, err := s.findTow(ctx, towId)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.userRepository.Find(ctx, &model.User{ID: &userId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if len(users) == 0 || stringValue(users[0].CompanyID) == "" || stringValue(users[0].CompanyID) != stringValue(tow.CompanyID) {
+		return nil, fmt.Errorf("user is not allowed to view this tow")
+	}
+
+	return tow, nil
+}
+
+// findTow returns a tow by ID.
+func (s *InvoiceService) findTow(ctx context.Context, towId string) (*model.Tow, error) {
+	if towId == "" {
+		return nil, fmt.Errorf("tow id is required")
+	}
+
+	tows, err := s.towRepository.Find(ctx, &model.Tow{ID: &towId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tow: %w", err)
+	}
+	if len(tows) == 0 || tows[0].ID == nil {
+		return nil, fmt.Errorf("tow not found")
+	}
+
+	return tows[0], nil
+}
+
+// validateInvoiceBranding checks the invoice settings of a company update.
+func validateInvoiceBranding(update *model.Company) error {
+	if update.BrandColor != nil && *update.BrandColor != "" && !brandColorPattern.MatchString(*update.BrandColor) {
+		return fmt.Errorf("brand color must be a hex color like #1F4E79")
+	}
+	if update.InvoicePrefix != nil && len(*update.InvoicePrefix) > 12 {
+		return fmt.Errorf("invoice prefix must be at most 12 characters")
+	}
+	return nil
+}
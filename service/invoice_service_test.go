@@ -0,0 +1,112 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+	"tow-management-system-api/model"
+)
+
+type memoryInvoiceTows struct {
+	memoryTows
+	// competitor, when set, is the number another request assigns first, so SetInvoiceNumber loses the race.
+	competitor string
+}
+
+func (r *memoryInvoiceTows) SetInvoiceNumber(ctx context.Context, id string, invoiceNumber string, invoicedAt int64) (bool, error) {
+	tow := r.get(id)
+	if tow == nil {
+		return false, nil
+	}
+	if tow.InvoiceNumber == nil && r.competitor != "" {
+		tow.InvoiceNumber = &r.competitor
+		tow.InvoicedAt = &invoicedAt
+		r.replace(id, tow)
+	}
+	if tow.InvoiceNumber != nil {
+		return false, nil
+	}
+	tow.InvoiceNumber = &invoiceNumber
+	tow.InvoicedAt = &invoicedAt
+	r.replace(id, tow)
+	return true, nil
+}
+
+type memoryInvoiceCompanies struct {
+	memoryStore[model.Company]
+	issued int
+}
+
+func (r *memoryInvoiceCompanies) NextInvoiceNumber(ctx context.Context, id string) (int, error) {
+	r.issued++
+	return r.issued, nil
+}
+
+func TestAssignInvoiceNumber(t *testing.T) {
+	str := func(s string) *string { return &s }
+	company := &model.Company{ID: str("company-1"), InvoicePrefix: str("ACME-")}
+
+	tests := []struct {
+		name       string
+		competitor string
+		wantNumber string
+		wantIssued int
+	}{
+		{name: "first invoice gets the next number", wantNumber: "ACME-000001", wantIssued: 1},
+		{name: "lost race takes the stored number", competitor: "ACME-000007", wantNumber: "ACME-000007", wantIssued: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tows := &memoryInvoiceTows{competitor: tt.competitor}
+			if err := tows.Create(ctx, &model.Tow{ID: str("tow-1"), CompanyID: company.ID}); err != nil {
+				t.Fatal(err)
+			}
+			companies := &memoryInvoiceCompanies{}
+			svc := NewInvoiceService(tows, companies, nil)
+
+			tow := tows.get("tow-1")
+			if err := svc.assignInvoiceNumber(ctx, tow, company); err != nil {
+				t.Fatalf("assignInvoiceNumber() error = %v", err)
+			}
+			if stringValue(tow.InvoiceNumber) != tt.wantNumber || tow.InvoicedAt == nil {
+				t.Errorf("invoice number = %q at %v, want %q", stringValue(tow.InvoiceNumber), tow.InvoicedAt, tt.wantNumber)
+			}
+			if saved := tows.get("tow-1"); stringValue(saved.InvoiceNumber) != tt.wantNumber {
+				t.Errorf("saved invoice number = %q, want %q", stringValue(saved.InvoiceNumber), tt.wantNumber)
+			}
+
+			// A later render keeps the number without issuing another
+			again := tows.get("tow-1")
+			if err := svc.assignInvoiceNumber(ctx, again, company); err != nil {
+				t.Fatalf("assignInvoiceNumber() again error = %v", err)
+			}
+			if stringValue(again.InvoiceNumber) != tt.wantNumber || companies.issued != tt.wantIssued {
+				t.Errorf("second render = %q with %d numbers issued, want %q with %d", stringValue(again.InvoiceNumber), companies.issued, tt.wantNumber, tt.wantIssued)
+			}
+		})
+	}
+}
+
+func TestRenderInvoiceShowsPriceAdjustment(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	company := &model.Company{ID: str("company-1"), Name: str("Acme Towing")}
+	tow := &model.Tow{
+		ID:        str("tow-1"),
+		Price:     cents(15000),
+		LineItems: []model.PayableLineItem{{Name: "Hook-up", Amount: 7500, Quantity: 1}, {Name: "Mileage", Amount: 5000, Quantity: 10}},
+	}
+
+	out := renderInvoice(company, tow, time.UTC)
+	if !bytes.Contains(out, []byte("(Price adjustment) Tj")) || !bytes.Contains(out, []byte("($25.00) Tj")) {
+		t.Errorf("invoice has no $25.00 price adjustment for line items of $125.00 on a $150.00 tow")
+	}
+
+	tow.Price = cents(12500)
+	if out := renderInvoice(company, tow, time.UTC); bytes.Contains(out, []byte("(Price adjustment) Tj")) {
+		t.Errorf("invoice shows a price adjustment for line items that add up to the price")
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// memoryStore keeps documents the way the Mongo repositories do: filters and updates are the bson form of a
+// model, matched and $set field by field.
+type memoryStore[T any] struct {
+	mu   sync.Mutex
+	docs []bson.M
+}
+
+func toBSON(v any) bson.M {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		panic(err)
+	}
+	return doc
+}
+
+func fromBSON[T any](doc bson.M) *T {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+	item := new(T)
+	if err := bson.Unmarshal(data, item); err != nil {
+		panic(err)
+	}
+	return item
+}
+
+func (s *memoryStore[T]) Create(ctx context.Context, item *T) error {
+	doc := toBSON(item)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.docs {
+		if existing["_id"] == doc["_id"] {
+			return fmt.Errorf("duplicate key %v", doc["_id"])
+		}
+	}
+	s.docs = append(s.docs, doc)
+	return nil
+}
+
+func (s *memoryStore[T]) Find(ctx context.Context, filterModel *T) ([]*T, error) {
+	filter := toBSON(filterModel)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var results []*T
+	for _, doc := range s.docs {
+		matched := true
+		for k, v := range filter {
+			if !reflect.DeepEqual(doc[k], v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			results = append(results, fromBSON[T](doc))
+		}
+	}
+	return results, nil
+}
+
+func (s *memoryStore[T]) Update(ctx context.Context, id string, updateData *T) error {
+	fields := toBSON(updateData)
+	delete(fields, "_id")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, doc := range s.docs {
+		if doc["_id"] == id {
+			for k, v := range fields {
+				doc[k] = v
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("document with id %s not found", id)
+}
+
+// get returns the document with the given id, or nil.
+func (s *memoryStore[T]) get(id string) *T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, doc := range s.docs {
+		if doc["_id"] == id {
+			return fromBSON[T](doc)
+		}
+	}
+	return nil
+}
+
+// replace stores item in place of the document with the same id.
+func (s *memoryStore[T]) replace(id string, item *T) {
+	doc := toBSON(item)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.docs {
+		if s.docs[i]["_id"] == id {
+			s.docs[i] = doc
+		}
+	}
+}
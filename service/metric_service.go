@@ -27,11 +27,15 @@ var activeLabel = "Active Tows"
 var totalLabel = "Total Tows"
 var payoutLabel = "Payout Amount"
 
-// CalculateMetrics computes metrics for a company and returns a slice of Metric documents.
-func (m *MetricService) CalculateMetrics(ctx context.Context, companyID string) ([]*model.Metric, error) {
+// CalculateMetrics computes metrics for a company and returns a slice of Metric documents. The payout amount
+// covers payments and refunds within [from, to); a zero from or to leaves that end of the period open.
+func (m *MetricService) CalculateMetrics(ctx context.Context, companyID string, from, to int64) ([]*model.Metric, error) {
 	if companyID == "" {
 		return nil, fmt.Errorf("company id is required")
 	}
+	if from < 0 || to < 0 || (to != 0 && to <= from) {
+		return nil, fmt.Errorf("from must be before to")
+	}
 
 	// Pull all tows for the company. We’re intentionally not filtering by status here
 	// so we can derive multiple metrics in one pass with a single repository call.
@@ -48,7 +52,8 @@ func (m *MetricService) CalculateMetrics(ctx context.Context, companyID string)
 	// Build metrics (all fields are pointers; aligns with your Metric struct)
 	activeTotalStr := fmt.Sprintf("%d", activeTotal)
 	completedTotalStr := fmt.Sprintf("%d", completedTotal)
-	payoutAmountStr := "0.00" // TODO: use actual values
+	// What the company's Stripe payments leave it after platform fees and refunds
+	payoutAmountStr := formatCents(summarizePayments(tows, from, to).StripeNet)
 	now := time.Now().Unix()
 
 	metrics := []*model.Metric{
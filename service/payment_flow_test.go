@@ -0,0 +1,695 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"tow-management-system-api/model"
+	"tow-management-system-api/utilities"
+	"tow-management-system-api/utilities/paymenttest"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+var (
+	_ PaymentGateway = (*utilities.StripeUtility)(nil)
+	_ PaymentGateway = (*paymenttest.FakePaymentGateway)(nil)
+)
+
+type memoryTows struct{ memoryStore[model.Tow] }
+
+func (r *memoryTows) AddPayment(ctx context.Context, id string, payment *model.Payment) (bool, error) {
+	tow := r.get(id)
+	if tow == nil {
+		return false, nil
+	}
+	for _, p := range tow.Payments {
+		if payment.Reference != nil && stringValue(p.Reference) == *payment.Reference {
+			return false, nil
+		}
+	}
+	tow.Payments = append(tow.Payments, *payment)
+	r.replace(id, tow)
+	return true, nil
+}
+
+func (r *memoryTows) VoidPayment(ctx context.Context, id string, paymentId string, voidedBy string, voidedAt int64) (bool, error) {
+	tow := r.get(id)
+	if tow == nil {
+		return false, nil
+	}
+	for i, p := range tow.Payments {
+		if stringValue(p.ID) == paymentId && p.VoidedAt == nil {
+			tow.Payments[i].VoidedBy = &voidedBy
+			tow.Payments[i].VoidedAt = &voidedAt
+			r.replace(id, tow)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryTows) FindByPaymentIntent(ctx context.Context, paymentIntentId string) ([]*model.Tow, error) {
+	tows, err := r.Find(ctx, &model.Tow{})
+	if err != nil {
+		return nil, err
+	}
+	var found []*model.Tow
+	for _, tow := range tows {
+		paid := stringValue(tow.PaymentIntentID) == paymentIntentId
+		for _, p := range tow.Payments {
+			paid = paid || stringValue(p.PaymentIntentID) == paymentIntentId
+		}
+		if paid {
+			found = append(found, tow)
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryTows) SetPaymentIntent(ctx context.Context, id string, paymentId string, paymentIntentId string) error {
+	tow := r.get(id)
+	if tow == nil {
+		return nil
+	}
+	for i, p := range tow.Payments {
+		if stringValue(p.ID) == paymentId {
+			tow.Payments[i].PaymentIntentID = &paymentIntentId
+			r.replace(id, tow)
+		}
+	}
+	return nil
+}
+
+type memoryQuotes struct{ memoryStore[model.Quote] }
+
+func (r *memoryQuotes) Claim(ctx context.Context, id string, towId string) (bool, error) {
+	quote := r.get(id)
+	if quote == nil || quote.TowID != nil {
+		return false, nil
+	}
+	quote.TowID = &towId
+	r.replace(id, quote)
+	return true, nil
+}
+
+func (r *memoryQuotes) Release(ctx context.Context, id string, towId string) error {
+	quote := r.get(id)
+	if quote != nil && stringValue(quote.TowID) == towId {
+		quote.TowID = nil
+		r.replace(id, quote)
+	}
+	return nil
+}
+
+type memoryWebhookEvents struct {
+	store memoryStore[model.WebhookEvent]
+}
+
+func (r *memoryWebhookEvents) Create(ctx context.Context, item *model.WebhookEvent) (bool, error) {
+	if r.store.get(stringValue(item.ID)) != nil {
+		return false, nil
+	}
+	return true, r.store.Create(ctx, item)
+}
+
+func (r *memoryWebhookEvents) Find(ctx context.Context, filterModel *model.WebhookEvent) ([]*model.WebhookEvent, error) {
+	return r.store.Find(ctx, filterModel)
+}
+
+func (r *memoryWebhookEvents) Update(ctx context.Context, id string, updateData *model.WebhookEvent) error {
+	return r.store.Update(ctx, id, updateData)
+}
+
+func (r *memoryWebhookEvents) Claim(ctx context.Context, id string, now int64, leaseUntil int64) (bool, error) {
+	event := r.store.get(id)
+	if event == nil {
+		return false, nil
+	}
+	switch stringValue(event.Status) {
+	case model.WebhookEventStatusPending, model.WebhookEventStatusFailed:
+	case model.WebhookEventStatusProcessing:
+		if int64Value(event.LockedUntil) >= now {
+			return false, nil
+		}
+	default:
+		return false, nil
+	}
+	status := model.WebhookEventStatusProcessing
+	attempts := intValue(event.Attempts) + 1
+	return true, r.store.Update(ctx, id, &model.WebhookEvent{Status: &status, LockedUntil: &leaseUntil, Attempts: &attempts})
+}
+
+type sentEmail struct{ recipient, subject, content string }
+
+type emailRecorder struct {
+	mu     sync.Mutex
+	emails []sentEmail
+}
+
+func (r *emailRecorder) SendEmail(ctx context.Context, recipient string, subject string, content string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emails = append(r.emails, sentEmail{recipient, subject, content})
+	return nil
+}
+
+// paymentFlow wires TowService and PaymentService to in-memory repositories and the fake payment gateway, with
+// one company on the standard plan and one quote to book.
+type paymentFlow struct {
+	gateway    *paymenttest.FakePaymentGateway
+	tows       *memoryTows
+	quotes     *memoryQuotes
+	events     *memoryWebhookEvents
+	emails     *emailRecorder
+	towSvc     *TowService
+	paymentSvc *PaymentService
+	accountId  string
+}
+
+func newPaymentFlow(t *testing.T) *paymentFlow {
+	t.Helper()
+	ctx := context.Background()
+
+	f := &paymentFlow{
+		gateway: paymenttest.NewFakePaymentGateway(),
+		tows:    &memoryTows{},
+		quotes:  &memoryQuotes{},
+		events:  &memoryWebhookEvents{},
+		emails:  &emailRecorder{},
+	}
+	companies := &memoryStore[model.Company]{}
+	users := &memoryStore[model.User]{}
+
+	accountId, err := f.gateway.CreateConnectedAccount(ctx)
+	if err != nil {
+		t.Fatalf("CreateConnectedAccount() error = %v", err)
+	}
+	f.accountId = accountId
+
+	companyId, name, link := "company-1", "Acme Towing", "acme-towing"
+	if err := companies.Create(ctx, &model.Company{ID: &companyId, Name: &name, SchedulingLink: &link, StripeAccountId: &accountId}); err != nil {
+		t.Fatal(err)
+	}
+	userId, userEmail := "user-1", "dispatch@acme.example"
+	if err := users.Create(ctx, &model.User{ID: &userId, CompanyID: &companyId, Email: &userEmail}); err != nil {
+		t.Fatal(err)
+	}
+
+	quoteId, pickup, destination := "quote-1", "1 Main St", "9 Elm St"
+	createdAt, expiresAt := time.Now().Unix(), time.Now().Add(time.Hour).Unix()
+	quote := &model.Quote{
+		ID:          &quoteId,
+		CompanyID:   &companyId,
+		Pickup:      &pickup,
+		Destination: &destination,
+		Breakdown: &model.PriceBreakdown{
+			LineItems: []model.PayableLineItem{{Name: "Hook-up", Amount: 7500, Quantity: 1}, {Name: "Mileage", Amount: 5000, Quantity: 10}},
+			Total:     12500,
+			Miles:     10,
+		},
+		CreatedAt: &createdAt,
+		ExpiresAt: &expiresAt,
+	}
+	if err := f.quotes.Create(ctx, quote); err != nil {
+		t.Fatal(err)
+	}
+
+	f.towSvc = NewTowService(f.tows, nil, nil, companies, nil, f.quotes, nil, nil, nil, nil, nil, f.gateway, f.emails)
+	f.paymentSvc = NewPaymentService(f.tows, companies, f.events, users, f.gateway, f.emails)
+	return f
+}
+
+// schedule books the quote for a customer.
+func (f *paymentFlow) schedule(t *testing.T) *model.Tow {
+	t.Helper()
+
+	quoteId, email := "quote-1", "driver@example.com"
+	tow, err := f.towSvc.ScheduleTow(context.Background(), &model.Tow{QuoteID: &quoteId, PrimaryContact: &model.PrimaryContact{Email: &email}}, "acme-towing")
+	if err != nil {
+		t.Fatalf("ScheduleTow() error = %v", err)
+	}
+	return tow
+}
+
+// pay pays the tow's checkout and delivers the webhook Stripe would send.
+func (f *paymentFlow) pay(t *testing.T, tow *model.Tow) []byte {
+	t.Helper()
+
+	payload, err := f.gateway.PayCheckout(stringValue(tow.PaymentReference))
+	if err != nil {
+		t.Fatalf("PayCheckout() error = %v", err)
+	}
+	if err := f.paymentSvc.HandleWebhookEvent(context.Background(), payload, paymenttest.FakeWebhookSignature); err != nil {
+		t.Fatalf("HandleWebhookEvent() error = %v", err)
+	}
+	return payload
+}
+
+// deliver sends the webhook Stripe would send for an event about object, created at the given unix time.
+func (f *paymentFlow) deliver(t *testing.T, eventType stripe.EventType, object any, created int64) {
+	t.Helper()
+
+	payload, err := f.gateway.WebhookPayload(eventType, object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event map[string]any
+	if err := json.Unmarshal(payload, &event); err != nil {
+		t.Fatal(err)
+	}
+	event["created"] = created
+	if payload, err = json.Marshal(event); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.paymentSvc.HandleWebhookEvent(context.Background(), payload, paymenttest.FakeWebhookSignature); err != nil {
+		t.Fatalf("HandleWebhookEvent() %s error = %v", eventType, err)
+	}
+}
+
+func TestScheduleTowCreatesCheckout(t *testing.T) {
+	f := newPaymentFlow(t)
+	tow := f.schedule(t)
+
+	checkout := f.gateway.Checkout(stringValue(tow.PaymentReference))
+	if checkout == nil {
+		t.Fatalf("no checkout session %q", stringValue(tow.PaymentReference))
+	}
+	if checkout.TowID != *tow.ID || checkout.DestinationAccountID != f.accountId || checkout.Session.AmountTotal != 12500 {
+		t.Errorf("checkout = tow %s, destination %s, total %d; want tow %s, destination %s, total 12500",
+			checkout.TowID, checkout.DestinationAccountID, checkout.Session.AmountTotal, *tow.ID, f.accountId)
+	}
+	if checkout.ApplicationFee != 625 || intValue(tow.ApplicationFee) != 625 {
+		t.Errorf("application fee = %d on the checkout and %d on the tow, want 625", checkout.ApplicationFee, intValue(tow.ApplicationFee))
+	}
+
+	saved := f.tows.get(*tow.ID)
+	if saved == nil {
+		t.Fatal("tow was not saved")
+	}
+	if stringValue(saved.PaymentStatus) != paymentStatusUnpaid || intValue(saved.BalanceDue) != 12500 || stringValue(saved.CheckoutUrl) != checkout.Session.URL {
+		t.Errorf("saved tow = status %q, balance %d, checkout %q; want unpaid, 12500, %q",
+			stringValue(saved.PaymentStatus), intValue(saved.BalanceDue), stringValue(saved.CheckoutUrl), checkout.Session.URL)
+	}
+	if quote := f.quotes.get("quote-1"); stringValue(quote.TowID) != *tow.ID {
+		t.Errorf("quote booked for %q, want %q", stringValue(quote.TowID), *tow.ID)
+	}
+
+	if len(f.emails.emails) != 1 || f.emails.emails[0].recipient != "driver@example.com" || !strings.Contains(f.emails.emails[0].content, checkout.Session.URL) {
+		t.Errorf("emails = %+v, want the checkout link sent to driver@example.com", f.emails.emails)
+	}
+}
+
+func TestScheduleTowReleasesQuoteWhenCheckoutFails(t *testing.T) {
+	f := newPaymentFlow(t)
+	f.gateway.Err = errors.New("stripe is unavailable")
+
+	quoteId, email := "quote-1", "driver@example.com"
+	_, err := f.towSvc.ScheduleTow(context.Background(), &model.Tow{QuoteID: &quoteId, PrimaryContact: &model.PrimaryContact{Email: &email}}, "acme-towing")
+	if err == nil || !strings.Contains(err.Error(), "stripe is unavailable") {
+		t.Fatalf("ScheduleTow() error = %v, want the gateway error", err)
+	}
+
+	if tows, _ := f.tows.Find(context.Background(), &model.Tow{}); len(tows) != 0 {
+		t.Errorf("%d tows saved, want none", len(tows))
+	}
+	if quote := f.quotes.get("quote-1"); quote.TowID != nil {
+		t.Errorf("quote still booked for %q", *quote.TowID)
+	}
+	if len(f.emails.emails) != 0 {
+		t.Errorf("emails = %+v, want none", f.emails.emails)
+	}
+}
+
+func TestCheckoutWebhookRecordsPayment(t *testing.T) {
+	f := newPaymentFlow(t)
+	tow := f.schedule(t)
+	payload := f.pay(t, tow)
+
+	// Stripe redelivers events; the payment must only be counted once
+	if err := f.paymentSvc.HandleWebhookEvent(context.Background(), payload, paymenttest.FakeWebhookSignature); err != nil {
+		t.Fatalf("HandleWebhookEvent() redelivery error = %v", err)
+	}
+
+	paid := f.tows.get(*tow.ID)
+	if stringValue(paid.PaymentStatus) != paymentStatusPaid || intValue(paid.PaidAmount) != 12500 || intValue(paid.BalanceDue) != 0 {
+		t.Errorf("paid tow = status %q, paid %d, balance %d; want paid, 12500, 0",
+			stringValue(paid.PaymentStatus), intValue(paid.PaidAmount), intValue(paid.BalanceDue))
+	}
+	if len(paid.Payments) != 1 {
+		t.Fatalf("%d ledger payments, want 1", len(paid.Payments))
+	}
+	if p := paid.Payments[0]; stringValue(p.Method) != model.PaymentMethodStripe || intValue(p.Amount) != 12500 || stringValue(p.Reference) != stringValue(tow.PaymentReference) {
+		t.Errorf("ledger payment = %s %d ref %s, want stripe 12500 ref %s",
+			stringValue(p.Method), intValue(p.Amount), stringValue(p.Reference), stringValue(tow.PaymentReference))
+	}
+
+	session, err := f.gateway.GetCheckoutSession(context.Background(), stringValue(tow.PaymentReference))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stringValue(paid.PaymentIntentID) != session.PaymentIntent.ID {
+		t.Errorf("payment intent = %q, want %q", stringValue(paid.PaymentIntentID), session.PaymentIntent.ID)
+	}
+
+	events, _ := f.events.Find(context.Background(), &model.WebhookEvent{})
+	if len(events) != 1 || stringValue(events[0].Status) != model.WebhookEventStatusProcessed || intValue(events[0].Attempts) != 1 {
+		t.Errorf("webhook events = %d, want one processed in one attempt", len(events))
+	}
+
+	if err := f.paymentSvc.HandleWebhookEvent(context.Background(), payload, "forged"); err == nil {
+		t.Error("HandleWebhookEvent() accepted a forged signature")
+	}
+}
+
+func TestCheckoutWebhookIgnoresStaleEvents(t *testing.T) {
+	f := newPaymentFlow(t)
+	tow := f.schedule(t)
+	session := &stripe.CheckoutSession{ID: stringValue(tow.PaymentReference), Object: "checkout.session"}
+	now := time.Now().Unix()
+
+	f.deliver(t, stripe.EventTypeCheckoutSessionExpired, session, now)
+	// A declined bank debit from before the session expired arrives late
+	f.deliver(t, stripe.EventTypeCheckoutSessionAsyncPaymentFailed, session, now-60)
+
+	saved := f.tows.get(*tow.ID)
+	if stringValue(saved.PaymentStatus) != paymentStatusExpired || int64Value(saved.CheckoutEventAt) != now {
+		t.Errorf("tow = status %q, checkout event at %d; want expired at %d", stringValue(saved.PaymentStatus), int64Value(saved.CheckoutEventAt), now)
+	}
+
+	f.deliver(t, stripe.EventTypeCheckoutSessionAsyncPaymentFailed, session, now+60)
+	if saved := f.tows.get(*tow.ID); stringValue(saved.PaymentStatus) != paymentStatusFailed {
+		t.Errorf("status after a newer failure = %q, want failed", stringValue(saved.PaymentStatus))
+	}
+}
+
+func TestWebhookEventsAreForPlatformAdmins(t *testing.T) {
+	t.Setenv("PLATFORM_ADMIN_USER_IDS", "admin-1, admin-2")
+	f := newPaymentFlow(t)
+	ctx := context.Background()
+	f.pay(t, f.schedule(t))
+
+	for userId, wantErr := range map[string]string{"": "user id is required", "user-1": "not allowed"} {
+		if _, err := f.paymentSvc.FindWebhookEvents(ctx, userId, ""); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("FindWebhookEvents(%q) error = %v, want %q", userId, err, wantErr)
+		}
+		if _, err := f.paymentSvc.ReplayWebhookEvent(ctx, userId, "evt_1"); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("ReplayWebhookEvent(%q) error = %v, want %q", userId, err, wantErr)
+		}
+	}
+
+	events, err := f.paymentSvc.FindWebhookEvents(ctx, "admin-2", "")
+	if err != nil {
+		t.Fatalf("FindWebhookEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Payload != nil {
+		t.Errorf("webhook events = %d, want one without its payload", len(events))
+	}
+	if stored, _ := f.events.Find(ctx, &model.WebhookEvent{}); len(stored) != 1 || stored[0].Payload == nil {
+		t.Errorf("listing the events dropped the stored payload")
+	}
+	if _, err := f.paymentSvc.ReplayWebhookEvent(ctx, "admin-1", stringValue(events[0].ID)); err == nil || !strings.Contains(err.Error(), "already been processed") {
+		t.Errorf("ReplayWebhookEvent() of a processed event error = %v", err)
+	}
+}
+
+func TestRegeneratePaymentLinkAfterPriceEdit(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx := context.Background()
+	tow := f.schedule(t)
+	oldSession := stringValue(tow.PaymentReference)
+
+	price := 15000
+	if _, err := f.towSvc.UpdateTow(ctx, *tow.ID, &model.Tow{Price: &price}); err != nil {
+		t.Fatalf("UpdateTow() error = %v", err)
+	}
+
+	regenerated, err := f.towSvc.RegeneratePaymentLink(ctx, *tow.ID)
+	if err != nil {
+		t.Fatalf("RegeneratePaymentLink() error = %v", err)
+	}
+	if stringValue(regenerated.PaymentReference) == oldSession {
+		t.Fatal("payment link was not replaced")
+	}
+	if old := f.gateway.Checkout(oldSession); old.Session.Status != stripe.CheckoutSessionStatusExpired {
+		t.Errorf("old checkout is %s, want expired", old.Session.Status)
+	}
+
+	// The booked line items add up to 12500, so the new price is collected as a single item
+	checkout := f.gateway.Checkout(stringValue(regenerated.PaymentReference))
+	if checkout.Session.AmountTotal != 15000 || len(checkout.LineItems) != 1 || checkout.LineItems[0].Amount != 15000 {
+		t.Errorf("checkout = total %d, line items %+v; want 15000 as one item", checkout.Session.AmountTotal, checkout.LineItems)
+	}
+	if checkout.ApplicationFee != 750 || intValue(regenerated.ApplicationFee) != 750 {
+		t.Errorf("application fee = %d on the checkout and %d on the tow, want 750", checkout.ApplicationFee, intValue(regenerated.ApplicationFee))
+	}
+}
+
+func TestDisputeWebhookIgnoresStaleEvents(t *testing.T) {
+	f := newPaymentFlow(t)
+	tow := f.schedule(t)
+	f.pay(t, tow)
+	paymentIntentId := stringValue(f.tows.get(*tow.ID).PaymentIntentID)
+	now := time.Now().Unix()
+
+	won := &stripe.Dispute{ID: "dp_1", Object: "dispute", Amount: 12500, Status: stripe.DisputeStatusWon, PaymentIntent: &stripe.PaymentIntent{ID: paymentIntentId}}
+	f.deliver(t, stripe.EventTypeChargeDisputeClosed, won, now)
+	// The event that opened the dispute arrives after the one that closed it
+	opened := &stripe.Dispute{ID: "dp_1", Object: "dispute", Amount: 12500, Status: stripe.DisputeStatusNeedsResponse, PaymentIntent: &stripe.PaymentIntent{ID: paymentIntentId}}
+	f.deliver(t, stripe.EventTypeChargeDisputeCreated, opened, now-60)
+
+	saved := f.tows.get(*tow.ID)
+	if stringValue(saved.PaymentStatus) != paymentStatusPaid || stringValue(saved.Dispute.Status) != string(stripe.DisputeStatusWon) {
+		t.Errorf("tow = status %q, dispute %q; want paid, won", stringValue(saved.PaymentStatus), stringValue(saved.Dispute.Status))
+	}
+
+	var alerts int
+	for _, email := range f.emails.emails {
+		if email.recipient == "dispatch@acme.example" {
+			alerts++
+		}
+	}
+	if alerts != 1 {
+		t.Errorf("%d dispute alerts sent, want 1 for the dispute closing", alerts)
+	}
+}
+
+func TestRefundTow(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx := context.Background()
+	tow := f.schedule(t)
+	f.pay(t, tow)
+
+	refunded, err := f.paymentSvc.RefundTow(ctx, *tow.ID, 2500, string(stripe.RefundReasonRequestedByCustomer), "user-1")
+	if err != nil {
+		t.Fatalf("RefundTow() partial error = %v", err)
+	}
+	if stringValue(refunded.PaymentStatus) != paymentStatusPartiallyRefunded || intValue(refunded.RefundedAmount) != 2500 || intValue(refunded.BalanceDue) != 2500 {
+		t.Errorf("after partial refund = status %q, refunded %d, balance %d; want partially_refunded, 2500, 2500",
+			stringValue(refunded.PaymentStatus), intValue(refunded.RefundedAmount), intValue(refunded.BalanceDue))
+	}
+	if len(refunded.Refunds) != 1 || stringValue(refunded.Refunds[0].IssuedBy) != "user-1" {
+		t.Errorf("refunds = %+v, want one issued by user-1", refunded.Refunds)
+	}
+
+	if _, err := f.paymentSvc.RefundTow(ctx, *tow.ID, 10001, "", "user-1"); err == nil {
+		t.Error("RefundTow() refunded more than was left")
+	}
+
+	// A refund made in the Stripe dashboard is reconciled from the charge.refunded webhook
+	paymentIntentId := stringValue(refunded.PaymentIntentID)
+	if _, err := f.gateway.CreateRefund(ctx, paymentIntentId, 2500, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	payload, err := f.gateway.WebhookPayload(stripe.EventTypeChargeRefunded, &stripe.Charge{ID: "ch_1", PaymentIntent: &stripe.PaymentIntent{ID: paymentIntentId}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.paymentSvc.HandleWebhookEvent(ctx, payload, paymenttest.FakeWebhookSignature); err != nil {
+		t.Fatalf("HandleWebhookEvent() charge.refunded error = %v", err)
+	}
+	if synced := f.tows.get(*tow.ID); intValue(synced.RefundedAmount) != 5000 || len(synced.Refunds) != 2 || stringValue(synced.Refunds[1].IssuedBy) != refundIssuedByStripe {
+		t.Errorf("after dashboard refund = refunded %d, refunds %+v; want 5000 with the second issued by stripe", intValue(synced.RefundedAmount), synced.Refunds)
+	}
+
+	refunded, err = f.paymentSvc.RefundTow(ctx, *tow.ID, 0, "", "user-1")
+	if err != nil {
+		t.Fatalf("RefundTow() remainder error = %v", err)
+	}
+	if stringValue(refunded.PaymentStatus) != paymentStatusRefunded || intValue(refunded.RefundedAmount) != 12500 {
+		t.Errorf("after full refund = status %q, refunded %d; want refunded, 12500", stringValue(refunded.PaymentStatus), intValue(refunded.RefundedAmount))
+	}
+
+	if _, err := f.paymentSvc.RefundTow(ctx, *tow.ID, 0, "", "user-1"); err == nil {
+		t.Error("RefundTow() refunded a tow that was already refunded")
+	}
+}
+
+func TestRefundTowPaidWithTwoCheckouts(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx := context.Background()
+	tow := f.schedule(t)
+	f.pay(t, tow)
+	firstIntent := stringValue(f.tows.get(*tow.ID).PaymentIntentID)
+
+	// The price goes up after the tow was paid, so the customer pays the difference with a new link
+	price := 15000
+	if _, err := f.towSvc.UpdateTow(ctx, *tow.ID, &model.Tow{Price: &price}); err != nil {
+		t.Fatalf("UpdateTow() error = %v", err)
+	}
+	regenerated, err := f.towSvc.RegeneratePaymentLink(ctx, *tow.ID)
+	if err != nil {
+		t.Fatalf("RegeneratePaymentLink() error = %v", err)
+	}
+	f.pay(t, regenerated)
+
+	saved := f.tows.get(*tow.ID)
+	secondIntent := stringValue(saved.PaymentIntentID)
+	if len(saved.Payments) != 2 || stringValue(saved.Payments[0].PaymentIntentID) != firstIntent || stringValue(saved.Payments[1].PaymentIntentID) != secondIntent || firstIntent == secondIntent {
+		t.Fatalf("payments = %+v, want one for each of %s and %s", saved.Payments, firstIntent, secondIntent)
+	}
+
+	for userId, wantErr := range map[string]string{"": "user id is required", "user-2": "not allowed"} {
+		if _, err := f.paymentSvc.RefundTow(ctx, *tow.ID, 0, "", userId); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("RefundTow(%q) error = %v, want %q", userId, err, wantErr)
+		}
+	}
+
+	// A refund made in the Stripe dashboard on the first payment is found from its intent
+	if _, err := f.gateway.CreateRefund(ctx, firstIntent, 2000, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	f.deliver(t, stripe.EventTypeChargeRefunded, &stripe.Charge{ID: "ch_1", PaymentIntent: &stripe.PaymentIntent{ID: firstIntent}}, time.Now().Unix())
+	if synced := f.tows.get(*tow.ID); intValue(synced.RefundedAmount) != 2000 || len(synced.Refunds) != 1 || stringValue(synced.Refunds[0].PaymentIntentID) != firstIntent {
+		t.Errorf("after dashboard refund = refunded %d, refunds %+v; want 2000 from %s", intValue(synced.RefundedAmount), synced.Refunds, firstIntent)
+	}
+
+	if _, err := f.paymentSvc.RefundTow(ctx, *tow.ID, 13001, "", "user-1"); err == nil || err.Error() != "refund amount must be between 1 and 13000 cents" {
+		t.Errorf("RefundTow() of more than was left error = %v", err)
+	}
+
+	// 4000 takes the second payment's 2500 and 1500 of what is left of the first
+	refunded, err := f.paymentSvc.RefundTow(ctx, *tow.ID, 4000, "", "user-1")
+	if err != nil {
+		t.Fatalf("RefundTow() error = %v", err)
+	}
+	if intValue(refunded.RefundedAmount) != 6000 || len(refunded.Refunds) != 3 || stringValue(refunded.PaymentStatus) != paymentStatusPartiallyRefunded {
+		t.Errorf("after refund = status %q, refunded %d, refunds %+v; want partially_refunded, 6000 in 3 refunds",
+			stringValue(refunded.PaymentStatus), intValue(refunded.RefundedAmount), refunded.Refunds)
+	}
+	byIntent := map[string]int{}
+	for _, r := range refunded.Refunds {
+		byIntent[stringValue(r.PaymentIntentID)] += intValue(r.Amount)
+	}
+	if byIntent[firstIntent] != 3500 || byIntent[secondIntent] != 2500 {
+		t.Errorf("refunded by intent = %v, want 3500 from the first and 2500 from the second", byIntent)
+	}
+
+	refunded, err = f.paymentSvc.RefundTow(ctx, *tow.ID, 0, "", "user-1")
+	if err != nil {
+		t.Fatalf("RefundTow() remainder error = %v", err)
+	}
+	if stringValue(refunded.PaymentStatus) != paymentStatusRefunded || intValue(refunded.RefundedAmount) != 15000 {
+		t.Errorf("after full refund = status %q, refunded %d; want refunded, 15000", stringValue(refunded.PaymentStatus), intValue(refunded.RefundedAmount))
+	}
+}
+
+func TestBookingCannotSetLedgerFields(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx := context.Background()
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	at := time.Now().Unix()
+
+	tow, err := f.towSvc.ScheduleTow(ctx, &model.Tow{
+		QuoteID:          str("quote-1"),
+		PrimaryContact:   &model.PrimaryContact{Email: str("driver@example.com")},
+		Payments:         []model.Payment{{ID: str("payment-1"), Method: str(model.PaymentMethodCash), Amount: cents(12500)}},
+		Refunds:          []model.Refund{{ID: str("re_1"), Amount: cents(100)}},
+		RefundedAmount:   cents(100),
+		Dispute:          &model.Dispute{ID: str("dp_1")},
+		InvoiceNumber:    str("INV-1"),
+		InvoicedAt:       &at,
+		PaymentIntentID:  str("pi_other"),
+		CheckoutEventAt:  &at,
+		ApplicationFee:   cents(0),
+		DiscountAmount:   cents(5000),
+		PriceListID:      str("list-1"),
+		PriceListVersion: cents(3),
+		DriverID:         str("driver-1"),
+		DispatchedAt:     &at,
+		CompletedAt:      &at,
+	}, "acme-towing")
+	if err != nil {
+		t.Fatalf("ScheduleTow() error = %v", err)
+	}
+
+	saved := f.tows.get(*tow.ID)
+	if saved.Payments != nil || saved.Refunds != nil || saved.RefundedAmount != nil || saved.Dispute != nil || saved.InvoiceNumber != nil || saved.InvoicedAt != nil ||
+		saved.PaymentIntentID != nil || saved.CheckoutEventAt != nil || saved.DiscountAmount != nil || saved.PriceListID != nil || saved.PriceListVersion != nil ||
+		saved.DriverID != nil || saved.DispatchedAt != nil || saved.CompletedAt != nil {
+		t.Errorf("booking kept fields the server owns: %+v", saved)
+	}
+	if intValue(saved.ApplicationFee) != 625 || intValue(saved.BalanceDue) != 12500 || stringValue(saved.PaymentStatus) != paymentStatusUnpaid {
+		t.Errorf("tow = fee %d, balance %d, status %q; want 625, 12500, unpaid", intValue(saved.ApplicationFee), intValue(saved.BalanceDue), stringValue(saved.PaymentStatus))
+	}
+
+	for name, update := range map[string]*model.Tow{
+		"payments":       {Payments: []model.Payment{{ID: str("payment-1"), Method: str(model.PaymentMethodCash), Amount: cents(12500)}}},
+		"paymentStatus":  {PaymentStatus: str(paymentStatusPaid)},
+		"refundedAmount": {RefundedAmount: cents(0)},
+		"dispute":        {Dispute: &model.Dispute{ID: str("dp_1")}},
+		"invoiceNumber":  {InvoiceNumber: str("INV-1")},
+	} {
+		if _, err := f.towSvc.UpdateTow(ctx, *tow.ID, update); err == nil || !strings.HasPrefix(err.Error(), name+" cannot be updated") {
+			t.Errorf("UpdateTow() of %s error = %v", name, err)
+		}
+	}
+	if saved := f.tows.get(*tow.ID); saved.Payments != nil || stringValue(saved.PaymentStatus) != paymentStatusUnpaid {
+		t.Errorf("update changed the ledger: status %q, payments %+v", stringValue(saved.PaymentStatus), saved.Payments)
+	}
+}
+
+func TestDisputeOnAnEarlierPayment(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx := context.Background()
+	tow := f.schedule(t)
+	f.pay(t, tow)
+	paid := f.tows.get(*tow.ID)
+	firstIntent := stringValue(paid.PaymentIntentID)
+	// The first payment was recorded before payments kept their intent
+	paid.Payments[0].PaymentIntentID = nil
+	f.tows.replace(*tow.ID, paid)
+
+	price := 15000
+	if _, err := f.towSvc.UpdateTow(ctx, *tow.ID, &model.Tow{Price: &price}); err != nil {
+		t.Fatalf("UpdateTow() error = %v", err)
+	}
+	regenerated, err := f.towSvc.RegeneratePaymentLink(ctx, *tow.ID)
+	if err != nil {
+		t.Fatalf("RegeneratePaymentLink() error = %v", err)
+	}
+	f.pay(t, regenerated)
+	if saved := f.tows.get(*tow.ID); stringValue(saved.PaymentIntentID) == firstIntent || stringValue(saved.Payments[0].PaymentIntentID) != firstIntent {
+		t.Fatalf("payments = %+v, want the first to keep %s when the second checkout was paid", saved.Payments, firstIntent)
+	}
+
+	// The customer disputes the first payment after the tow's intent moved on to the second
+	now := time.Now().Unix()
+	opened := &stripe.Dispute{ID: "dp_1", Object: "dispute", Amount: 12500, Status: stripe.DisputeStatusNeedsResponse, PaymentIntent: &stripe.PaymentIntent{ID: firstIntent}}
+	f.deliver(t, stripe.EventTypeChargeDisputeCreated, opened, now)
+	saved := f.tows.get(*tow.ID)
+	if stringValue(saved.PaymentStatus) != paymentStatusDisputed || saved.Dispute == nil || stringValue(saved.Dispute.PaymentIntentID) != firstIntent {
+		t.Fatalf("tow = status %q, dispute %+v; want disputed on %s", stringValue(saved.PaymentStatus), saved.Dispute, firstIntent)
+	}
+
+	won := &stripe.Dispute{ID: "dp_1", Object: "dispute", Amount: 12500, Status: stripe.DisputeStatusWon, PaymentIntent: &stripe.PaymentIntent{ID: firstIntent}}
+	f.deliver(t, stripe.EventTypeChargeDisputeClosed, won, now+60)
+	if saved := f.tows.get(*tow.ID); stringValue(saved.PaymentStatus) != paymentStatusPaid || stringValue(saved.Dispute.Status) != string(stripe.DisputeStatusWon) {
+		t.Errorf("tow = status %q, dispute %q; want paid, won", stringValue(saved.PaymentStatus), stringValue(saved.Dispute.Status))
+	}
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"tow-management-system-api/model"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+// PaymentGateway is the payment provider behind companies' connected accounts, tow checkouts, refunds, payouts
+// and webhooks. utilities.StripeUtility talks to Stripe; paymenttest.FakePaymentGateway keeps everything in memory
+// for tests. Amounts are in cents.
+type PaymentGateway interface {
+	// Connected accounts
+	CreateConnectedAccount(ctx context.Context) (string, error)
+	GetAccount(ctx context.Context, accountId string) (*stripe.Account, error)
+	CreateLoginLink(ctx context.Context, accountId string) (string, error)
+	CreateAccountLink(ctx context.Context, accountId, returnURL, refreshURL string) (string, error)
+
+	// Checkout sessions
+	CreatePayableItem(ctx context.Context, towId string, total int64, lineItems []model.PayableLineItem, destinationAccountId string, applicationFee int64, successURL, cancelURL string) (string, string, error)
+	GetCheckoutSession(ctx context.Context, sessionId string) (*stripe.CheckoutSession, error)
+	ExpireCheckoutSession(ctx context.Context, sessionId string) (*stripe.CheckoutSession, error)
+
+	// Refunds
+	CreateRefund(ctx context.Context, paymentIntentId string, amount int64, reason string, metadata map[string]string) (*stripe.Refund, error)
+	ListRefunds(ctx context.Context, paymentIntentId string) ([]*stripe.Refund, error)
+
+	// Balances and payouts of connected accounts
+	GetAccountBalance(ctx context.Context, accountId string) (int64, int64, error)
+	ListPayouts(ctx context.Context, accountId string, from, to int64) ([]*stripe.Payout, error)
+	ListPayoutTransactions(ctx context.Context, accountId, payoutId string) ([]*stripe.BalanceTransaction, error)
+
+	// Webhooks
+	ConstructWebhookEvent(payload []byte, signature string) (stripe.Event, error)
+}
+
+// EmailSender is the minimal dependency services need to email customers and companies.
+type EmailSender interface {
+	SendEmail(ctx context.Context, recipient string, subject string, content string) error
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"tow-management-system-api/model"
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v83"
+)
+
+// Payment statuses derived from the payment ledger, besides unpaid and paid.
+const (
+	paymentStatusPartial  = "partial"
+	paymentStatusOverpaid = "overpaid"
+)
+
+// paymentCollectedByStripe marks ledger payments that were collected through Stripe checkout.
+const paymentCollectedByStripe = "stripe"
+
+// offlinePaymentMethods are the payment methods staff can record by hand.
+var offlinePaymentMethods = map[string]bool{
+	model.PaymentMethodCash:      true,
+	model.PaymentMethodCheck:     true,
+	model.PaymentMethodCard:      true,
+	model.PaymentMethodMotorClub: true,
+	model.PaymentMethodInsurance: true,
+	model.PaymentMethodOther:     true,
+}
+
+// RecordPayment adds an offline payment, e.g. cash collected by the driver or a motor club's share, to the
+// tow's ledger and updates its balance and payment status. collectedBy is the user id of the company user who
+// recorded it.
+func (s *PaymentService) RecordPayment(ctx context.Context, towId string, payment *model.Payment, collectedBy string) (*model.Tow, error) {
+	if payment == nil {
+		return nil, fmt.Errorf("payment is required")
+	}
+
+	method := strings.ToLower(strings.TrimSpace(stringValue(payment.Method)))
+	if method == model.PaymentMethodStripe {
+		return nil, fmt.Errorf("stripe payments are recorded from checkout")
+	}
+	if !offlinePaymentMethods[method] {
+		return nil, fmt.Errorf("unknown payment method %q", stringValue(payment.Method))
+	}
+	if intValue(payment.Amount) <= 0 {
+		return nil, fmt.Errorf("payment amount must be greater than zero")
+	}
+	reference := strings.TrimSpace(stringValue(payment.Reference))
+	if method == model.PaymentMethodCheck && reference == "" {
+		return nil, fmt.Errorf("payment reference is required for checks")
+	}
+
+	now := time.Now().UTC().Unix()
+	collectedAt := now
+	if payment.CollectedAt != nil {
+		if *payment.CollectedAt > now {
+			return nil, fmt.Errorf("payment collectedAt must not be in the future")
+		}
+		collectedAt = *payment.CollectedAt
+	}
+
+	tow, err := s.findCompanyTow(ctx, towId, collectedBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.backfillStripePayment(ctx, tow); err != nil {
+		return nil, err
+	}
+
+	id := uuid.NewString()
+	entry := &model.Payment{
+		ID:          &id,
+		Method:      &method,
+		Amount:      payment.Amount,
+		Note:        payment.Note,
+		CollectedAt: &collectedAt,
+	}
+	if reference != "" {
+		entry.Reference = &reference
+	}
+	entry.CollectedBy = &collectedBy
+
+	added, err := s.towDataRepository.AddPayment(ctx, *tow.ID, entry)
+	if err != nil {
+		return nil, err
+	}
+	if !added {
+		return nil, fmt.Errorf("payment with reference %s was already recorded", reference)
+	}
+
+	return s.settlePayments(ctx, *tow.ID)
+}
+
+// VoidPayment voids a mistaken offline payment so it no longer counts toward the tow's balance. Stripe payments
+// are returned with a refund instead. voidedBy is the user id of the company user who voided it.
+func (s *PaymentService) VoidPayment(ctx context.Context, towId, paymentId, voidedBy string) (*model.Tow, error) {
+	tow, err := s.findCompanyTow(ctx, towId, voidedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *model.Payment
+	for i := range tow.Payments {
+		if stringValue(tow.Payments[i].ID) == paymentId {
+			entry = &tow.Payments[i]
+			break
+		}
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("payment not found")
+	}
+	if stringValue(entry.Method) == model.PaymentMethodStripe {
+		return nil, fmt.Errorf("stripe payments cannot be voided; refund them instead")
+	}
+
+	voided, err := s.towDataRepository.VoidPayment(ctx, *tow.ID, paymentId, voidedBy, time.Now().UTC().Unix())
+	if err != nil {
+		return nil, err
+	}
+	if !voided {
+		return nil, fmt.Errorf("payment was already voided")
+	}
+
+	return s.settlePayments(ctx, *tow.ID)
+}
+
+// recordStripePayment adds a paid checkout session to the tow's ledger. The session ID is the payment's
+// reference, so the webhook and the checkout return page can both record it without counting it twice.
+func (s *PaymentService) recordStripePayment(ctx context.Context, tow *model.Tow, session *stripe.CheckoutSession) (*model.Tow, error) {
+	if err := s.backfillStripePayment(ctx, tow); err != nil {
+		return nil, err
+	}
+	for _, p := range tow.Payments {
+		if stringValue(p.Reference) == session.ID {
+			return tow, nil
+		}
+	}
+
+	// Stripe payments recorded before payments kept their intent get it before the tow's intent moves on, so
+	// refunds and disputes on them still find the tow
+	for i := range tow.Payments {
+		p := &tow.Payments[i]
+		if stringValue(p.Method) == model.PaymentMethodStripe && stringValue(p.PaymentIntentID) == "" {
+			if _, err := s.ledgerPaymentIntent(ctx, tow, p); err != nil {
+				log.Printf("failed to look up the payment intent of payment %s on tow %s: %v", stringValue(p.ID), *tow.ID, err)
+			}
+		}
+	}
+
+	if session.PaymentIntent != nil && session.PaymentIntent.ID != "" && stringValue(tow.PaymentIntentID) != session.PaymentIntent.ID {
+		if err := s.towDataRepository.Update(ctx, *tow.ID, &model.Tow{PaymentIntentID: &session.PaymentIntent.ID}); err != nil {
+			return nil, fmt.Errorf("failed to update tow payment intent: %w", err)
+		}
+	}
+
+	id := uuid.NewString()
+	method := model.PaymentMethodStripe
+	amount := int(session.AmountTotal)
+	collectedBy := paymentCollectedByStripe
+	collectedAt := time.Now().UTC().Unix()
+	entry := &model.Payment{
+		ID:          &id,
+		Method:      &method,
+		Amount:      &amount,
+		Reference:   &session.ID,
+		CollectedBy: &collectedBy,
+		CollectedAt: &collectedAt,
+	}
+	if session.PaymentIntent != nil && session.PaymentIntent.ID != "" {
+		entry.PaymentIntentID = &session.PaymentIntent.ID
+	}
+
+	if _, err := s.towDataRepository.AddPayment(ctx, *tow.ID, entry); err != nil {
+		return nil, err
+	}
+
+	return s.settlePayments(ctx, *tow.ID)
+}
+
+// backfillStripePayment adds the Stripe payment of a tow paid before the ledger existed to its ledger, before
+// the first entry is recorded next to it. Without it the payment paidAmount infers from the status would stop
+// counting once the ledger has entries.
+func (s *PaymentService) backfillStripePayment(ctx context.Context, tow *model.Tow) error {
+	if len(tow.Payments) > 0 {
+		return nil
+	}
+	amount := paidAmount(tow, model.PaymentMethodStripe)
+	if amount <= 0 {
+		return nil
+	}
+
+	// The reference keeps concurrent backfills from adding the payment twice
+	reference := stringValue(tow.PaymentReference)
+	if reference == "" {
+		reference = "pre-ledger-" + *tow.ID
+	}
+	id := uuid.NewString()
+	method := model.PaymentMethodStripe
+	collectedBy := paymentCollectedByStripe
+	entry := &model.Payment{
+		ID:          &id,
+		Method:      &method,
+		Amount:      &amount,
+		Reference:   &reference,
+		CollectedBy: &collectedBy,
+		CollectedAt: tow.CreatedAt,
+	}
+	if stringValue(tow.PaymentIntentID) != "" {
+		entry.PaymentIntentID = tow.PaymentIntentID
+	}
+
+	added, err := s.towDataRepository.AddPayment(ctx, *tow.ID, entry)
+	if err != nil {
+		return err
+	}
+	if added {
+		tow.Payments = append(tow.Payments, *entry)
+	}
+	return nil
+}
+
+// settlePayments recomputes the tow's paid amount, balance due and payment status from its ledger and refunds.
+// The tow is read again so payments recorded concurrently are included.
+func (s *PaymentService) settlePayments(ctx context.Context, towId string) (*model.Tow, error) {
+	tow, err := s.findTow(ctx, towId)
+	if err != nil {
+		return nil, err
+	}
+
+	update := settledPayment(tow)
+	if err := s.towDataRepository.Update(ctx, towId, update); err != nil {
+		return nil, fmt.Errorf("failed to update tow balance: %w", err)
+	}
+
+	tow.PaidAmount = update.PaidAmount
+	tow.BalanceDue = update.BalanceDue
+	tow.PaymentStatus = update.PaymentStatus
+	return tow, nil
+}
+
+// findTow returns a tow by ID.
+func (s *PaymentService) findTow(ctx context.Context, towId string) (*model.Tow, error) {
+	if towId == "" {
+		return nil, fmt.Errorf("tow id is required")
+	}
+
+	tows, err := s.towDataRepository.Find(ctx, &model.Tow{ID: &towId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tow: %w", err)
+	}
+	if len(tows) == 0 || tows[0].ID == nil {
+		return nil, fmt.Errorf("tow not found")
+	}
+
+	return tows[0], nil
+}
+
+// findCompanyTow returns a tow whose payments the user may change because they belong to the company that ran it.
+func (s *PaymentService) findCompanyTow(ctx context.Context, towId, userId string) (*model.Tow, error) {
+	if userId == "" {
+		return nil, fmt.Errorf("user id is required")
+	}
+
+	tow, err := s.findTow(ctx, towId)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.userRepository.Find(ctx, &model.User{ID: &userId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if len(users) == 0 || stringValue(users[0].CompanyID) == "" || stringValue(users[0].CompanyID) != stringValue(tow.CompanyID) {
+		return nil, fmt.Errorf("user is not allowed to change this tow's payments")
+	}
+
+	return tow, nil
+}
+
+// settledPayment returns the update that brings the tow's paid amount, balance due and payment status in line
+// with its ledger.
+func settledPayment(tow *model.Tow) *model.Tow {
+	paid := paidAmount(tow, "")
+	balance := balanceDue(tow)
+	status := derivePaymentStatus(tow)
+	return &model.Tow{PaidAmount: &paid, BalanceDue: &balance, PaymentStatus: &status}
+}
+
+// paidAmount returns the cents collected by the tow's payments that were not voided, only counting payments
+// made with method when it is not empty. Tows paid through Stripe before the ledger existed have no entries
+// and count their price as one Stripe payment.
+func paidAmount(tow *model.Tow, method string) int {
+	if len(tow.Payments) == 0 {
+		switch stringValue(tow.PaymentStatus) {
+		case paymentStatusPaid, paymentStatusRefunded, paymentStatusPartiallyRefunded, paymentStatusDisputed, paymentStatusDisputeLost:
+			if method == "" || method == model.PaymentMethodStripe {
+				return intValue(tow.Price)
+			}
+		}
+		return 0
+	}
+
+	paid := 0
+	for _, p := range tow.Payments {
+		if p.VoidedAt != nil {
+			continue
+		}
+		if method != "" && stringValue(p.Method) != method {
+			continue
+		}
+		paid += intValue(p.Amount)
+	}
+	return paid
+}
+
+// balanceDue returns the cents still owed on the tow: its price less what was paid, plus what was refunded.
+// It is negative when the tow was overpaid.
+func balanceDue(tow *model.Tow) int {
+	return intValue(tow.Price) - paidAmount(tow, "") + intValue(tow.RefundedAmount)
+}
+
+// derivePaymentStatus returns the tow's payment status from its ledger and refunds. Disputed tows keep their
+// dispute status until Stripe closes the dispute.
+func derivePaymentStatus(tow *model.Tow) string {
+	switch status := stringValue(tow.PaymentStatus); status {
+	case paymentStatusDisputed, paymentStatusDisputeLost:
+		return status
+	}
+	return ledgerPaymentStatus(tow)
+}
+
+// ledgerPaymentStatus returns the payment status the tow's ledger and refunds add up to, comparing what was
+// paid less what was refunded with the price. A refund that leaves the tow fully paid, e.g. returning an
+// overpayment, leaves it paid. An unpaid tow keeps an expired or failed checkout status so dispatch can see
+// why it is unpaid.
+func ledgerPaymentStatus(tow *model.Tow) string {
+	refunded := intValue(tow.RefundedAmount)
+	net := paidAmount(tow, "") - refunded
+	price := intValue(tow.Price)
+	switch {
+	case net <= 0 && refunded > 0:
+		return paymentStatusRefunded
+	case net <= 0:
+		switch status := stringValue(tow.PaymentStatus); status {
+		case paymentStatusExpired, paymentStatusFailed:
+			return status
+		}
+		return paymentStatusUnpaid
+	case net < price && refunded > 0:
+		return paymentStatusPartiallyRefunded
+	case net < price:
+		return paymentStatusPartial
+	case net == price:
+		return paymentStatusPaid
+	default:
+		return paymentStatusOverpaid
+	}
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"tow-management-system-api/model"
+)
+
+func TestSettledPayment(t *testing.T) {
+	payment := func(method string, amount int, voided bool) model.Payment {
+		p := model.Payment{Method: &method, Amount: &amount}
+		if voided {
+			voidedAt := int64(1767225600)
+			p.VoidedAt = &voidedAt
+		}
+		return p
+	}
+	status := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+
+	tests := []struct {
+		name        string
+		tow         *model.Tow
+		wantPaid    int
+		wantBalance int
+		wantStatus  string
+	}{
+		{name: "nothing paid", tow: &model.Tow{Price: cents(10000)}, wantBalance: 10000, wantStatus: paymentStatusUnpaid},
+		{name: "expired checkout stays expired", tow: &model.Tow{Price: cents(10000), PaymentStatus: status(paymentStatusExpired)}, wantBalance: 10000, wantStatus: paymentStatusExpired},
+		{
+			name:        "motor club and cash add up to the price",
+			tow:         &model.Tow{Price: cents(10000), Payments: []model.Payment{payment(model.PaymentMethodMotorClub, 7500, false), payment(model.PaymentMethodCash, 2500, false)}},
+			wantPaid:    10000,
+			wantBalance: 0,
+			wantStatus:  paymentStatusPaid,
+		},
+		{
+			name:        "partial payment",
+			tow:         &model.Tow{Price: cents(10000), Payments: []model.Payment{payment(model.PaymentMethodMotorClub, 7500, false)}},
+			wantPaid:    7500,
+			wantBalance: 2500,
+			wantStatus:  paymentStatusPartial,
+		},
+		{
+			name:        "voided payments do not count",
+			tow:         &model.Tow{Price: cents(10000), Payments: []model.Payment{payment(model.PaymentMethodCash, 10000, true)}},
+			wantBalance: 10000,
+			wantStatus:  paymentStatusUnpaid,
+		},
+		{
+			name:        "overpaid",
+			tow:         &model.Tow{Price: cents(10000), Payments: []model.Payment{payment(model.PaymentMethodStripe, 10000, false), payment(model.PaymentMethodCheck, 500, false)}},
+			wantPaid:    10500,
+			wantBalance: -500,
+			wantStatus:  paymentStatusOverpaid,
+		},
+		{
+			name:        "refund reopens the balance",
+			tow:         &model.Tow{Price: cents(10000), RefundedAmount: cents(3000), Payments: []model.Payment{payment(model.PaymentMethodStripe, 10000, false)}},
+			wantPaid:    10000,
+			wantBalance: 3000,
+			wantStatus:  paymentStatusPartiallyRefunded,
+		},
+		{
+			name:       "paid before the ledger existed",
+			tow:        &model.Tow{Price: cents(10000), PaymentStatus: status(paymentStatusPaid)},
+			wantPaid:   10000,
+			wantStatus: paymentStatusPaid,
+		},
+		{
+			name:        "returning an overpayment leaves the tow paid",
+			tow:         &model.Tow{Price: cents(10000), RefundedAmount: cents(2000), Payments: []model.Payment{payment(model.PaymentMethodStripe, 10000, false), payment(model.PaymentMethodCash, 2000, false)}},
+			wantPaid:    12000,
+			wantBalance: 0,
+			wantStatus:  paymentStatusPaid,
+		},
+		{
+			name:        "full refund",
+			tow:         &model.Tow{Price: cents(10000), RefundedAmount: cents(10000), Payments: []model.Payment{payment(model.PaymentMethodStripe, 10000, false)}},
+			wantPaid:    10000,
+			wantBalance: 10000,
+			wantStatus:  paymentStatusRefunded,
+		},
+		{
+			name:       "dispute keeps its status",
+			tow:        &model.Tow{Price: cents(10000), PaymentStatus: status(paymentStatusDisputed), Payments: []model.Payment{payment(model.PaymentMethodStripe, 10000, false)}},
+			wantPaid:   10000,
+			wantStatus: paymentStatusDisputed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := settledPayment(tt.tow)
+			if *got.PaidAmount != tt.wantPaid {
+				t.Errorf("PaidAmount = %d, want %d", *got.PaidAmount, tt.wantPaid)
+			}
+			if *got.BalanceDue != tt.wantBalance {
+				t.Errorf("BalanceDue = %d, want %d", *got.BalanceDue, tt.wantBalance)
+			}
+			if *got.PaymentStatus != tt.wantStatus {
+				t.Errorf("PaymentStatus = %s, want %s", *got.PaymentStatus, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRecordPaymentBackfillsStripePaymentFromBeforeTheLedger(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	ctx := context.Background()
+
+	tows := &memoryTows{}
+	if err := tows.Create(ctx, &model.Tow{ID: str("tow-1"), CompanyID: str("company-1"), Price: cents(10000), PaymentStatus: str(paymentStatusPaid), PaymentReference: str("cs_old")}); err != nil {
+		t.Fatal(err)
+	}
+	users := &memoryStore[model.User]{}
+	if err := users.Create(ctx, &model.User{ID: str("user-1"), CompanyID: str("company-1")}); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewPaymentService(tows, nil, nil, users, nil, nil)
+
+	tow, err := svc.RecordPayment(ctx, "tow-1", &model.Payment{Method: str(model.PaymentMethodCash), Amount: cents(2000)}, "user-1")
+	if err != nil {
+		t.Fatalf("RecordPayment() error = %v", err)
+	}
+	if intValue(tow.PaidAmount) != 12000 || stringValue(tow.PaymentStatus) != paymentStatusOverpaid {
+		t.Errorf("tow = paid %d, status %q; want 12000, overpaid", intValue(tow.PaidAmount), stringValue(tow.PaymentStatus))
+	}
+
+	saved := tows.get("tow-1")
+	if len(saved.Payments) != 2 {
+		t.Fatalf("%d ledger payments, want the backfilled Stripe payment and the cash", len(saved.Payments))
+	}
+	if p := saved.Payments[0]; stringValue(p.Method) != model.PaymentMethodStripe || intValue(p.Amount) != 10000 || stringValue(p.Reference) != "cs_old" {
+		t.Errorf("first ledger payment = %s %d ref %s, want stripe 10000 ref cs_old", stringValue(p.Method), intValue(p.Amount), stringValue(p.Reference))
+	}
+}
+
+func TestLedgerPaymentsAreForCompanyUsers(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	ctx := context.Background()
+
+	tows := &memoryTows{}
+	cash := model.Payment{ID: str("payment-1"), Method: str(model.PaymentMethodCash), Amount: cents(2000)}
+	if err := tows.Create(ctx, &model.Tow{ID: str("tow-1"), CompanyID: str("company-1"), Price: cents(10000), Payments: []model.Payment{cash}}); err != nil {
+		t.Fatal(err)
+	}
+	users := &memoryStore[model.User]{}
+	for id, companyId := range map[string]string{"user-1": "company-1", "user-2": "company-2"} {
+		if err := users.Create(ctx, &model.User{ID: str(id), CompanyID: str(companyId)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := NewPaymentService(tows, nil, nil, users, nil, nil)
+
+	for userId, wantErr := range map[string]string{"": "user id is required", "user-2": "not allowed", "user-3": "not allowed"} {
+		if _, err := svc.RecordPayment(ctx, "tow-1", &model.Payment{Method: str(model.PaymentMethodCash), Amount: cents(1000)}, userId); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("RecordPayment(%q) error = %v, want %q", userId, err, wantErr)
+		}
+		if _, err := svc.VoidPayment(ctx, "tow-1", "payment-1", userId); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("VoidPayment(%q) error = %v, want %q", userId, err, wantErr)
+		}
+	}
+	if saved := tows.get("tow-1"); len(saved.Payments) != 1 || saved.Payments[0].VoidedAt != nil {
+		t.Errorf("payments = %+v, want the cash payment untouched", saved.Payments)
+	}
+
+	tow, err := svc.VoidPayment(ctx, "tow-1", "payment-1", "user-1")
+	if err != nil {
+		t.Fatalf("VoidPayment() error = %v", err)
+	}
+	if intValue(tow.PaidAmount) != 0 || stringValue(tow.Payments[0].VoidedBy) != "user-1" {
+		t.Errorf("tow = paid %d, voided by %q; want 0 voided by user-1", intValue(tow.PaidAmount), stringValue(tow.Payments[0].VoidedBy))
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"tow-management-system-api/model"
+	"tow-management-system-api/utilities"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+// paymentEmailSubject is the subject of the email that sends the customer their checkout link.
+const paymentEmailSubject = "Service Confirmation – Complete Your Payment"
+
+// RegeneratePaymentLink replaces a tow's checkout session with a fresh one for the tow's balance due, e.g.
+// after the emailed link expired. The old session is expired first so the customer cannot pay twice.
+func (s *TowService) RegeneratePaymentLink(ctx context.Context, towId string) (*model.Tow, error) {
+	tow, err := s.findUnpaidTow(ctx, towId)
+	if err != nil {
+		return nil, err
+	}
+	return s.regeneratePaymentLink(ctx, tow)
+}
+
+// ResendPaymentLink re-sends the payment email for an unpaid or partly paid tow. A link that can no longer be
+// paid, or is not for the balance due, is regenerated first, so the customer always receives a working link.
+func (s *TowService) ResendPaymentLink(ctx context.Context, towId string) (*model.Tow, error) {
+	tow, err := s.findUnpaidTow(ctx, towId)
+	if err != nil {
+		return nil, err
+	}
+
+	// A link for more than the balance, e.g. sent before a motor club paid its part, is replaced too
+	current := false
+	switch stringValue(tow.PaymentStatus) {
+	case paymentStatusUnpaid, paymentStatusPartial:
+		if stringValue(tow.PaymentReference) != "" {
+			session, err := s.paymentGateway.GetCheckoutSession(ctx, *tow.PaymentReference)
+			if err != nil {
+				return nil, err
+			}
+			current = session.Status == stripe.CheckoutSessionStatusOpen && session.AmountTotal == int64(balanceDue(tow))
+		}
+	}
+	if !current {
+		if tow, err = s.regeneratePaymentLink(ctx, tow); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.sendPaymentEmail(ctx, tow); err != nil {
+		return nil, err
+	}
+	return tow, nil
+}
+
+// findUnpaidTow returns a tow whose checkout link may be replaced or re-sent.
+func (s *TowService) findUnpaidTow(ctx context.Context, towId string) (*model.Tow, error) {
+	if towId == "" {
+		return nil, fmt.Errorf("tow id is required")
+	}
+
+	tows, err := s.towRepository.Find(ctx, &model.Tow{ID: &towId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tow: %w", err)
+	}
+	if len(tows) == 0 {
+		return nil, fmt.Errorf("tow not found")
+	}
+	tow := tows[0]
+
+	switch stringValue(tow.PaymentStatus) {
+	case "", paymentStatusUnpaid, paymentStatusExpired, paymentStatusFailed, paymentStatusPartial:
+	default:
+		return nil, fmt.Errorf("tow payment is %s and no longer needs a payment link", stringValue(tow.PaymentStatus))
+	}
+	if balanceDue(tow) <= 0 || len(tow.LineItems) == 0 {
+		return nil, fmt.Errorf("tow has no balance to collect")
+	}
+
+	return tow, nil
+}
+
+// regeneratePaymentLink expires the tow's current checkout session if it is still open, creates a new one and
+// saves it on the tow. The platform fee is recalculated in case the company's plan changed.
+func (s *TowService) regeneratePaymentLink(ctx context.Context, tow *model.Tow) (*model.Tow, error) {
+	companies, err := s.companyRepository.Find(ctx, &model.Company{ID: tow.CompanyID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch company: %w", err)
+	}
+	if len(companies) == 0 {
+		return nil, fmt.Errorf("company not found")
+	}
+	company := companies[0]
+	if err := checkCanAcceptPayments(company); err != nil {
+		return nil, err
+	}
+
+	if err := s.expireCheckoutSession(ctx, tow); err != nil {
+		return nil, err
+	}
+
+	// Collect only what is still owed, e.g. the customer's share after a motor club paid its part, or the new
+	// price when the tow was repriced after booking and its line items no longer add up to it
+	total := int64(balanceDue(tow))
+	lineItems := tow.LineItems
+	if total != utilities.LineItemsTotal(lineItems) {
+		lineItems = []model.PayableLineItem{{Name: "Balance due", Amount: total, Quantity: 1}}
+	}
+	fee, err := applicationFee(company, total)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken := stringValue(tow.AccessToken)
+	if accessToken == "" {
+		if accessToken, err = newAccessToken(); err != nil {
+			return nil, err
+		}
+	}
+	successURL, cancelURL := checkoutReturnURLs(company, *tow.ID, accessToken)
+
+	checkoutSessionId, checkoutURL, err := s.paymentGateway.CreatePayableItem(ctx, *tow.ID, total, lineItems, *company.StripeAccountId, fee, successURL, cancelURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payable item: %w", err)
+	}
+
+	applicationFeeAmount := int(fee)
+	paymentStatus := paymentStatusUnpaid
+	if stringValue(tow.PaymentStatus) == paymentStatusPartial {
+		paymentStatus = paymentStatusPartial
+	}
+	update := &model.Tow{
+		PaymentStatus:    &paymentStatus,
+		PaymentReference: &checkoutSessionId,
+		CheckoutUrl:      &checkoutURL,
+		AccessToken:      &accessToken,
+		ApplicationFee:   &applicationFeeAmount,
+	}
+	if err := s.towRepository.Update(ctx, *tow.ID, update); err != nil {
+		return nil, fmt.Errorf("failed to save payment link: %w", err)
+	}
+
+	tow.PaymentStatus = update.PaymentStatus
+	tow.PaymentReference = update.PaymentReference
+	tow.CheckoutUrl = update.CheckoutUrl
+	tow.AccessToken = update.AccessToken
+	tow.ApplicationFee = update.ApplicationFee
+	return tow, nil
+}
+
+// expireCheckoutSession makes sure the tow's current checkout session can no longer be paid before it is
+// replaced. A completed session whose payment is not in the ledger yet means the customer paid (or a delayed
+// payment is settling), so it must not be replaced.
+func (s *TowService) expireCheckoutSession(ctx context.Context, tow *model.Tow) error {
+	sessionId := stringValue(tow.PaymentReference)
+	if sessionId == "" {
+		return nil
+	}
+
+	session, err := s.paymentGateway.GetCheckoutSession(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+
+	switch session.Status {
+	case stripe.CheckoutSessionStatusComplete:
+		for _, p := range tow.Payments {
+			if stringValue(p.Reference) == sessionId {
+				return nil
+			}
+		}
+		return fmt.Errorf("tow checkout was already completed and is awaiting payment confirmation")
+	case stripe.CheckoutSessionStatusOpen:
+		if _, err := s.paymentGateway.ExpireCheckoutSession(ctx, sessionId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendPaymentEmail emails the tow's checkout link to its primary contact.
+func (s *TowService) sendPaymentEmail(ctx context.Context, tow *model.Tow) error {
+	emailContent, err := s.formatPaymentEmail(ctx, tow)
+	if err != nil {
+		return fmt.Errorf("failed to format email: %w", err)
+	}
+
+	return s.emailUtility.SendEmail(ctx, *tow.PrimaryContact.Email, paymentEmailSubject, emailContent)
+}
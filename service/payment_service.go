@@ -2,12 +2,9 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"strings"
 
 	"tow-management-system-api/model"
-	"tow-management-system-api/utilities"
 
 	"github.com/stripe/stripe-go/v83"
 )
@@ -15,22 +12,40 @@ import (
 // TowDataRepository defines the interface for tow data operations.
 type TowDataRepository interface {
 	Find(ctx context.Context, filterModel *model.Tow) ([]*model.Tow, error)
+	FindByPaymentIntent(ctx context.Context, paymentIntentId string) ([]*model.Tow, error)
 	Update(ctx context.Context, id string, updateData *model.Tow) error
+	SetPaymentIntent(ctx context.Context, id string, paymentId string, paymentIntentId string) error
+	AddPayment(ctx context.Context, id string, payment *model.Payment) (bool, error)
+	VoidPayment(ctx context.Context, id string, paymentId string, voidedBy string, voidedAt int64) (bool, error)
+}
+
+// WebhookEventRepository persists received Stripe webhook events and guards them against concurrent processing.
+type WebhookEventRepository interface {
+	Create(ctx context.Context, item *model.WebhookEvent) (bool, error)
+	Find(ctx context.Context, filterModel *model.WebhookEvent) ([]*model.WebhookEvent, error)
+	Update(ctx context.Context, id string, updateData *model.WebhookEvent) error
+	Claim(ctx context.Context, id string, now int64, leaseUntil int64) (bool, error)
 }
 
 // PaymentService encapsulates payment-related business logic.
 type PaymentService struct {
-	towDataRepository TowDataRepository
-	companyRepository CompanyRepository
-	stripeClient      *utilities.StripeUtility
+	towDataRepository      TowDataRepository
+	companyRepository      CompanyRepository
+	webhookEventRepository WebhookEventRepository
+	userRepository         UserFinder
+	paymentGateway         PaymentGateway
+	emailUtility           EmailSender
 }
 
 // NewPaymentService constructs a PaymentService with the provided dependencies.
-func NewPaymentService(towDataRepo TowDataRepository, companyRepo CompanyRepository, stripeClient *utilities.StripeUtility) *PaymentService {
+func NewPaymentService(towDataRepo TowDataRepository, companyRepo CompanyRepository, webhookEventRepo WebhookEventRepository, userRepo UserFinder, paymentGateway PaymentGateway, emailUtility EmailSender) *PaymentService {
 	return &PaymentService{
-		towDataRepository: towDataRepo,
-		companyRepository: companyRepo,
-		stripeClient:      stripeClient,
+		towDataRepository:      towDataRepo,
+		companyRepository:      companyRepo,
+		webhookEventRepository: webhookEventRepo,
+		userRepository:         userRepo,
+		paymentGateway:         paymentGateway,
+		emailUtility:           emailUtility,
 	}
 }
 
@@ -59,7 +74,7 @@ func (s *PaymentService) RetrievePaymentAccount(ctx context.Context, companyId s
 	}
 
 	// Get the Stripe account
-	account, err := s.stripeClient.GetAccount(*company.StripeAccountId)
+	account, err := s.paymentGateway.GetAccount(ctx, *company.StripeAccountId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve stripe account: %w", err)
 	}
@@ -93,14 +108,14 @@ func (s *PaymentService) GenerateDashboardLink(ctx context.Context, companyId, r
 	}
 
 	// Get the Stripe account
-	account, err := s.stripeClient.GetAccount(*company.StripeAccountId)
+	account, err := s.paymentGateway.GetAccount(ctx, *company.StripeAccountId)
 	if err != nil {
 		return "", fmt.Errorf("failed to retrieve stripe account: %w", err)
 	}
 
 	// If details are submitted, return a login link
 	if account.DetailsSubmitted {
-		loginLink, err := s.stripeClient.CreateLoginLink(account.ID)
+		loginLink, err := s.paymentGateway.CreateLoginLink(ctx, account.ID)
 		if err != nil {
 			return "", fmt.Errorf("failed to create login link: %w", err)
 		}
@@ -112,58 +127,10 @@ func (s *PaymentService) GenerateDashboardLink(ctx context.Context, companyId, r
 		return "", fmt.Errorf("returnURL and refreshURL are required for account onboarding")
 	}
 
-	accountLink, err := s.stripeClient.CreateAccountLink(account.ID, returnURL, refreshURL)
+	accountLink, err := s.paymentGateway.CreateAccountLink(ctx, account.ID, returnURL, refreshURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to create account link: %w", err)
 	}
 
 	return accountLink, nil
 }
-
-// ProcessCheckoutSuccessEvent processes a Stripe checkout session success event.
-// It finds the tow associated with the checkout session and updates its payment status to "paid".
-func (s *PaymentService) ProcessCheckoutSuccessEvent(ctx context.Context, event *stripe.Event) error {
-	if event == nil {
-		return fmt.Errorf("event is required")
-	}
-
-	// Check if the event type begins with "checkout.session"
-	eventType := string(event.Type)
-	if !strings.HasPrefix(eventType, "checkout.session.async_payment_succeeded") {
-		return fmt.Errorf("event type %s does not begin with 'checkout.session'", eventType)
-	}
-
-	// Extract the checkout session ID from event.data.object.id
-	// The event.Data.Raw contains the full event data with nested object
-	var checkoutSession stripe.CheckoutSession
-
-	if err := json.Unmarshal(event.Data.Raw, &checkoutSession); err != nil {
-		return fmt.Errorf("failed to unmarshal checkout session from event data: %w", err)
-	}
-
-	if checkoutSession.ID == "" {
-		return fmt.Errorf("checkout session ID is empty")
-	}
-
-	// Search for the tow with PaymentReference == checkout session ID
-	tow, err := s.towDataRepository.Find(ctx, &model.Tow{PaymentReference: &checkoutSession.ID})
-	if err != nil {
-		return fmt.Errorf("failed to find tow with payment reference %s: %w", checkoutSession.ID, err)
-	}
-
-	if len(tow) == 0 {
-		return fmt.Errorf("tow not found with payment reference %s", checkoutSession.ID)
-	}
-
-	if tow[0].ID == nil || *tow[0].ID == "" {
-		return fmt.Errorf("tow ID is empty")
-	}
-
-	// Update the tow to set PaymentStatus == "paid"
-	paidStatus := "paid"
-	if err := s.towDataRepository.Update(ctx, *tow[0].ID, &model.Tow{PaymentStatus: &paidStatus}); err != nil {
-		return fmt.Errorf("failed to update tow payment status: %w", err)
-	}
-
-	return nil
-}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"tow-management-system-api/model"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+// GetPaymentSummary adds up the company's payments, platform fees and refunds recorded within [from, to) and
+// reports its Stripe balance and the payouts created in the period. A zero from or to leaves that end of the
+// period open. The ledger figures are still returned when Stripe cannot be reached.
+func (s *PaymentService) GetPaymentSummary(ctx context.Context, companyId string, from, to int64) (*model.PaymentSummary, error) {
+	company, err := s.findPeriodCompany(ctx, companyId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	tows, err := s.towDataRepository.Find(ctx, &model.Tow{CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tows: %w", err)
+	}
+
+	summary := summarizePayments(tows, from, to)
+	summary.CompanyID = companyId
+	summary.From = from
+	summary.To = to
+
+	accountId := stringValue(company.StripeAccountId)
+	if accountId == "" {
+		return summary, nil
+	}
+
+	available, pending, err := s.paymentGateway.GetAccountBalance(ctx, accountId)
+	if err != nil {
+		log.Println(err.Error())
+		summary.BalanceUnavailable = true
+		return summary, nil
+	}
+	payouts, err := s.paymentGateway.ListPayouts(ctx, accountId, from, to)
+	if err != nil {
+		log.Println(err.Error())
+		summary.BalanceUnavailable = true
+		return summary, nil
+	}
+
+	summary.AvailableBalance = available
+	summary.PendingBalance = pending
+	for _, p := range payouts {
+		switch p.Status {
+		case stripe.PayoutStatusPaid:
+			summary.PaidOut += p.Amount
+		case stripe.PayoutStatusPending, stripe.PayoutStatusInTransit:
+			summary.InTransit += p.Amount
+		}
+	}
+	return summary, nil
+}
+
+// FindPayouts returns the payouts created within [from, to) from the company's Stripe account to its bank,
+// newest first, each with the tows whose payments and refunds it settled. A zero from or to leaves that end
+// of the period open.
+func (s *PaymentService) FindPayouts(ctx context.Context, companyId string, from, to int64) ([]*model.Payout, error) {
+	company, err := s.findPeriodCompany(ctx, companyId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	accountId := stringValue(company.StripeAccountId)
+	if accountId == "" {
+		return nil, fmt.Errorf("company does not have a stripe account id")
+	}
+
+	payouts, err := s.paymentGateway.ListPayouts(ctx, accountId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Payout, 0, len(payouts))
+	for _, p := range payouts {
+		transactions, err := s.paymentGateway.ListPayoutTransactions(ctx, accountId, p.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		payout := &model.Payout{
+			ID:          p.ID,
+			Amount:      p.Amount,
+			Currency:    string(p.Currency),
+			Status:      string(p.Status),
+			ArrivalDate: p.ArrivalDate,
+			CreatedAt:   p.Created,
+		}
+		payout.Tows, payout.OtherAmount = payoutTows(transactions)
+		result = append(result, payout)
+	}
+
+	return result, nil
+}
+
+// findPeriodCompany validates a reporting period and returns the company it is for.
+func (s *PaymentService) findPeriodCompany(ctx context.Context, companyId string, from, to int64) (*model.Company, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+	if from < 0 || to < 0 || (to != 0 && to <= from) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+
+	companies, err := s.companyRepository.Find(ctx, &model.Company{ID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find company: %w", err)
+	}
+	if len(companies) == 0 {
+		return nil, fmt.Errorf("company not found")
+	}
+	return companies[0], nil
+}
+
+// summarizePayments adds up the ledger payments and refunds of the tows that fall within [from, to). A tow's
+// platform fee is taken from its Stripe payments in proportion to their amount, and returned with refunds the
+// same way, as Stripe does when a refund reverses the transfer. Tows paid before the ledger existed count
+// their price as one Stripe payment made when the tow was created.
+func summarizePayments(tows []*model.Tow, from, to int64) *model.PaymentSummary {
+	inPeriod := func(ts *int64) bool {
+		if ts == nil {
+			return false
+		}
+		return *ts >= from && (to == 0 || *ts < to)
+	}
+
+	summary := &model.PaymentSummary{}
+	for _, tow := range tows {
+		stripePaid := paidAmount(tow, model.PaymentMethodStripe)
+		fee := intValue(tow.ApplicationFee)
+		proportionalFee := func(amount int) int {
+			if stripePaid <= 0 {
+				return 0
+			}
+			return amount * min(fee, stripePaid) / stripePaid
+		}
+
+		counted := false
+		if len(tow.Payments) == 0 && stripePaid > 0 && inPeriod(tow.CreatedAt) {
+			summary.StripeCollected += stripePaid
+			summary.PlatformFees += proportionalFee(stripePaid)
+			counted = true
+		}
+		for _, p := range tow.Payments {
+			if p.VoidedAt != nil || !inPeriod(p.CollectedAt) {
+				continue
+			}
+			amount := intValue(p.Amount)
+			if stringValue(p.Method) == model.PaymentMethodStripe {
+				summary.StripeCollected += amount
+				summary.PlatformFees += proportionalFee(amount)
+			} else {
+				summary.OfflineCollected += amount
+			}
+			counted = true
+		}
+		for _, r := range tow.Refunds {
+			switch stripe.RefundStatus(stringValue(r.Status)) {
+			case stripe.RefundStatusSucceeded, stripe.RefundStatusPending:
+			default:
+				continue
+			}
+			if !inPeriod(r.CreatedAt) {
+				continue
+			}
+			amount := intValue(r.Amount)
+			summary.Refunds += amount
+			summary.PlatformFeesRefunded += proportionalFee(amount)
+			counted = true
+		}
+
+		if counted {
+			summary.TowCount++
+		}
+	}
+
+	summary.GrossCollected = summary.StripeCollected + summary.OfflineCollected
+	fees := summary.PlatformFees - summary.PlatformFeesRefunded
+	summary.Net = summary.GrossCollected - fees - summary.Refunds
+	summary.StripeNet = summary.StripeCollected - fees - summary.Refunds
+	return summary
+}
+
+// payoutTows groups the net amounts a payout settled by tow. Tow payments are traced through the transfer
+// behind them, whose transfer group is the tow ID; anything else, such as refunds, Stripe adjustments or
+// payments made before checkouts carried a transfer group, is returned as one other amount.
+func payoutTows(transactions []*stripe.BalanceTransaction) ([]model.PayoutTow, int64) {
+	var tows []model.PayoutTow
+	index := map[string]int{}
+	var other int64
+	for _, t := range transactions {
+		if t.Type == stripe.BalanceTransactionTypePayout {
+			continue
+		}
+
+		towId := ""
+		if t.Source != nil && t.Source.Charge != nil {
+			towId = t.Source.Charge.TransferGroup
+			if towId == "" && t.Source.Charge.SourceTransfer != nil {
+				towId = t.Source.Charge.SourceTransfer.TransferGroup
+			}
+		}
+		if towId == "" {
+			other += t.Net
+			continue
+		}
+
+		if i, ok := index[towId]; ok {
+			tows[i].Amount += t.Net
+			continue
+		}
+		index[towId] = len(tows)
+		tows = append(tows, model.PayoutTow{TowID: towId, Amount: t.Net})
+	}
+	if tows == nil {
+		tows = []model.PayoutTow{}
+	}
+	return tows, other
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"testing"
+	"tow-management-system-api/model"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+func TestSummarizePayments(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	at := func(ts int64) *int64 { return &ts }
+	payment := func(method string, amount int, collectedAt int64) model.Payment {
+		return model.Payment{Method: &method, Amount: &amount, CollectedAt: &collectedAt}
+	}
+
+	voided := payment(model.PaymentMethodCash, 5000, 150)
+	voided.VoidedAt = at(160)
+	tows := []*model.Tow{
+		// Paid online with a 10% fee, then half refunded
+		{
+			Price:          cents(10000),
+			ApplicationFee: cents(1000),
+			Payments:       []model.Payment{payment(model.PaymentMethodStripe, 10000, 150)},
+			Refunds:        []model.Refund{{Amount: cents(5000), Status: str(string(stripe.RefundStatusSucceeded)), CreatedAt: at(170)}},
+			RefundedAmount: cents(5000),
+		},
+		// Motor club and cash; the voided cash does not count
+		{
+			Price:    cents(8000),
+			Payments: []model.Payment{payment(model.PaymentMethodMotorClub, 6000, 120), payment(model.PaymentMethodCash, 2000, 130), voided},
+		},
+		// Paid before the ledger existed
+		{Price: cents(4000), ApplicationFee: cents(400), PaymentStatus: str(paymentStatusPaid), CreatedAt: at(110)},
+		// Outside the period
+		{Price: cents(3000), Payments: []model.Payment{payment(model.PaymentMethodCash, 3000, 250)}},
+		// A failed refund is ignored
+		{
+			Price:    cents(2000),
+			Payments: []model.Payment{payment(model.PaymentMethodStripe, 2000, 50)},
+			Refunds:  []model.Refund{{Amount: cents(2000), Status: str(string(stripe.RefundStatusFailed)), CreatedAt: at(150)}},
+		},
+	}
+
+	got := summarizePayments(tows, 100, 200)
+	want := model.PaymentSummary{
+		TowCount:             3,
+		GrossCollected:       22000,
+		StripeCollected:      14000,
+		OfflineCollected:     8000,
+		PlatformFees:         1400,
+		Refunds:              5000,
+		PlatformFeesRefunded: 500,
+		Net:                  16100,
+		StripeNet:            8100,
+	}
+	if *got != want {
+		t.Errorf("summarizePayments() = %+v, want %+v", *got, want)
+	}
+
+	if all := summarizePayments(tows, 0, 0); all.TowCount != 5 || all.GrossCollected != 27000 {
+		t.Errorf("unbounded summary counted %d tows and %d cents, want 5 and 27000", all.TowCount, all.GrossCollected)
+	}
+}
+
+func TestPayoutTows(t *testing.T) {
+	charge := func(transferGroup string) *stripe.BalanceTransactionSource {
+		return &stripe.BalanceTransactionSource{Charge: &stripe.Charge{SourceTransfer: &stripe.Transfer{TransferGroup: transferGroup}}}
+	}
+
+	transactions := []*stripe.BalanceTransaction{
+		{Type: stripe.BalanceTransactionTypePayment, Net: 9000, Source: charge("tow-1")},
+		{Type: stripe.BalanceTransactionTypePayment, Net: 3600, Source: charge("tow-2")},
+		{Type: stripe.BalanceTransactionTypePayment, Net: 1800, Source: charge("tow-1")},
+		{Type: stripe.BalanceTransactionTypePayment, Net: 5000, Source: charge("")},
+		{Type: stripe.BalanceTransactionTypePaymentRefund, Net: -1000},
+		{Type: stripe.BalanceTransactionTypePayout, Net: -18400},
+	}
+
+	tows, other := payoutTows(transactions)
+	want := []model.PayoutTow{{TowID: "tow-1", Amount: 10800}, {TowID: "tow-2", Amount: 3600}}
+	if len(tows) != len(want) {
+		t.Fatalf("payoutTows() returned %v, want %v", tows, want)
+	}
+	for i := range want {
+		if tows[i] != want[i] {
+			t.Errorf("payoutTows()[%d] = %+v, want %+v", i, tows[i], want[i])
+		}
+	}
+	if other != 4000 {
+		t.Errorf("payoutTows() other amount = %d, want 4000", other)
+	}
+}
@@ -0,0 +1,457 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"tow-management-system-api/model"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+// Tow payment statuses. Tows start unpaid; the rest are set from Stripe events.
+const (
+	paymentStatusUnpaid            = "unpaid"
+	paymentStatusPaid              = "paid"
+	paymentStatusFailed            = "failed"
+	paymentStatusExpired           = "expired"
+	paymentStatusRefunded          = "refunded"
+	paymentStatusPartiallyRefunded = "partially_refunded"
+	paymentStatusDisputed          = "disputed"
+	paymentStatusDisputeLost       = "dispute_lost"
+)
+
+// webhookEventLease is how long an invocation may hold an event before another may claim it again. It is
+// longer than the Lambda timeout so an event is only reclaimed after its invocation is gone.
+const webhookEventLease = 2 * time.Minute
+
+// webhookEventHandler processes one type of Stripe event. Returning an error makes Stripe retry the delivery.
+type webhookEventHandler func(ctx context.Context, event *stripe.Event) error
+
+// webhookHandlers routes Stripe event types to their handlers. Types that are not listed are acknowledged and ignored.
+func (s *PaymentService) webhookHandlers() map[stripe.EventType]webhookEventHandler {
+	return map[stripe.EventType]webhookEventHandler{
+		stripe.EventTypeCheckoutSessionCompleted:             s.handleCheckoutCompleted,
+		stripe.EventTypeCheckoutSessionAsyncPaymentSucceeded: s.handleCheckoutPaid,
+		stripe.EventTypeCheckoutSessionAsyncPaymentFailed:    s.handleCheckoutFailed,
+		stripe.EventTypeCheckoutSessionExpired:               s.handleCheckoutExpired,
+		stripe.EventTypeChargeRefunded:                       s.handleChargeRefunded,
+		stripe.EventTypeChargeDisputeCreated:                 s.handleDispute,
+		stripe.EventTypeChargeDisputeUpdated:                 s.handleDispute,
+		stripe.EventTypeChargeDisputeClosed:                  s.handleDispute,
+		stripe.EventTypeChargeDisputeFundsWithdrawn:          s.handleDispute,
+		stripe.EventTypeChargeDisputeFundsReinstated:         s.handleDispute,
+		stripe.EventTypeAccountUpdated:                       s.handleAccountUpdated,
+	}
+}
+
+// HandleWebhookEvent verifies a Stripe webhook delivery with its Stripe-Signature header, records it and routes
+// the event to the handler for its type. Redeliveries of an event that was already processed are acknowledged
+// without running the handler again. Unknown event types are acknowledged without being processed.
+func (s *PaymentService) HandleWebhookEvent(ctx context.Context, payload []byte, signature string) error {
+	event, err := s.paymentGateway.ConstructWebhookEvent(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Unix()
+	eventType := string(event.Type)
+	body := string(payload)
+	status := model.WebhookEventStatusPending
+	attempts := 0
+	record := &model.WebhookEvent{
+		ID:             &event.ID,
+		Type:           &eventType,
+		Payload:        &body,
+		EventCreatedAt: &event.Created,
+		ReceivedAt:     &now,
+		Status:         &status,
+		Attempts:       &attempts,
+	}
+	if _, err := s.webhookEventRepository.Create(ctx, record); err != nil {
+		return fmt.Errorf("failed to record webhook event %s: %w", event.ID, err)
+	}
+
+	return s.runWebhookEvent(ctx, &event)
+}
+
+// FindWebhookEvents lists recorded webhook events for a platform admin, newest first, optionally only those with
+// the given status. Payloads carry customer and payment details, so they are left out of the list.
+func (s *PaymentService) FindWebhookEvents(ctx context.Context, userId string, status string) ([]*model.WebhookEvent, error) {
+	if err := authorizePlatformAdmin(userId); err != nil {
+		return nil, err
+	}
+
+	filter := &model.WebhookEvent{}
+	if status != "" {
+		filter.Status = &status
+	}
+
+	events, err := s.webhookEventRepository.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook events: %w", err)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return int64Value(events[i].ReceivedAt) > int64Value(events[j].ReceivedAt)
+	})
+	for _, event := range events {
+		event.Payload = nil
+	}
+
+	return events, nil
+}
+
+// ReplayWebhookEvent runs a recorded event that failed (or was never finished) through its handler again, for a
+// platform admin.
+func (s *PaymentService) ReplayWebhookEvent(ctx context.Context, userId string, eventId string) (*model.WebhookEvent, error) {
+	if err := authorizePlatformAdmin(userId); err != nil {
+		return nil, err
+	}
+	if eventId == "" {
+		return nil, fmt.Errorf("event id is required")
+	}
+
+	record, err := s.findWebhookEvent(ctx, eventId)
+	if err != nil {
+		return nil, err
+	}
+	if stringValue(record.Status) == model.WebhookEventStatusProcessed {
+		return nil, fmt.Errorf("webhook event %s has already been processed", eventId)
+	}
+
+	// The payload was verified when it was received
+	var event stripe.Event
+	if err := json.Unmarshal([]byte(stringValue(record.Payload)), &event); err != nil {
+		return nil, fmt.Errorf("failed to parse stored webhook event %s: %w", eventId, err)
+	}
+
+	runErr := s.runWebhookEvent(ctx, &event)
+
+	record, err = s.findWebhookEvent(ctx, eventId)
+	if err != nil {
+		return nil, err
+	}
+	if runErr != nil && stringValue(record.Status) != model.WebhookEventStatusFailed {
+		return nil, runErr
+	}
+
+	return record, nil
+}
+
+// runWebhookEvent claims a recorded event, runs its handler and records the outcome. An event another
+// invocation is processing returns an error so Stripe redelivers it later, when it will be found processed.
+func (s *PaymentService) runWebhookEvent(ctx context.Context, event *stripe.Event) error {
+	now := time.Now().UTC()
+	claimed, err := s.webhookEventRepository.Claim(ctx, event.ID, now.Unix(), now.Add(webhookEventLease).Unix())
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		record, err := s.findWebhookEvent(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+		if stringValue(record.Status) == model.WebhookEventStatusProcessed {
+			log.Printf("skipping stripe event %s, already processed", event.ID)
+			return nil
+		}
+		return fmt.Errorf("webhook event %s is already being processed", event.ID)
+	}
+
+	handleErr := s.dispatchWebhookEvent(ctx, event)
+
+	finishedAt := time.Now().UTC().Unix()
+	update := &model.WebhookEvent{LockedUntil: &finishedAt}
+	if handleErr != nil {
+		status := model.WebhookEventStatusFailed
+		message := handleErr.Error()
+		update.Status = &status
+		update.LastError = &message
+	} else {
+		status := model.WebhookEventStatusProcessed
+		update.Status = &status
+		update.ProcessedAt = &finishedAt
+	}
+	if err := s.webhookEventRepository.Update(ctx, event.ID, update); err != nil {
+		log.Printf("failed to record outcome of stripe event %s: %s", event.ID, err.Error())
+	}
+
+	return handleErr
+}
+
+// findWebhookEvent returns a recorded webhook event by its Stripe event ID.
+func (s *PaymentService) findWebhookEvent(ctx context.Context, eventId string) (*model.WebhookEvent, error) {
+	events, err := s.webhookEventRepository.Find(ctx, &model.WebhookEvent{ID: &eventId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook event %s: %w", eventId, err)
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("webhook event not found")
+	}
+	return events[0], nil
+}
+
+// dispatchWebhookEvent runs the handler registered for the event's type.
+func (s *PaymentService) dispatchWebhookEvent(ctx context.Context, event *stripe.Event) error {
+	handle, ok := s.webhookHandlers()[event.Type]
+	if !ok {
+		log.Printf("ignoring stripe event %s of type %s", event.ID, event.Type)
+		return nil
+	}
+
+	if err := handle(ctx, event); err != nil {
+		return fmt.Errorf("failed to process %s event %s: %w", event.Type, event.ID, err)
+	}
+	return nil
+}
+
+// handleCheckoutCompleted records the payment in the tow's ledger once the customer finishes checkout with an
+// immediate payment method. Delayed methods (e.g. bank debits) complete unpaid and are recorded by
+// async_payment_succeeded.
+func (s *PaymentService) handleCheckoutCompleted(ctx context.Context, event *stripe.Event) error {
+	session, tow, err := s.findCheckoutTow(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	// Money that was collected is recorded however late the event arrives
+	if session.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid || session.PaymentStatus == stripe.CheckoutSessionPaymentStatusNoPaymentRequired {
+		if _, err := s.recordStripePayment(ctx, tow, session); err != nil {
+			return err
+		}
+		return s.markCheckoutEvent(ctx, tow, event)
+	}
+
+	if session.PaymentIntent == nil || session.PaymentIntent.ID == "" || staleCheckoutEvent(tow, event) {
+		return nil
+	}
+	update := &model.Tow{PaymentIntentID: &session.PaymentIntent.ID, CheckoutEventAt: &event.Created}
+	if err := s.towDataRepository.Update(ctx, *tow.ID, update); err != nil {
+		return fmt.Errorf("failed to update tow payment intent: %w", err)
+	}
+	return nil
+}
+
+// handleCheckoutPaid records the payment when a delayed payment method succeeds.
+func (s *PaymentService) handleCheckoutPaid(ctx context.Context, event *stripe.Event) error {
+	session, tow, err := s.findCheckoutTow(ctx, event)
+	if err != nil {
+		return err
+	}
+	if _, err := s.recordStripePayment(ctx, tow, session); err != nil {
+		return err
+	}
+	return s.markCheckoutEvent(ctx, tow, event)
+}
+
+// handleCheckoutFailed marks the tow's payment failed when a delayed payment method is declined. A tow with
+// payments is left alone, and so is a tow a newer checkout event was applied to, since events can arrive out
+// of order.
+func (s *PaymentService) handleCheckoutFailed(ctx context.Context, event *stripe.Event) error {
+	_, tow, err := s.findCheckoutTow(ctx, event)
+	if err != nil {
+		return err
+	}
+	if staleCheckoutEvent(tow, event) {
+		log.Printf("ignoring stripe event %s for tow %s, a newer checkout event was applied", event.ID, *tow.ID)
+		return nil
+	}
+	switch stringValue(tow.PaymentStatus) {
+	case "", paymentStatusUnpaid, paymentStatusExpired:
+		return s.setCheckoutStatus(ctx, tow, paymentStatusFailed, event)
+	}
+	return nil
+}
+
+// handleCheckoutExpired records that the tow's checkout link can no longer be paid, so dispatch can send a new
+// one. Only unpaid tows are marked, and neither sessions that were already replaced by a regenerated link nor
+// events older than the last checkout event applied to the tow change anything.
+func (s *PaymentService) handleCheckoutExpired(ctx context.Context, event *stripe.Event) error {
+	session, tow, err := s.lookupCheckoutTow(ctx, event)
+	if err != nil {
+		return err
+	}
+	if tow == nil {
+		log.Printf("no tow found for expired checkout session %s; the link was replaced", session.ID)
+		return nil
+	}
+	if staleCheckoutEvent(tow, event) {
+		log.Printf("ignoring stripe event %s for tow %s, a newer checkout event was applied", event.ID, *tow.ID)
+		return nil
+	}
+	if status := stringValue(tow.PaymentStatus); status != "" && status != paymentStatusUnpaid {
+		return nil
+	}
+	return s.setCheckoutStatus(ctx, tow, paymentStatusExpired, event)
+}
+
+// handleChargeRefunded reconciles the tow's refunds with Stripe, including refunds made in the Stripe dashboard.
+func (s *PaymentService) handleChargeRefunded(ctx context.Context, event *stripe.Event) error {
+	var charge stripe.Charge
+	if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+		return fmt.Errorf("failed to unmarshal charge from event data: %w", err)
+	}
+
+	tow, err := s.findPaymentIntentTow(ctx, charge.PaymentIntent)
+	if err != nil || tow == nil {
+		return err
+	}
+
+	_, err = s.syncRefunds(ctx, tow)
+	return err
+}
+
+// handleDispute tracks a chargeback on any of the tow's Stripe payments: the dispute is stored on the tow, which is
+// disputed while it is open and back to the status its ledger adds up to when the company keeps the money. The
+// company is alerted when the dispute opens and when it closes, unless the event arrived after a newer one.
+func (s *PaymentService) handleDispute(ctx context.Context, event *stripe.Event) error {
+	var dispute stripe.Dispute
+	if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+		return fmt.Errorf("failed to unmarshal dispute from event data: %w", err)
+	}
+
+	tow, err := s.findPaymentIntentTow(ctx, dispute.PaymentIntent)
+	if err != nil || tow == nil {
+		return err
+	}
+
+	applied, err := s.recordDispute(ctx, tow, &dispute, event.Created)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		log.Printf("ignoring stripe event %s for tow %s, a newer dispute event was recorded", event.ID, *tow.ID)
+		return nil
+	}
+
+	switch event.Type {
+	case stripe.EventTypeChargeDisputeCreated, stripe.EventTypeChargeDisputeClosed:
+		s.alertDispute(ctx, tow, event.Type)
+	}
+	return nil
+}
+
+// handleAccountUpdated keeps the company's copy of its Stripe account capabilities in sync.
+func (s *PaymentService) handleAccountUpdated(ctx context.Context, event *stripe.Event) error {
+	var account stripe.Account
+	if err := json.Unmarshal(event.Data.Raw, &account); err != nil {
+		return fmt.Errorf("failed to unmarshal account from event data: %w", err)
+	}
+	if account.ID == "" {
+		return fmt.Errorf("account ID is empty")
+	}
+
+	companies, err := s.companyRepository.Find(ctx, &model.Company{StripeAccountId: &account.ID})
+	if err != nil {
+		return fmt.Errorf("failed to find company with stripe account %s: %w", account.ID, err)
+	}
+	if len(companies) == 0 || companies[0].ID == nil {
+		log.Printf("no company found for stripe account %s", account.ID)
+		return nil
+	}
+
+	update := &model.Company{
+		ChargesEnabled:   &account.ChargesEnabled,
+		PayoutsEnabled:   &account.PayoutsEnabled,
+		DetailsSubmitted: &account.DetailsSubmitted,
+	}
+	if err := s.companyRepository.Update(ctx, *companies[0].ID, update); err != nil {
+		return fmt.Errorf("failed to update company stripe status: %w", err)
+	}
+	return nil
+}
+
+// findCheckoutTow returns the checkout session in the event and the tow it was created for.
+func (s *PaymentService) findCheckoutTow(ctx context.Context, event *stripe.Event) (*stripe.CheckoutSession, *model.Tow, error) {
+	checkoutSession, tow, err := s.lookupCheckoutTow(ctx, event)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The tow may not be saved yet when Stripe is quick; the error makes Stripe retry
+	if tow == nil {
+		return nil, nil, fmt.Errorf("tow not found with payment reference %s", checkoutSession.ID)
+	}
+	return checkoutSession, tow, nil
+}
+
+// lookupCheckoutTow returns the checkout session in the event and the tow it currently belongs to, or a nil tow
+// when no tow uses the session.
+func (s *PaymentService) lookupCheckoutTow(ctx context.Context, event *stripe.Event) (*stripe.CheckoutSession, *model.Tow, error) {
+	var checkoutSession stripe.CheckoutSession
+	if err := json.Unmarshal(event.Data.Raw, &checkoutSession); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal checkout session from event data: %w", err)
+	}
+
+	if checkoutSession.ID == "" {
+		return nil, nil, fmt.Errorf("checkout session ID is empty")
+	}
+
+	tows, err := s.towDataRepository.Find(ctx, &model.Tow{PaymentReference: &checkoutSession.ID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find tow with payment reference %s: %w", checkoutSession.ID, err)
+	}
+
+	if len(tows) == 0 {
+		return &checkoutSession, nil, nil
+	}
+
+	if tows[0].ID == nil || *tows[0].ID == "" {
+		return nil, nil, fmt.Errorf("tow ID is empty")
+	}
+
+	return &checkoutSession, tows[0], nil
+}
+
+// findPaymentIntentTow returns the tow paid with a payment intent, which may be any of its Stripe payments.
+// Charges that did not come from a tow checkout (e.g. made directly in the dashboard) return nil without an error.
+func (s *PaymentService) findPaymentIntentTow(ctx context.Context, paymentIntent *stripe.PaymentIntent) (*model.Tow, error) {
+	if paymentIntent == nil || paymentIntent.ID == "" {
+		return nil, nil
+	}
+
+	tows, err := s.towDataRepository.FindByPaymentIntent(ctx, paymentIntent.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tow with payment intent %s: %w", paymentIntent.ID, err)
+	}
+	if len(tows) == 0 || tows[0].ID == nil {
+		log.Printf("no tow found for payment intent %s", paymentIntent.ID)
+		return nil, nil
+	}
+
+	return tows[0], nil
+}
+
+// setCheckoutStatus updates the tow's payment status from a checkout event and records the event as the
+// latest one applied.
+func (s *PaymentService) setCheckoutStatus(ctx context.Context, tow *model.Tow, status string, event *stripe.Event) error {
+	if strings.EqualFold(stringValue(tow.PaymentStatus), status) {
+		return s.markCheckoutEvent(ctx, tow, event)
+	}
+	if err := s.towDataRepository.Update(ctx, *tow.ID, &model.Tow{PaymentStatus: &status, CheckoutEventAt: &event.Created}); err != nil {
+		return fmt.Errorf("failed to update tow payment status: %w", err)
+	}
+	return nil
+}
+
+// staleCheckoutEvent reports whether a checkout event newer than event was already applied to the tow. Stripe
+// does not deliver events in the order they were created.
+func staleCheckoutEvent(tow *model.Tow, event *stripe.Event) bool {
+	return event.Created < int64Value(tow.CheckoutEventAt)
+}
+
+// markCheckoutEvent records event as the latest checkout event applied to the tow.
+func (s *PaymentService) markCheckoutEvent(ctx context.Context, tow *model.Tow, event *stripe.Event) error {
+	if event.Created <= int64Value(tow.CheckoutEventAt) {
+		return nil
+	}
+	if err := s.towDataRepository.Update(ctx, *tow.ID, &model.Tow{CheckoutEventAt: &event.Created}); err != nil {
+		return fmt.Errorf("failed to record tow checkout event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,500 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"tow-management-system-api/model"
+
+	"github.com/google/uuid"
+)
+
+type CommissionRuleRepository interface {
+	Create(ctx context.Context, item *model.CommissionRule) error
+	Find(ctx context.Context, filterModel *model.CommissionRule) ([]*model.CommissionRule, error)
+	Update(ctx context.Context, id string, updateData *model.CommissionRule) error
+	Delete(ctx context.Context, id string) error
+}
+
+type PayrollRunRepository interface {
+	Create(ctx context.Context, item *model.PayrollRun) error
+	Find(ctx context.Context, filterModel *model.PayrollRun) ([]*model.PayrollRun, error)
+	Update(ctx context.Context, id string, updateData *model.PayrollRun) error
+}
+
+// UserFinder is the minimal dependency needed to look up users (e.g. drivers) by filter.
+type UserFinder interface {
+	Find(ctx context.Context, filter *model.User) ([]*model.User, error)
+}
+
+const (
+	commissionTypePercentage = "percentage"
+	commissionTypeFlat       = "flat"
+
+	payrollStatusDraft    = "draft"
+	payrollStatusApproved = "approved"
+)
+
+// PayrollService computes driver earnings from completed tows and manages payroll runs.
+type PayrollService struct {
+	commissionRuleRepository CommissionRuleRepository
+	payrollRunRepository     PayrollRunRepository
+	towRepository            TowFinder
+	userRepository           UserFinder
+}
+
+// NewPayrollService creates a new PayrollService instance.
+func NewPayrollService(commissionRuleRepo CommissionRuleRepository, payrollRunRepo PayrollRunRepository, towRepo TowFinder, userRepo UserFinder) *PayrollService {
+	return &PayrollService{
+		commissionRuleRepository: commissionRuleRepo,
+		payrollRunRepository:     payrollRunRepo,
+		towRepository:            towRepo,
+		userRepository:           userRepo,
+	}
+}
+
+// FindCommissionRules retrieves all commission rules configured for a company.
+func (s *PayrollService) FindCommissionRules(ctx context.Context, companyId string) ([]*model.CommissionRule, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+
+	rules, err := s.commissionRuleRepository.Find(ctx, &model.CommissionRule{CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("find commission rules failed: %w", err)
+	}
+
+	return rules, nil
+}
+
+// SetCommissionRules creates or updates the commission rules of a company.
+// Uses Create if rule.id is null or empty, otherwise uses Update. Rules being updated must belong to the company,
+// and the company can have at most one default rule (one without a driver). Nothing is saved unless every rule is valid.
+func (s *PayrollService) SetCommissionRules(ctx context.Context, companyId string, rules []*model.CommissionRule) error {
+	if companyId == "" {
+		return fmt.Errorf("company id is required")
+	}
+	if rules == nil {
+		return fmt.Errorf("commission rules list is required")
+	}
+
+	existing, err := s.FindCommissionRules(ctx, companyId)
+	if err != nil {
+		return err
+	}
+	// The company's rules as they will be once saved, by ID; new rules are keyed by position
+	result := map[string]*model.CommissionRule{}
+	for _, rule := range existing {
+		result[stringValue(rule.ID)] = rule
+	}
+
+	for i, rule := range rules {
+		if err := validateCommissionRule(rule); err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		if rule.ID == nil || *rule.ID == "" {
+			result[fmt.Sprintf("new:%d", i)] = rule
+			continue
+		}
+		if _, ok := result[*rule.ID]; !ok {
+			return fmt.Errorf("rules[%d]: commission rule %s not found", i, *rule.ID)
+		}
+		result[*rule.ID] = rule
+	}
+
+	defaults := 0
+	for _, rule := range result {
+		if rule.DriverID == nil || *rule.DriverID == "" {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		return fmt.Errorf("only one default commission rule is allowed")
+	}
+
+	for _, rule := range rules {
+		rule.CompanyID = &companyId
+
+		if rule.ID == nil || *rule.ID == "" {
+			id := uuid.NewString()
+			rule.ID = &id
+			if err := s.commissionRuleRepository.Create(ctx, rule); err != nil {
+				return fmt.Errorf("failed to create commission rule: %w", err)
+			}
+		} else {
+			if err := s.commissionRuleRepository.Update(ctx, *rule.ID, rule); err != nil {
+				return fmt.Errorf("failed to update commission rule: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GeneratePayroll computes per-driver earnings for the company's tows completed within [periodStart, periodEnd)
+// and saves them as a draft payroll run for review.
+func (s *PayrollService) GeneratePayroll(ctx context.Context, companyId string, periodStart, periodEnd int64) (*model.PayrollRun, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+	if periodEnd <= periodStart {
+		return nil, fmt.Errorf("period end must be after period start")
+	}
+
+	rules, err := s.FindCommissionRules(ctx, companyId)
+	if err != nil {
+		return nil, err
+	}
+
+	var defaultRule *model.CommissionRule
+	driverRules := map[string]*model.CommissionRule{}
+	for _, rule := range rules {
+		if rule.DriverID == nil || *rule.DriverID == "" {
+			defaultRule = rule
+		} else {
+			driverRules[*rule.DriverID] = rule
+		}
+	}
+
+	tows, err := s.towRepository.Find(ctx, &model.Tow{CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("find tows failed: %w", err)
+	}
+
+	entries := map[string]*model.PayrollEntry{}
+	for _, tow := range tows {
+		if !isPayableTow(tow, periodStart, periodEnd) {
+			continue
+		}
+
+		driverId := *tow.DriverID
+		rule, ok := driverRules[driverId]
+		if !ok {
+			rule = defaultRule
+		}
+		if rule == nil {
+			return nil, fmt.Errorf("no commission rule configured for driver %s", driverId)
+		}
+
+		entry, ok := entries[driverId]
+		if !ok {
+			entry = &model.PayrollEntry{DriverID: &driverId}
+			entries[driverId] = entry
+		}
+
+		commission, hours, hourlyPay := calculateTowEarnings(rule, tow)
+
+		entry.TowIDs = append(entry.TowIDs, *tow.ID)
+		entry.TowCount++
+		entry.GrossRevenue += *tow.Price
+		entry.Commission += commission
+		entry.Hours += hours
+		entry.HourlyPay += hourlyPay
+	}
+
+	driverIds := make([]string, 0, len(entries))
+	for driverId := range entries {
+		driverIds = append(driverIds, driverId)
+	}
+	sort.Strings(driverIds)
+
+	run := &model.PayrollRun{
+		CompanyID:   &companyId,
+		PeriodStart: &periodStart,
+		PeriodEnd:   &periodEnd,
+		Entries:     make([]model.PayrollEntry, 0, len(driverIds)),
+	}
+
+	for _, driverId := range driverIds {
+		entry := entries[driverId]
+		entry.Hours = math.Round(entry.Hours*100) / 100
+		entry.DriverName = s.findDriverName(ctx, driverId)
+		entry.Total = entry.Commission + entry.HourlyPay + entry.Adjustment
+		run.Entries = append(run.Entries, *entry)
+	}
+
+	id := uuid.NewString()
+	status := payrollStatusDraft
+	now := time.Now().UTC().Unix()
+	run.ID = &id
+	run.Status = &status
+	run.CreatedAt = &now
+
+	if err := s.payrollRunRepository.Create(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to save payroll run: %w", err)
+	}
+
+	return run, nil
+}
+
+// FindPayrollRunsByCompanyId retrieves all payroll runs of a company.
+func (s *PayrollService) FindPayrollRunsByCompanyId(ctx context.Context, companyId string) ([]*model.PayrollRun, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+
+	runs, err := s.payrollRunRepository.Find(ctx, &model.PayrollRun{CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("find payroll runs failed: %w", err)
+	}
+
+	return runs, nil
+}
+
+// FindPayrollRunById retrieves a single payroll run of a company.
+func (s *PayrollService) FindPayrollRunById(ctx context.Context, companyId string, payrollId string) (*model.PayrollRun, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+	if payrollId == "" {
+		return nil, fmt.Errorf("payroll id is required")
+	}
+
+	runs, err := s.payrollRunRepository.Find(ctx, &model.PayrollRun{ID: &payrollId, CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("find payroll run failed: %w", err)
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("payroll run not found")
+	}
+
+	return runs[0], nil
+}
+
+// AdjustPayrollEntry records a bookkeeper adjustment (positive or negative, in cents) on a driver's entry
+// of a company's draft payroll run and recomputes that entry's total.
+func (s *PayrollService) AdjustPayrollEntry(ctx context.Context, companyId string, payrollId string, driverId string, adjustment int, note string) (*model.PayrollRun, error) {
+	if driverId == "" {
+		return nil, fmt.Errorf("driver id is required")
+	}
+
+	run, err := s.FindPayrollRunById(ctx, companyId, payrollId)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status == nil || *run.Status != payrollStatusDraft {
+		return nil, fmt.Errorf("only draft payroll runs can be adjusted")
+	}
+
+	found := false
+	for i := range run.Entries {
+		entry := &run.Entries[i]
+		if entry.DriverID == nil || *entry.DriverID != driverId {
+			continue
+		}
+		entry.Adjustment = adjustment
+		entry.AdjustmentNote = &note
+		entry.Total = entry.Commission + entry.HourlyPay + entry.Adjustment
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("payroll entry for driver %s not found", driverId)
+	}
+
+	if err := s.payrollRunRepository.Update(ctx, payrollId, &model.PayrollRun{Entries: run.Entries}); err != nil {
+		return nil, fmt.Errorf("failed to update payroll run: %w", err)
+	}
+
+	return run, nil
+}
+
+// ApprovePayrollRun locks a company's draft payroll run so it can no longer be adjusted.
+func (s *PayrollService) ApprovePayrollRun(ctx context.Context, companyId string, payrollId string, approvedBy string) (*model.PayrollRun, error) {
+	run, err := s.FindPayrollRunById(ctx, companyId, payrollId)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status == nil || *run.Status != payrollStatusDraft {
+		return nil, fmt.Errorf("only draft payroll runs can be approved")
+	}
+
+	status := payrollStatusApproved
+	now := time.Now().UTC().Unix()
+	update := &model.PayrollRun{Status: &status, ApprovedAt: &now}
+	if approvedBy != "" {
+		update.ApprovedBy = &approvedBy
+	}
+
+	if err := s.payrollRunRepository.Update(ctx, payrollId, update); err != nil {
+		return nil, fmt.Errorf("failed to approve payroll run: %w", err)
+	}
+
+	run.Status = update.Status
+	run.ApprovedAt = update.ApprovedAt
+	run.ApprovedBy = update.ApprovedBy
+
+	return run, nil
+}
+
+// ExportPayrollCSV renders a company's payroll run as CSV with one row per driver. Amounts are in dollars.
+func (s *PayrollService) ExportPayrollCSV(ctx context.Context, companyId string, payrollId string) ([]byte, error) {
+	run, err := s.FindPayrollRunById(ctx, companyId, payrollId)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"Driver ID", "Driver Name", "Period Start", "Period End", "Tows", "Gross Revenue", "Commission", "Hours", "Hourly Pay", "Adjustment", "Adjustment Note", "Total"}
+	if err := writer.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write payroll header: %w", err)
+	}
+
+	periodStart := formatUnixDate(run.PeriodStart)
+	periodEnd := formatUnixDate(run.PeriodEnd)
+
+	for _, entry := range run.Entries {
+		row := []string{
+			stringValue(entry.DriverID),
+			stringValue(entry.DriverName),
+			periodStart,
+			periodEnd,
+			fmt.Sprintf("%d", entry.TowCount),
+			formatCents(entry.GrossRevenue),
+			formatCents(entry.Commission),
+			fmt.Sprintf("%.2f", entry.Hours),
+			formatCents(entry.HourlyPay),
+			formatCents(entry.Adjustment),
+			stringValue(entry.AdjustmentNote),
+			formatCents(entry.Total),
+		}
+		if err := writer.Write(row); err != nil {
+			return nil, fmt.Errorf("failed to write payroll row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to export payroll: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// findDriverName returns "First Last" for the driver, or nil if the user cannot be resolved.
+func (s *PayrollService) findDriverName(ctx context.Context, driverId string) *string {
+	users, err := s.userRepository.Find(ctx, &model.User{ID: &driverId})
+	if err != nil || len(users) == 0 {
+		return nil
+	}
+
+	name := strings.TrimSpace(stringValue(users[0].FirstName) + " " + stringValue(users[0].LastName))
+	if name == "" {
+		return nil
+	}
+
+	return &name
+}
+
+// isPayableTow reports whether a tow was completed by a driver within [periodStart, periodEnd) and has a price.
+func isPayableTow(tow *model.Tow, periodStart, periodEnd int64) bool {
+	if tow.ID == nil || tow.DriverID == nil || *tow.DriverID == "" || tow.Price == nil {
+		return false
+	}
+	if tow.Status == nil || strings.ToUpper(*tow.Status) != "COMPLETED" {
+		return false
+	}
+
+	// Fall back to the creation time for tows completed before CompletedAt was tracked
+	completedAt := tow.CompletedAt
+	if completedAt == nil {
+		completedAt = tow.CreatedAt
+	}
+	if completedAt == nil {
+		return false
+	}
+
+	return *completedAt >= periodStart && *completedAt < periodEnd
+}
+
+// calculateTowEarnings returns the commission, hours worked and hourly pay (cents) a rule yields for one tow.
+func calculateTowEarnings(rule *model.CommissionRule, tow *model.Tow) (int, float64, int) {
+	commission := 0
+	switch stringValue(rule.Type) {
+	case commissionTypePercentage:
+		commission = int(math.Round(float64(*tow.Price) * *rule.Percentage / 100))
+	case commissionTypeFlat:
+		commission = *rule.FlatAmount
+	}
+
+	hours := 0.0
+	if tow.DispatchedAt != nil && tow.CompletedAt != nil && *tow.CompletedAt > *tow.DispatchedAt {
+		hours = float64(*tow.CompletedAt-*tow.DispatchedAt) / 3600
+	}
+
+	hourlyPay := 0
+	if rule.HourlyRate != nil {
+		hourlyPay = int(math.Round(float64(*rule.HourlyRate) * hours))
+	}
+
+	return commission, hours, hourlyPay
+}
+
+// validateCommissionRule checks that a rule carries the amount its type requires.
+func validateCommissionRule(rule *model.CommissionRule) error {
+	if rule == nil {
+		return fmt.Errorf("commission rule is required")
+	}
+
+	switch stringValue(rule.Type) {
+	case commissionTypePercentage:
+		if rule.Percentage == nil || *rule.Percentage < 0 || *rule.Percentage > 100 {
+			return fmt.Errorf("percentage must be between 0 and 100")
+		}
+	case commissionTypeFlat:
+		if rule.FlatAmount == nil || *rule.FlatAmount < 0 {
+			return fmt.Errorf("flatAmount must be zero or greater")
+		}
+	default:
+		return fmt.Errorf("type must be %q or %q", commissionTypePercentage, commissionTypeFlat)
+	}
+
+	if rule.HourlyRate != nil && *rule.HourlyRate < 0 {
+		return fmt.Errorf("hourlyRate must be zero or greater")
+	}
+
+	return nil
+}
+
+// stringValue dereferences an optional string, returning "" for nil.
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// intValue dereferences an optional int, returning 0 for nil.
+func intValue(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+// int64Value dereferences an optional int64, returning 0 for nil.
+func int64Value(i *int64) int64 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+// formatCents renders an amount in cents as a dollar string, e.g. 1250 -> "12.50".
+func formatCents(cents int) string {
+	return fmt.Sprintf("%.2f", float64(cents)/100)
+}
+
+// formatUnixDate renders unix seconds as a UTC YYYY-MM-DD date.
+func formatUnixDate(ts *int64) string {
+	if ts == nil {
+		return ""
+	}
+	return time.Unix(*ts, 0).UTC().Format("2006-01-02")
+}
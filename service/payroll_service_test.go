@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"tow-management-system-api/model"
+)
+
+type memoryCommissionRules struct {
+	memoryStore[model.CommissionRule]
+}
+
+func (r *memoryCommissionRules) Delete(ctx context.Context, id string) error {
+	return nil
+}
+
+func TestCalculateTowEarnings(t *testing.T) {
+	cents := func(c int) *int { return &c }
+	percent := func(p float64) *float64 { return &p }
+	ruleType := func(s string) *string { return &s }
+	at := func(ts int64) *int64 { return &ts }
+
+	tests := []struct {
+		name           string
+		rule           *model.CommissionRule
+		tow            *model.Tow
+		wantCommission int
+		wantHours      float64
+		wantHourlyPay  int
+	}{
+		{
+			name:           "percentage rounds to the cent",
+			rule:           &model.CommissionRule{Type: ruleType(commissionTypePercentage), Percentage: percent(25)},
+			tow:            &model.Tow{Price: cents(10001)},
+			wantCommission: 2500,
+		},
+		{
+			name:           "flat amount ignores the price",
+			rule:           &model.CommissionRule{Type: ruleType(commissionTypeFlat), FlatAmount: cents(4000)},
+			tow:            &model.Tow{Price: cents(25000)},
+			wantCommission: 4000,
+		},
+		{
+			name:           "hourly rate on top of the commission",
+			rule:           &model.CommissionRule{Type: ruleType(commissionTypeFlat), FlatAmount: cents(1000), HourlyRate: cents(2000)},
+			tow:            &model.Tow{Price: cents(10000), DispatchedAt: at(0), CompletedAt: at(5400)},
+			wantCommission: 1000,
+			wantHours:      1.5,
+			wantHourlyPay:  3000,
+		},
+		{
+			name:           "no hours without a dispatch time",
+			rule:           &model.CommissionRule{Type: ruleType(commissionTypePercentage), Percentage: percent(10), HourlyRate: cents(2000)},
+			tow:            &model.Tow{Price: cents(10000), CompletedAt: at(5400)},
+			wantCommission: 1000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commission, hours, hourlyPay := calculateTowEarnings(tt.rule, tt.tow)
+			if commission != tt.wantCommission || hours != tt.wantHours || hourlyPay != tt.wantHourlyPay {
+				t.Errorf("calculateTowEarnings() = %d, %v, %d; want %d, %v, %d",
+					commission, hours, hourlyPay, tt.wantCommission, tt.wantHours, tt.wantHourlyPay)
+			}
+		})
+	}
+}
+
+func TestSetCommissionRules(t *testing.T) {
+	str := func(s string) *string { return &s }
+	percent := func(p float64) *float64 { return &p }
+	percentage := func(id, companyId, driverId string) *model.CommissionRule {
+		rule := &model.CommissionRule{Type: str(commissionTypePercentage), Percentage: percent(20)}
+		if id != "" {
+			rule.ID = str(id)
+		}
+		if companyId != "" {
+			rule.CompanyID = str(companyId)
+		}
+		if driverId != "" {
+			rule.DriverID = str(driverId)
+		}
+		return rule
+	}
+
+	tests := []struct {
+		name    string
+		rules   []*model.CommissionRule
+		wantErr string
+	}{
+		{name: "update own rule and add a driver rule", rules: []*model.CommissionRule{percentage("rule-default", "", ""), percentage("", "", "driver-2")}},
+		{name: "rule of another company", rules: []*model.CommissionRule{percentage("rule-other", "", "driver-3")}, wantErr: "not found"},
+		{name: "second default rule", rules: []*model.CommissionRule{percentage("", "", "")}, wantErr: "only one default"},
+		{name: "two default rules in one request", rules: []*model.CommissionRule{percentage("rule-default", "", "driver-4"), percentage("", "", ""), percentage("", "", "")}, wantErr: "only one default"},
+		{name: "existing default moved to a driver", rules: []*model.CommissionRule{percentage("rule-default", "", "driver-4"), percentage("", "", "")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := &memoryCommissionRules{}
+			for _, rule := range []*model.CommissionRule{percentage("rule-default", "company-1", ""), percentage("rule-driver", "company-1", "driver-1"), percentage("rule-other", "company-2", "")} {
+				if err := repo.Create(ctx, rule); err != nil {
+					t.Fatal(err)
+				}
+			}
+			svc := NewPayrollService(repo, nil, nil, nil)
+
+			err := svc.SetCommissionRules(ctx, "company-1", tt.rules)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SetCommissionRules() error = %v, want %q", err, tt.wantErr)
+				}
+				if rules, _ := repo.Find(ctx, &model.CommissionRule{}); len(rules) != 3 {
+					t.Errorf("%d rules stored after a rejected request, want the original 3", len(rules))
+				}
+				if other := repo.get("rule-other"); stringValue(other.CompanyID) != "company-2" {
+					t.Errorf("rule-other moved to company %q", stringValue(other.CompanyID))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetCommissionRules() error = %v", err)
+			}
+
+			rules, _ := svc.FindCommissionRules(ctx, "company-1")
+			defaults := 0
+			for _, rule := range rules {
+				if stringValue(rule.DriverID) == "" {
+					defaults++
+				}
+			}
+			if defaults != 1 {
+				t.Errorf("company has %d default rules, want 1", defaults)
+			}
+		})
+	}
+}
+
+func TestPayrollRunsAreScopedToTheCompany(t *testing.T) {
+	str := func(s string) *string { return &s }
+	ctx := context.Background()
+
+	runs := &memoryStore[model.PayrollRun]{}
+	if err := runs.Create(ctx, &model.PayrollRun{
+		ID:        str("payroll-1"),
+		CompanyID: str("company-1"),
+		Status:    str(payrollStatusDraft),
+		Entries:   []model.PayrollEntry{{DriverID: str("driver-1"), Commission: 10000, Total: 10000}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewPayrollService(nil, runs, nil, nil)
+
+	if _, err := svc.FindPayrollRunById(ctx, "company-2", "payroll-1"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("FindPayrollRunById() of another company's run error = %v, want not found", err)
+	}
+	if _, err := svc.AdjustPayrollEntry(ctx, "company-2", "payroll-1", "driver-1", -5000, "fuel"); err == nil {
+		t.Errorf("AdjustPayrollEntry() of another company's run expected an error")
+	}
+	if _, err := svc.ApprovePayrollRun(ctx, "company-2", "payroll-1", "user-2"); err == nil {
+		t.Errorf("ApprovePayrollRun() of another company's run expected an error")
+	}
+	if _, err := svc.ExportPayrollCSV(ctx, "company-2", "payroll-1"); err == nil {
+		t.Errorf("ExportPayrollCSV() of another company's run expected an error")
+	}
+	if run := runs.get("payroll-1"); stringValue(run.Status) != payrollStatusDraft || run.Entries[0].Total != 10000 {
+		t.Errorf("run = %+v, want the draft unchanged", run)
+	}
+
+	run, err := svc.AdjustPayrollEntry(ctx, "company-1", "payroll-1", "driver-1", -5000, "fuel")
+	if err != nil {
+		t.Fatalf("AdjustPayrollEntry() error = %v", err)
+	}
+	if run.Entries[0].Total != 5000 {
+		t.Errorf("total = %d, want 5000", run.Entries[0].Total)
+	}
+}
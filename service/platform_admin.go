@@ -0,0 +1,23 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// authorizePlatformAdmin checks that the user is one of the platform admins listed in PLATFORM_ADMIN_USER_IDS
+// (separated by commas or spaces). Platform settings such as plans and fees, and webhook events that span every
+// company, are only managed by platform admins.
+func authorizePlatformAdmin(userId string) error {
+	if userId == "" {
+		return fmt.Errorf("user id is required")
+	}
+	adminIds := strings.FieldsFunc(os.Getenv("PLATFORM_ADMIN_USER_IDS"), func(r rune) bool { return r == ',' || r == ' ' })
+	for _, adminId := range adminIds {
+		if adminId == userId {
+			return nil
+		}
+	}
+	return fmt.Errorf("user is not allowed to manage the platform")
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"tow-management-system-api/model"
+)
+
+// platformPlan is the application fee the platform keeps from each tow payment of a company on the plan.
+type platformPlan struct {
+	FeePercent float64 // percent of the amount charged
+	FeeFixed   int64   // cents per payment
+}
+
+// platformPlans lists the plans a company can be on. Companies without a plan are on the standard plan.
+var platformPlans = map[string]platformPlan{
+	model.PlanStandard: {FeePercent: 5},
+	model.PlanPro:      {FeePercent: 2.5},
+}
+
+// applicationFee returns the platform fee in cents for a tow payment of total cents. The company's fee overrides
+// replace the matching part of its plan's fee. The fee never exceeds the payment.
+func applicationFee(company *model.Company, total int64) (int64, error) {
+	planName := stringValue(company.Plan)
+	if planName == "" {
+		planName = model.PlanStandard
+	}
+	plan, ok := platformPlans[planName]
+	if !ok {
+		return 0, fmt.Errorf("unknown plan %q", planName)
+	}
+
+	if company.ApplicationFeePercent != nil {
+		plan.FeePercent = *company.ApplicationFeePercent
+	}
+	if company.ApplicationFeeFixed != nil {
+		plan.FeeFixed = int64(*company.ApplicationFeeFixed)
+	}
+
+	fee := int64(math.Round(float64(total)*plan.FeePercent/100)) + plan.FeeFixed
+	if fee > total {
+		fee = total
+	}
+	if fee < 0 {
+		fee = 0
+	}
+
+	return fee, nil
+}
+
+// validateApplicationFee checks the plan and fee overrides of a company update.
+func validateApplicationFee(update *model.Company) error {
+	if update.Plan != nil {
+		if _, ok := platformPlans[*update.Plan]; !ok {
+			return fmt.Errorf("plan must be %q or %q", model.PlanStandard, model.PlanPro)
+		}
+	}
+	if update.ApplicationFeePercent != nil && (*update.ApplicationFeePercent < 0 || *update.ApplicationFeePercent > 100) {
+		return fmt.Errorf("application fee percent must be between 0 and 100")
+	}
+	if update.ApplicationFeeFixed != nil && *update.ApplicationFeeFixed < 0 {
+		return fmt.Errorf("application fee must be zero or greater")
+	}
+	return nil
+}
+
+// checkCanAcceptPayments reports an error when tow payments cannot be routed to the company's Stripe account.
+func checkCanAcceptPayments(company *model.Company) error {
+	if company.StripeAccountId == nil || *company.StripeAccountId == "" {
+		return fmt.Errorf("company does not have a stripe account id")
+	}
+	// ChargesEnabled is only known once Stripe has sent an account.updated event
+	if company.ChargesEnabled != nil && !*company.ChargesEnabled {
+		return fmt.Errorf("company stripe account cannot accept payments yet")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"tow-management-system-api/model"
+)
+
+func TestApplicationFee(t *testing.T) {
+	pro := model.PlanPro
+	unknown := "enterprise"
+	percent := 10.0
+	fixed := 30
+	bigFixed := 20000
+
+	tests := []struct {
+		name    string
+		company *model.Company
+		total   int64
+		want    int64
+		wantErr bool
+	}{
+		{name: "defaults to the standard plan", company: &model.Company{}, total: 10000, want: 500},
+		{name: "pro plan", company: &model.Company{Plan: &pro}, total: 10000, want: 250},
+		{name: "rounds to the nearest cent", company: &model.Company{Plan: &pro}, total: 1999, want: 50},
+		{name: "percent override", company: &model.Company{Plan: &pro, ApplicationFeePercent: &percent}, total: 10000, want: 1000},
+		{name: "fixed override adds to the plan percent", company: &model.Company{ApplicationFeeFixed: &fixed}, total: 10000, want: 530},
+		{name: "never exceeds the payment", company: &model.Company{ApplicationFeeFixed: &bigFixed}, total: 10000, want: 10000},
+		{name: "unknown plan", company: &model.Company{Plan: &unknown}, total: 10000, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applicationFee(tt.company, tt.total)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applicationFee() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("applicationFee() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCanAcceptPayments(t *testing.T) {
+	account := "acct_123"
+	empty := ""
+	enabled := true
+	disabled := false
+
+	tests := []struct {
+		name    string
+		company *model.Company
+		wantErr bool
+	}{
+		{name: "no stripe account", company: &model.Company{}, wantErr: true},
+		{name: "empty stripe account", company: &model.Company{StripeAccountId: &empty}, wantErr: true},
+		{name: "charges not reported yet", company: &model.Company{StripeAccountId: &account}},
+		{name: "charges enabled", company: &model.Company{StripeAccountId: &account, ChargesEnabled: &enabled}},
+		{name: "charges disabled", company: &model.Company{StripeAccountId: &account, ChargesEnabled: &disabled}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkCanAcceptPayments(tt.company); (err != nil) != tt.wantErr {
+				t.Errorf("checkCanAcceptPayments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompanyPlanIsSetByPlatformAdmins(t *testing.T) {
+	t.Setenv("PLATFORM_ADMIN_USER_IDS", "admin-1")
+	str := func(s string) *string { return &s }
+	percent := func(p float64) *float64 { return &p }
+	cents := func(c int) *int { return &c }
+	flag := func(b bool) *bool { return &b }
+	ctx := context.Background()
+
+	companies := &memoryStore[model.Company]{}
+	if err := companies.Create(ctx, &model.Company{ID: str("company-1"), Name: str("Acme Towing"), StripeAccountId: str("acct_1"), ChargesEnabled: flag(false)}); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewCompanyService(companies, nil)
+
+	// A company updating itself cannot change its fees or payment status
+	if err := svc.UpdateCompany(ctx, "company-1", &model.Company{
+		Name:                  str("Acme Towing & Recovery"),
+		Plan:                  str(model.PlanPro),
+		ApplicationFeePercent: percent(0),
+		ApplicationFeeFixed:   cents(0),
+		StripeAccountId:       str("acct_2"),
+		ChargesEnabled:        flag(true),
+	}); err != nil {
+		t.Fatalf("UpdateCompany() error = %v", err)
+	}
+	company := companies.get("company-1")
+	if stringValue(company.Name) != "Acme Towing & Recovery" {
+		t.Errorf("name = %q, want the update", stringValue(company.Name))
+	}
+	if company.Plan != nil || company.ApplicationFeePercent != nil || company.ApplicationFeeFixed != nil || stringValue(company.StripeAccountId) != "acct_1" || *company.ChargesEnabled {
+		t.Errorf("company = %+v, want the plan, fees and Stripe status unchanged", company)
+	}
+
+	for userId, wantErr := range map[string]string{"": "user id is required", "user-1": "not allowed"} {
+		if err := svc.UpdateCompanyPlan(ctx, userId, "company-1", &model.Company{Plan: str(model.PlanPro)}); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("UpdateCompanyPlan(%q) error = %v, want %q", userId, err, wantErr)
+		}
+	}
+	if err := svc.UpdateCompanyPlan(ctx, "admin-1", "company-1", &model.Company{Plan: str("enterprise")}); err == nil {
+		t.Errorf("UpdateCompanyPlan() accepted an unknown plan")
+	}
+	if err := svc.UpdateCompanyPlan(ctx, "admin-1", "company-1", &model.Company{Plan: str(model.PlanPro), ApplicationFeeFixed: cents(30), ChargesEnabled: flag(true)}); err != nil {
+		t.Fatalf("UpdateCompanyPlan() error = %v", err)
+	}
+	company = companies.get("company-1")
+	if stringValue(company.Plan) != model.PlanPro || intValue(company.ApplicationFeeFixed) != 30 || *company.ChargesEnabled {
+		t.Errorf("company = %+v, want the pro plan with a 30 cent fee and nothing else changed", company)
+	}
+}
@@ -3,8 +3,12 @@ package service
 import (
 	"context"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
 	"tow-management-system-api/model"
-	"tow-management-system-api/repository"
+	"tow-management-system-api/utilities"
 
 	"github.com/google/uuid"
 )
@@ -13,49 +17,174 @@ type PriceRepository interface {
 	Create(ctx context.Context, item *model.Price) error
 	Find(ctx context.Context, filterModel *model.Price) ([]*model.Price, error)
 	Update(ctx context.Context, id string, updateData *model.Price) error
+	Delete(ctx context.Context, id string) error
 }
 
-// PriceService defines business logic for the Price entity.
+type PriceListRepository interface {
+	Create(ctx context.Context, item *model.PriceList) error
+	Find(ctx context.Context, filterModel *model.PriceList) ([]*model.PriceList, error)
+	Update(ctx context.Context, id string, updateData *model.PriceList) error
+}
+
+type PricingZoneRepository interface {
+	Create(ctx context.Context, item *model.PricingZone) error
+	Find(ctx context.Context, filterModel *model.PricingZone) ([]*model.PricingZone, error)
+	Replace(ctx context.Context, id string, zone *model.PricingZone) error
+	Delete(ctx context.Context, id string) error
+}
+
+// CompanyFinder is the minimal dependency needed to look up a company by filter.
+type CompanyFinder interface {
+	Find(ctx context.Context, filterModel *model.Company) ([]*model.Company, error)
+}
+
+const (
+	priceListStatusDraft     = "draft"
+	priceListStatusPublished = "published"
+)
+
+// PriceService defines business logic for the Price entity and versioned price lists.
 type PriceService struct {
-	priceRepository *repository.PriceMongoRepository
+	priceRepository       PriceRepository
+	priceListRepository   PriceListRepository
+	pricingZoneRepository PricingZoneRepository
+	towRepository         TowFinder
+	companyRepository     CompanyFinder
 }
 
 // NewPriceService creates a new PriceService instance.
-func NewPriceService(priceRepo *repository.PriceMongoRepository) *PriceService {
+func NewPriceService(priceRepo PriceRepository, priceListRepo PriceListRepository, pricingZoneRepo PricingZoneRepository, towRepo TowFinder, companyRepo CompanyFinder) *PriceService {
 	return &PriceService{
-		priceRepository: priceRepo,
+		priceRepository:       priceRepo,
+		priceListRepository:   priceListRepo,
+		pricingZoneRepository: pricingZoneRepo,
+		towRepository:         towRepo,
+		companyRepository:     companyRepo,
 	}
 }
 
-// SetPrice creates or sets multiple prices. Returns nil if no errors.
-// Uses Create if price.id is null or empty, otherwise uses Update.
-func (s *PriceService) SetPrice(ctx context.Context, prices []*model.Price) error {
+// SetPrice validates and saves a company's catalog prices. Prices without an id are created; prices with an
+// id update an existing item of the same company and only change the fields that are set. Item names must be
+// unique among the company's active items. Nothing is saved unless every price is valid.
+func (s *PriceService) SetPrice(ctx context.Context, companyId string, prices []*model.Price) error {
+	if companyId == "" {
+		return fmt.Errorf("company id is required")
+	}
 	if prices == nil {
 		return fmt.Errorf("prices list is required")
 	}
 
+	existing, err := s.priceRepository.Find(ctx, &model.Price{CompanyID: &companyId})
+	if err != nil {
+		return fmt.Errorf("find prices failed: %w", err)
+	}
+
+	// catalog is the company's catalog as it will look after the save
+	catalog := map[string]*model.Price{}
+	for _, price := range existing {
+		catalog[stringValue(price.ID)] = price
+	}
+
+	var creates []*model.Price
+	var updates []*model.Price
 	for _, price := range prices {
-		// Check if price has an ID - if nil or empty, create; otherwise update
+		if price == nil {
+			return fmt.Errorf("price is required")
+		}
+		if price.CompanyID != nil && *price.CompanyID != companyId {
+			return fmt.Errorf("price %q belongs to another company", stringValue(price.ItemName))
+		}
+		price.CompanyID = &companyId
+
 		if price.ID == nil || *price.ID == "" {
-			// Generate UUID for new prices
+			if err := validateCatalogPrice(price); err != nil {
+				return err
+			}
 			id := uuid.NewString()
 			price.ID = &id
-			if err := s.priceRepository.Create(ctx, price); err != nil {
-				return fmt.Errorf("failed to create price: %w", err)
+			catalog[id] = price
+			creates = append(creates, price)
+			continue
+		}
+
+		current, ok := catalog[*price.ID]
+		if !ok {
+			return fmt.Errorf("price with id %s not found", *price.ID)
+		}
+		merged := mergePrice(current, price)
+		if err := validateCatalogPrice(merged); err != nil {
+			return err
+		}
+		catalog[*price.ID] = merged
+		updates = append(updates, price)
+	}
+
+	if err := checkDuplicatePriceNames(catalog); err != nil {
+		return err
+	}
+
+	for _, price := range creates {
+		if err := s.priceRepository.Create(ctx, price); err != nil {
+			return fmt.Errorf("failed to create price: %w", err)
+		}
+	}
+	for _, price := range updates {
+		if err := s.priceRepository.Update(ctx, *price.ID, price); err != nil {
+			return fmt.Errorf("failed to update price: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SetLegacyPrices saves prices sent to the legacy PUT /pricing, which has no company in the path. Each price
+// belongs to the company it names or, for an update, to the company of the stored item, and each company's
+// prices are saved with SetPrice. New prices must name their company.
+func (s *PriceService) SetLegacyPrices(ctx context.Context, prices []*model.Price) error {
+	if prices == nil {
+		return fmt.Errorf("prices list is required")
+	}
+
+	byCompany := map[string][]*model.Price{}
+	var companyIds []string
+	for _, price := range prices {
+		if price == nil {
+			return fmt.Errorf("price is required")
+		}
+
+		companyId := stringValue(price.CompanyID)
+		if companyId == "" && stringValue(price.ID) != "" {
+			stored, err := s.priceRepository.Find(ctx, &model.Price{ID: price.ID})
+			if err != nil {
+				return fmt.Errorf("find price failed: %w", err)
 			}
-		} else {
-			if err := s.priceRepository.Update(ctx, *price.ID, price); err != nil {
-				return fmt.Errorf("failed to update price: %w", err)
+			if len(stored) == 0 {
+				return fmt.Errorf("price with id %s not found", *price.ID)
 			}
+			companyId = stringValue(stored[0].CompanyID)
+		}
+		if companyId == "" {
+			return fmt.Errorf("price %q: company id is required", stringValue(price.ItemName))
+		}
+
+		if _, ok := byCompany[companyId]; !ok {
+			companyIds = append(companyIds, companyId)
+		}
+		byCompany[companyId] = append(byCompany[companyId], price)
+	}
+
+	for _, companyId := range companyIds {
+		if err := s.SetPrice(ctx, companyId, byCompany[companyId]); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-// FindPricesByCompanyId retrieves all prices that belong to a specific company.
-// Returns the prices as a slice or nil and any errors.
-func (s *PriceService) FindPricesByCompanyId(ctx context.Context, companyId string) ([]*model.Price, error) {
+// FindPricesByCompanyId retrieves the prices that belong to a specific company. Archived items are only
+// included when includeArchived is set.
+func (s *PriceService) FindPricesByCompanyId(ctx context.Context, companyId string, includeArchived bool) ([]*model.Price, error) {
 	if companyId == "" {
 		return nil, fmt.Errorf("company id is required")
 	}
@@ -68,5 +197,679 @@ func (s *PriceService) FindPricesByCompanyId(ctx context.Context, companyId stri
 		return nil, fmt.Errorf("find prices failed: %w", err)
 	}
 
-	return prices, nil
+	if includeArchived {
+		return prices, nil
+	}
+	return activePrices(prices), nil
+}
+
+// DeletePrice removes one of the company's catalog items. Items that completed or booked tows were charged for
+// are archived instead, so the tows' line items still resolve to a catalog entry. Returns whether the item was
+// archived.
+func (s *PriceService) DeletePrice(ctx context.Context, companyId string, priceId string) (bool, error) {
+	if companyId == "" {
+		return false, fmt.Errorf("company id is required")
+	}
+	if priceId == "" {
+		return false, fmt.Errorf("price id is required")
+	}
+
+	prices, err := s.priceRepository.Find(ctx, &model.Price{ID: &priceId, CompanyID: &companyId})
+	if err != nil {
+		return false, fmt.Errorf("find price failed: %w", err)
+	}
+	if len(prices) == 0 {
+		return false, fmt.Errorf("price not found")
+	}
+	price := prices[0]
+
+	tows, err := s.towRepository.Find(ctx, &model.Tow{CompanyID: price.CompanyID})
+	if err != nil {
+		return false, fmt.Errorf("failed to load tows: %w", err)
+	}
+
+	for _, tow := range tows {
+		if chargedForPrice(tow, stringValue(price.ItemName)) {
+			archived := true
+			if err := s.priceRepository.Update(ctx, priceId, &model.Price{Archived: &archived}); err != nil {
+				return false, fmt.Errorf("failed to archive price: %w", err)
+			}
+			return true, nil
+		}
+	}
+
+	if err := s.priceRepository.Delete(ctx, priceId); err != nil {
+		return false, fmt.Errorf("failed to delete price: %w", err)
+	}
+	return false, nil
+}
+
+// validateCatalogPrice checks a catalog item the same way the pricing engine checks a price list rule.
+func validateCatalogPrice(price *model.Price) error {
+	name := strings.TrimSpace(stringValue(price.ItemName))
+	if name == "" {
+		return fmt.Errorf("item name is required")
+	}
+
+	// Untyped items are only valid under the legacy names the engine still understands
+	ruleType := stringValue(price.Type)
+	if ruleType == "" {
+		switch name {
+		case legacyHookUpFeeName:
+			ruleType = model.PriceTypeFlat
+		case legacyPerMileName:
+			ruleType = model.PriceTypePerMile
+		default:
+			return fmt.Errorf("price %q: type is required", name)
+		}
+	}
+
+	if err := validatePricingRule(price, ruleType); err != nil {
+		return fmt.Errorf("price %q: %w", name, err)
+	}
+	return nil
+}
+
+// mergePrice returns current with the fields set on update applied, matching the repository's partial update.
+func mergePrice(current, update *model.Price) *model.Price {
+	merged := *current
+	if update.ItemName != nil {
+		merged.ItemName = update.ItemName
+	}
+	if update.Amount != nil {
+		merged.Amount = update.Amount
+	}
+	if update.Type != nil {
+		merged.Type = update.Type
+	}
+	if update.Percent != nil {
+		merged.Percent = update.Percent
+	}
+	if update.Condition != nil {
+		merged.Condition = update.Condition
+	}
+	if update.IncludedMiles != nil {
+		merged.IncludedMiles = update.IncludedMiles
+	}
+	if update.Tiers != nil {
+		merged.Tiers = update.Tiers
+	}
+	if update.Taxable != nil {
+		merged.Taxable = update.Taxable
+	}
+	if update.Archived != nil {
+		merged.Archived = update.Archived
+	}
+	return &merged
+}
+
+// checkDuplicatePriceNames rejects a catalog with two active items of the same name, ignoring case.
+func checkDuplicatePriceNames(catalog map[string]*model.Price) error {
+	seen := map[string]struct{}{}
+	for _, price := range activePrices(mapValues(catalog)) {
+		key := strings.ToLower(strings.TrimSpace(stringValue(price.ItemName)))
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("duplicate item name %q", stringValue(price.ItemName))
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+// activePrices returns the prices that have not been archived.
+func activePrices(prices []*model.Price) []*model.Price {
+	active := make([]*model.Price, 0, len(prices))
+	for _, price := range prices {
+		if price != nil && (price.Archived == nil || !*price.Archived) {
+			active = append(active, price)
+		}
+	}
+	return active
+}
+
+// chargedForPrice reports whether one of the tow's line items came from the catalog item with the given name.
+// Rate-based items are itemized as "Name (details)".
+func chargedForPrice(tow *model.Tow, itemName string) bool {
+	if itemName == "" {
+		return false
+	}
+	for _, item := range tow.LineItems {
+		if item.Name == itemName || strings.HasPrefix(item.Name, itemName+" (") {
+			return true
+		}
+	}
+	return false
+}
+
+// mapValues returns the prices in m in no particular order.
+func mapValues(m map[string]*model.Price) []*model.Price {
+	values := make([]*model.Price, 0, len(m))
+	for _, value := range m {
+		values = append(values, value)
+	}
+	return values
+}
+
+// CreatePriceListDraft starts a new price list version for a company. When no prices are given the draft
+// starts as a copy of the list currently in effect, so small edits do not require re-entering every rule.
+func (s *PriceService) CreatePriceListDraft(ctx context.Context, companyId string, prices []*model.Price, notes *string) (*model.PriceList, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+
+	lists, err := s.priceListRepository.Find(ctx, &model.PriceList{CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch price lists: %w", err)
+	}
+
+	version := 1
+	for _, list := range lists {
+		if list.Version != nil && *list.Version >= version {
+			version = *list.Version + 1
+		}
+	}
+
+	if prices == nil {
+		if active := selectActivePriceList(lists, time.Now().Unix()); active != nil {
+			prices = active.Prices
+		} else {
+			prices, err = s.FindPricesByCompanyId(ctx, companyId, false)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	id := uuid.NewString()
+	status := priceListStatusDraft
+	now := time.Now().UTC().Unix()
+	list := &model.PriceList{
+		ID:        &id,
+		CompanyID: &companyId,
+		Version:   &version,
+		Status:    &status,
+		Prices:    preparePriceListPrices(companyId, prices),
+		Notes:     notes,
+		CreatedAt: &now,
+	}
+
+	if err := s.priceListRepository.Create(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to create price list: %w", err)
+	}
+
+	return list, nil
+}
+
+// UpdatePriceListDraft replaces the prices and notes of a company's draft. Published versions cannot be changed.
+func (s *PriceService) UpdatePriceListDraft(ctx context.Context, companyId string, priceListId string, prices []*model.Price, notes *string) (*model.PriceList, error) {
+	list, err := s.FindPriceListById(ctx, companyId, priceListId)
+	if err != nil {
+		return nil, err
+	}
+	if stringValue(list.Status) != priceListStatusDraft {
+		return nil, fmt.Errorf("only draft price lists can be edited")
+	}
+
+	update := &model.PriceList{Notes: notes}
+	if prices != nil {
+		update.Prices = preparePriceListPrices(companyId, prices)
+	}
+
+	if err := s.priceListRepository.Update(ctx, priceListId, update); err != nil {
+		return nil, fmt.Errorf("failed to update price list: %w", err)
+	}
+
+	return s.FindPriceListById(ctx, companyId, priceListId)
+}
+
+// PublishPriceList validates a company's draft and publishes it to take effect at effectiveFrom (now when zero).
+func (s *PriceService) PublishPriceList(ctx context.Context, companyId string, priceListId string, effectiveFrom int64, publishedBy string) (*model.PriceList, error) {
+	list, err := s.FindPriceListById(ctx, companyId, priceListId)
+	if err != nil {
+		return nil, err
+	}
+	if stringValue(list.Status) != priceListStatusDraft {
+		return nil, fmt.Errorf("price list is already published")
+	}
+
+	// A published list must be able to price a tow on its own
+	if _, err := resolvePricingRules(list.Prices); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Unix()
+	if effectiveFrom == 0 {
+		effectiveFrom = now
+	}
+
+	status := priceListStatusPublished
+	update := &model.PriceList{
+		Status:        &status,
+		EffectiveFrom: &effectiveFrom,
+		PublishedAt:   &now,
+	}
+	if publishedBy != "" {
+		update.PublishedBy = &publishedBy
+	}
+
+	if err := s.priceListRepository.Update(ctx, priceListId, update); err != nil {
+		return nil, fmt.Errorf("failed to publish price list: %w", err)
+	}
+
+	return s.FindPriceListById(ctx, companyId, priceListId)
+}
+
+// FindPriceListsByCompanyId returns every price list version for a company, newest first.
+func (s *PriceService) FindPriceListsByCompanyId(ctx context.Context, companyId string) ([]*model.PriceList, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+
+	lists, err := s.priceListRepository.Find(ctx, &model.PriceList{CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("find price lists failed: %w", err)
+	}
+
+	sort.Slice(lists, func(i, j int) bool {
+		return intValue(lists[i].Version) > intValue(lists[j].Version)
+	})
+
+	return lists, nil
+}
+
+// FindPriceListById retrieves a single price list version of a company.
+func (s *PriceService) FindPriceListById(ctx context.Context, companyId string, priceListId string) (*model.PriceList, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+	if priceListId == "" {
+		return nil, fmt.Errorf("price list id is required")
+	}
+
+	lists, err := s.priceListRepository.Find(ctx, &model.PriceList{ID: &priceListId, CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch price list: %w", err)
+	}
+	if len(lists) == 0 {
+		return nil, fmt.Errorf("price list not found")
+	}
+
+	return lists[0], nil
+}
+
+// preparePriceListPrices copies prices into a list, giving each a stable ID and the owning company.
+func preparePriceListPrices(companyId string, prices []*model.Price) []*model.Price {
+	prepared := make([]*model.Price, 0, len(prices))
+	for _, price := range prices {
+		if price == nil {
+			continue
+		}
+		copied := *price
+		if copied.ID == nil || *copied.ID == "" {
+			id := uuid.NewString()
+			copied.ID = &id
+		}
+		copied.CompanyID = &companyId
+		prepared = append(prepared, &copied)
+	}
+	return prepared
+}
+
+// selectActivePriceList returns the published list in effect at the given unix time: the one with the latest
+// EffectiveFrom not after at, preferring the higher version when two take effect together.
+func selectActivePriceList(lists []*model.PriceList, at int64) *model.PriceList {
+	var active *model.PriceList
+	for _, list := range lists {
+		if stringValue(list.Status) != priceListStatusPublished || list.EffectiveFrom == nil || *list.EffectiveFrom > at {
+			continue
+		}
+		if active == nil ||
+			*list.EffectiveFrom > *active.EffectiveFrom ||
+			(*list.EffectiveFrom == *active.EffectiveFrom && intValue(list.Version) > intValue(active.Version)) {
+			active = list
+		}
+	}
+	return active
+}
+
+// CreatePricingZone validates and saves a pricing zone for a company.
+func (s *PriceService) CreatePricingZone(ctx context.Context, companyId string, zone *model.PricingZone) (*model.PricingZone, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+	if zone == nil {
+		return nil, fmt.Errorf("pricing zone is required")
+	}
+	if err := validatePricingZone(zone); err != nil {
+		return nil, err
+	}
+
+	id := uuid.NewString()
+	zone.ID = &id
+	zone.CompanyID = &companyId
+
+	if err := s.pricingZoneRepository.Create(ctx, zone); err != nil {
+		return nil, fmt.Errorf("failed to create pricing zone: %w", err)
+	}
+
+	return zone, nil
+}
+
+// UpdatePricingZone replaces one of a company's pricing zones. Fields left out of zone are cleared; the zone keeps
+// its ID and company.
+func (s *PriceService) UpdatePricingZone(ctx context.Context, companyId string, zoneId string, zone *model.PricingZone) error {
+	if zone == nil {
+		return fmt.Errorf("pricing zone is required")
+	}
+	if _, err := s.findPricingZone(ctx, companyId, zoneId); err != nil {
+		return err
+	}
+	if err := validatePricingZone(zone); err != nil {
+		return err
+	}
+
+	zone.ID = &zoneId
+	zone.CompanyID = &companyId
+
+	if err := s.pricingZoneRepository.Replace(ctx, zoneId, zone); err != nil {
+		return fmt.Errorf("failed to update pricing zone: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePricingZone removes one of a company's pricing zones. Tows already priced with it keep their price.
+func (s *PriceService) DeletePricingZone(ctx context.Context, companyId string, zoneId string) error {
+	if _, err := s.findPricingZone(ctx, companyId, zoneId); err != nil {
+		return err
+	}
+
+	if err := s.pricingZoneRepository.Delete(ctx, zoneId); err != nil {
+		return fmt.Errorf("failed to delete pricing zone: %w", err)
+	}
+
+	return nil
+}
+
+// findPricingZone fetches a pricing zone by ID, only if it belongs to the company.
+func (s *PriceService) findPricingZone(ctx context.Context, companyId string, zoneId string) (*model.PricingZone, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+	if zoneId == "" {
+		return nil, fmt.Errorf("pricing zone id is required")
+	}
+
+	zones, err := s.pricingZoneRepository.Find(ctx, &model.PricingZone{ID: &zoneId, CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pricing zone: %w", err)
+	}
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("pricing zone not found")
+	}
+
+	return zones[0], nil
+}
+
+// FindPricingZonesByCompanyId returns a company's pricing zones.
+func (s *PriceService) FindPricingZonesByCompanyId(ctx context.Context, companyId string) ([]*model.PricingZone, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+
+	zones, err := s.pricingZoneRepository.Find(ctx, &model.PricingZone{CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("find pricing zones failed: %w", err)
+	}
+
+	return zones, nil
+}
+
+// validatePricingZone checks a zone's area and pricing, and normalizes its ZIP codes.
+func validatePricingZone(zone *model.PricingZone) error {
+	if zone.Name == nil || strings.TrimSpace(*zone.Name) == "" {
+		return fmt.Errorf("zone name is required")
+	}
+	if zone.Polygon == nil && len(zone.PostalCodes) == 0 {
+		return fmt.Errorf("zone needs a polygon or postal codes")
+	}
+	if zone.Polygon != nil {
+		if zone.Polygon.Type != "Polygon" {
+			return fmt.Errorf("zone polygon must be a GeoJSON Polygon")
+		}
+		if err := utilities.ValidatePolygon(zone.Polygon.Coordinates); err != nil {
+			return err
+		}
+	}
+	for i, postalCode := range zone.PostalCodes {
+		postalCode = strings.TrimSpace(postalCode)
+		if !postalCodePattern.MatchString(postalCode) {
+			return fmt.Errorf("postal code %q must be a five digit ZIP code", postalCode)
+		}
+		zone.PostalCodes[i] = postalCode
+	}
+
+	if zone.FlatPrice == nil && zone.PerMileAmount == nil {
+		return fmt.Errorf("zone needs a flat price or a per-mile amount")
+	}
+	if zone.FlatPrice != nil && zone.PerMileAmount != nil {
+		return fmt.Errorf("zone takes a flat price or a per-mile amount, not both")
+	}
+	if zone.FlatPrice != nil && *zone.FlatPrice <= 0 {
+		return fmt.Errorf("zone flat price must be greater than zero")
+	}
+	if zone.PerMileAmount != nil && *zone.PerMileAmount < 0 {
+		return fmt.Errorf("zone per-mile amount must be zero or greater")
+	}
+
+	return nil
+}
+
+// matchPricingZone returns the zone containing both the pickup and the destination, preferring the highest
+// priority and then the lowest ID when zones overlap. It returns nil when no zone contains the trip.
+func matchPricingZone(zones []*model.PricingZone, pickup, destination *utilities.GeocodedAddress) *model.PricingZone {
+	var best *model.PricingZone
+	for _, zone := range zones {
+		if !zoneContains(zone, pickup) || !zoneContains(zone, destination) {
+			continue
+		}
+		if best == nil ||
+			intValue(zone.Priority) > intValue(best.Priority) ||
+			(intValue(zone.Priority) == intValue(best.Priority) && stringValue(zone.ID) < stringValue(best.ID)) {
+			best = zone
+		}
+	}
+	return best
+}
+
+// zoneContains reports whether a geocoded address is inside the zone's polygon or one of its ZIP codes.
+func zoneContains(zone *model.PricingZone, address *utilities.GeocodedAddress) bool {
+	if address == nil {
+		return false
+	}
+	if zone.Polygon != nil && utilities.PointInPolygon(address.Position, zone.Polygon.Coordinates) {
+		return true
+	}
+	postalCode := address.PostalCode
+	if len(postalCode) > 5 {
+		postalCode = postalCode[:5]
+	}
+	for _, zoneCode := range zone.PostalCodes {
+		if zoneCode == postalCode {
+			return true
+		}
+	}
+	return false
+}
+
+// SimulatePriceList replays a proposed price list over the company's tows completed within [from, to) and
+// compares each tow's charge with what the proposal would have charged. Either priceListId (a draft or
+// published version) or prices must be given. Only the distances, coordinates and times stored on the tows
+// are used, so no routing calls are made. Prices are compared before sales tax and promo discounts.
+func (s *PriceService) SimulatePriceList(ctx context.Context, companyId string, priceListId string, prices []*model.Price, from, to int64) (*model.PriceSimulation, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+	if to == 0 {
+		to = time.Now().Unix()
+	}
+	if from <= 0 || to <= from {
+		return nil, fmt.Errorf("from must be before to")
+	}
+
+	companies, err := s.companyRepository.Find(ctx, &model.Company{ID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("find company failed: %w", err)
+	}
+	if len(companies) == 0 {
+		return nil, fmt.Errorf("company not found")
+	}
+	company := companies[0]
+
+	simulation := &model.PriceSimulation{
+		CompanyID: &companyId,
+		From:      from,
+		To:        to,
+		Tows:      []model.PriceSimulationTow{},
+	}
+
+	if priceListId != "" {
+		list, err := s.FindPriceListById(ctx, companyId, priceListId)
+		if err != nil {
+			return nil, err
+		}
+		prices = list.Prices
+		simulation.PriceListID = list.ID
+	}
+	if len(prices) == 0 {
+		return nil, fmt.Errorf("price list or prices are required")
+	}
+	if _, err := resolvePricingRules(prices); err != nil {
+		return nil, err
+	}
+
+	zones, err := s.pricingZoneRepository.Find(ctx, &model.PricingZone{CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pricing zones: %w", err)
+	}
+
+	tows, err := s.towRepository.Find(ctx, &model.Tow{CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tows: %w", err)
+	}
+
+	yardMiles := usesYardMiles(prices)
+	for _, tow := range tows {
+		completedAt, ok := simulationCompletedAt(tow)
+		if !ok || completedAt < from || completedAt >= to {
+			continue
+		}
+
+		result := model.PriceSimulationTow{TowID: tow.ID, CompletedAt: completedAt}
+		newPrice, lineItems, skipped := repriceTow(company, prices, zones, tow, yardMiles)
+		if skipped != "" {
+			result.Skipped = &skipped
+			simulation.SkippedCount++
+			simulation.Tows = append(simulation.Tows, result)
+			continue
+		}
+
+		result.OldPrice = chargedListPrice(tow)
+		result.NewPrice = newPrice
+		result.Delta = newPrice - result.OldPrice
+		result.LineItems = lineItems
+
+		simulation.TowCount++
+		simulation.OldRevenue += result.OldPrice
+		simulation.NewRevenue += result.NewPrice
+		simulation.Tows = append(simulation.Tows, result)
+	}
+
+	sort.Slice(simulation.Tows, func(i, j int) bool {
+		return simulation.Tows[i].CompletedAt < simulation.Tows[j].CompletedAt
+	})
+
+	simulation.RevenueDelta = simulation.NewRevenue - simulation.OldRevenue
+	if simulation.OldRevenue > 0 {
+		simulation.DeltaPercent = math.Round(float64(simulation.RevenueDelta)/float64(simulation.OldRevenue)*10000) / 100
+	}
+
+	return simulation, nil
+}
+
+// simulationCompletedAt returns when a completed tow finished, falling back to its creation time for tows
+// completed before CompletedAt was tracked.
+func simulationCompletedAt(tow *model.Tow) (int64, bool) {
+	if tow.Status == nil || strings.ToUpper(*tow.Status) != "COMPLETED" {
+		return 0, false
+	}
+	if tow.CompletedAt != nil {
+		return *tow.CompletedAt, true
+	}
+	if tow.CreatedAt != nil {
+		return *tow.CreatedAt, true
+	}
+	return 0, false
+}
+
+// repriceTow prices a completed tow with the proposed rules from its stored trip. It returns the pre-tax,
+// pre-discount total and line items, or the reason the tow cannot be repriced.
+func repriceTow(company *model.Company, prices []*model.Price, zones []*model.PricingZone, tow *model.Tow, yardMiles bool) (int64, []model.PayableLineItem, string) {
+	if tow.Price == nil {
+		return 0, nil, "tow has no price"
+	}
+	if tow.Miles == nil || tow.Minutes == nil {
+		return 0, nil, "tow has no stored route"
+	}
+	if yardMiles && tow.EnRouteMiles == nil {
+		return 0, nil, "tow has no stored en-route miles"
+	}
+
+	// Time-based rules see the tow when it was booked, as they did when it was first priced
+	var pricedAt time.Time
+	if tow.CreatedAt != nil {
+		pricedAt = time.Unix(*tow.CreatedAt, 0)
+	}
+
+	input, err := newPricingInput(company, tow, *tow.Miles, *tow.Minutes, pricedAt)
+	if err != nil {
+		return 0, nil, err.Error()
+	}
+	if tow.EnRouteMiles != nil {
+		input.EnRouteMiles = *tow.EnRouteMiles
+	}
+	if tow.ReturnMiles != nil {
+		input.ReturnMiles = *tow.ReturnMiles
+	}
+	input.Zone = simulationZone(zones, tow)
+
+	breakdown, err := calculatePrice(prices, input)
+	if err != nil {
+		return 0, nil, err.Error()
+	}
+
+	return breakdown.Total, breakdown.LineItems, ""
+}
+
+// simulationZone returns the zone a tow was priced in if it still exists, otherwise the zone whose polygon
+// contains the tow's stored coordinates. ZIP code zones need a geocode and are only matched by the stored ID.
+func simulationZone(zones []*model.PricingZone, tow *model.Tow) *model.PricingZone {
+	if tow.PricingZoneID != nil {
+		for _, zone := range zones {
+			if stringValue(zone.ID) == *tow.PricingZoneID {
+				return zone
+			}
+		}
+	}
+	if len(tow.PickupCoordinates) != 2 || len(tow.DestinationCoordinates) != 2 {
+		return nil
+	}
+	return matchPricingZone(zones,
+		&utilities.GeocodedAddress{Position: tow.PickupCoordinates},
+		&utilities.GeocodedAddress{Position: tow.DestinationCoordinates})
+}
+
+// chargedListPrice returns what a tow was charged before sales tax and its promo discount.
+func chargedListPrice(tow *model.Tow) int64 {
+	return int64(intValue(tow.Price) - intValue(tow.TaxAmount) + intValue(tow.DiscountAmount))
 }
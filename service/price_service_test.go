@@ -0,0 +1,624 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"tow-management-system-api/model"
+	"tow-management-system-api/utilities"
+)
+
+type memoryPricingZones struct {
+	memoryStore[model.PricingZone]
+}
+
+func (r *memoryPricingZones) Replace(ctx context.Context, id string, zone *model.PricingZone) error {
+	if r.get(id) == nil {
+		return fmt.Errorf("pricing zone with id %s not found", id)
+	}
+	r.replace(id, zone)
+	return nil
+}
+
+func (r *memoryPricingZones) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, doc := range r.docs {
+		if doc["_id"] == id {
+			r.docs = append(r.docs[:i], r.docs[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestMatchPricingZone(t *testing.T) {
+	str := func(s string) *string { return &s }
+	priority := func(p int) *int { return &p }
+	square := &model.GeoJSONPolygon{Type: "Polygon", Coordinates: [][][]float64{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}}}
+
+	downtown := &model.PricingZone{ID: str("zone-b"), Polygon: square}
+	airport := &model.PricingZone{ID: str("zone-a"), PostalCodes: []string{"78719"}}
+	overlap := &model.PricingZone{ID: str("zone-c"), Polygon: square, Priority: priority(1)}
+	tie := &model.PricingZone{ID: str("zone-a"), Polygon: square}
+
+	inside := &utilities.GeocodedAddress{Position: []float64{5, 5}, PostalCode: "78701"}
+	outside := &utilities.GeocodedAddress{Position: []float64{20, 20}, PostalCode: "78701"}
+	airportAddress := &utilities.GeocodedAddress{Position: []float64{20, 20}, PostalCode: "78719-1234"}
+
+	tests := []struct {
+		name        string
+		zones       []*model.PricingZone
+		pickup      *utilities.GeocodedAddress
+		destination *utilities.GeocodedAddress
+		want        *model.PricingZone
+	}{
+		{name: "both ends in the polygon", zones: []*model.PricingZone{downtown}, pickup: inside, destination: inside, want: downtown},
+		{name: "destination outside", zones: []*model.PricingZone{downtown}, pickup: inside, destination: outside},
+		{name: "ZIP+4 matches the ZIP code", zones: []*model.PricingZone{airport}, pickup: airportAddress, destination: airportAddress, want: airport},
+		{name: "higher priority wins", zones: []*model.PricingZone{downtown, overlap}, pickup: inside, destination: inside, want: overlap},
+		{name: "lowest ID breaks a tie", zones: []*model.PricingZone{downtown, tie}, pickup: inside, destination: inside, want: tie},
+		{name: "no geocode", zones: []*model.PricingZone{downtown}, pickup: nil, destination: inside},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchPricingZone(tt.zones, tt.pickup, tt.destination); got != tt.want {
+				t.Errorf("matchPricingZone() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePricingZone(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+
+	tests := []struct {
+		name    string
+		zone    *model.PricingZone
+		wantErr bool
+	}{
+		{name: "flat price by ZIP code", zone: &model.PricingZone{Name: str("Airport"), PostalCodes: []string{" 78719 "}, FlatPrice: cents(9000)}},
+		{name: "flat price and per-mile amount", zone: &model.PricingZone{Name: str("Airport"), PostalCodes: []string{"78719"}, FlatPrice: cents(9000), PerMileAmount: cents(300)}, wantErr: true},
+		{name: "no pricing", zone: &model.PricingZone{Name: str("Airport"), PostalCodes: []string{"78719"}}, wantErr: true},
+		{name: "no area", zone: &model.PricingZone{Name: str("Airport"), FlatPrice: cents(9000)}, wantErr: true},
+		{name: "bad ZIP code", zone: &model.PricingZone{Name: str("Airport"), PostalCodes: []string{"7871"}, PerMileAmount: cents(300)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePricingZone(tt.zone); (err != nil) != tt.wantErr {
+				t.Errorf("validatePricingZone() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdatePricingZoneReplacesTheZone(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	ctx := context.Background()
+
+	zones := &memoryPricingZones{}
+	if err := zones.Create(ctx, &model.PricingZone{
+		ID:          str("zone-1"),
+		CompanyID:   str("company-1"),
+		Name:        str("Downtown"),
+		Polygon:     &model.GeoJSONPolygon{Type: "Polygon", Coordinates: [][][]float64{{{0, 0}, {10, 0}, {10, 10}, {0, 0}}}},
+		PostalCodes: []string{"78701"},
+		FlatPrice:   cents(9000),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewPriceService(nil, nil, zones, nil, nil)
+
+	if err := svc.UpdatePricingZone(ctx, "company-1", "zone-1", &model.PricingZone{
+		CompanyID:     str("company-2"),
+		Name:          str("Downtown"),
+		PostalCodes:   []string{"78702"},
+		PerMileAmount: cents(400),
+	}); err != nil {
+		t.Fatalf("UpdatePricingZone() error = %v", err)
+	}
+
+	zone := zones.get("zone-1")
+	if stringValue(zone.CompanyID) != "company-1" {
+		t.Errorf("company = %q, want company-1", stringValue(zone.CompanyID))
+	}
+	if zone.FlatPrice != nil || zone.Polygon != nil {
+		t.Errorf("flat price %v and polygon %v kept after a replacement without them", zone.FlatPrice, zone.Polygon)
+	}
+	if intValue(zone.PerMileAmount) != 400 || len(zone.PostalCodes) != 1 || zone.PostalCodes[0] != "78702" {
+		t.Errorf("zone = %+v, want the new postal code and per-mile amount", zone)
+	}
+
+	if err := svc.UpdatePricingZone(ctx, "company-1", "zone-2", &model.PricingZone{Name: str("Nowhere"), PostalCodes: []string{"78702"}, FlatPrice: cents(1)}); err == nil {
+		t.Errorf("UpdatePricingZone() of a missing zone expected an error")
+	}
+}
+
+func TestPricingZonesOfAnotherCompany(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	ctx := context.Background()
+
+	zones := &memoryPricingZones{}
+	if err := zones.Create(ctx, &model.PricingZone{ID: str("zone-1"), CompanyID: str("company-1"), Name: str("Downtown"), PostalCodes: []string{"78701"}, FlatPrice: cents(9000)}); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewPriceService(nil, nil, zones, nil, nil)
+
+	err := svc.UpdatePricingZone(ctx, "company-2", "zone-1", &model.PricingZone{Name: str("Downtown"), PostalCodes: []string{"78701"}, FlatPrice: cents(1)})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("UpdatePricingZone() by another company error = %v, want not found", err)
+	}
+	if err := svc.DeletePricingZone(ctx, "company-2", "zone-1"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("DeletePricingZone() by another company error = %v, want not found", err)
+	}
+	if zone := zones.get("zone-1"); zone == nil || intValue(zone.FlatPrice) != 9000 {
+		t.Fatalf("zone = %+v, want it unchanged", zone)
+	}
+
+	if err := svc.DeletePricingZone(ctx, "company-1", "zone-1"); err != nil {
+		t.Fatalf("DeletePricingZone() error = %v", err)
+	}
+	if zones.get("zone-1") != nil {
+		t.Errorf("zone kept after its company deleted it")
+	}
+}
+
+type memoryPrices struct {
+	memoryStore[model.Price]
+}
+
+func (r *memoryPrices) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, doc := range r.docs {
+		if doc["_id"] == id {
+			r.docs = append(r.docs[:i], r.docs[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// newCatalog returns a price service over two companies' catalogs: company-1 has a hook-up fee and a mileage
+// rate, company-2 a hook-up fee of its own.
+func newCatalog(t *testing.T, tows ...*model.Tow) (*PriceService, *memoryPrices) {
+	t.Helper()
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	ctx := context.Background()
+
+	prices := &memoryPrices{}
+	for _, price := range []*model.Price{
+		{ID: str("hookup"), CompanyID: str("company-1"), ItemName: str("Hook-up"), Type: str(model.PriceTypeFlat), Amount: cents(7500)},
+		{ID: str("mileage"), CompanyID: str("company-1"), ItemName: str("Mileage"), Type: str(model.PriceTypePerMile), Amount: cents(300)},
+		{ID: str("other"), CompanyID: str("company-2"), ItemName: str("Hook-up"), Type: str(model.PriceTypeFlat), Amount: cents(9000)},
+	} {
+		if err := prices.Create(ctx, price); err != nil {
+			t.Fatal(err)
+		}
+	}
+	towStore := &memoryTows{}
+	for _, tow := range tows {
+		if err := towStore.Create(ctx, tow); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewPriceService(prices, nil, nil, towStore, nil), prices
+}
+
+func TestSetPrice(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+
+	tests := []struct {
+		name       string
+		prices     []*model.Price
+		wantErr    string
+		wantCount  int
+		wantHookUp int
+	}{
+		{
+			name:       "create a new item",
+			prices:     []*model.Price{{ItemName: str("Winch"), Type: str(model.PriceTypeFlat), Amount: cents(2000)}},
+			wantCount:  3,
+			wantHookUp: 7500,
+		},
+		{
+			name:       "update merges into the stored item",
+			prices:     []*model.Price{{ID: str("hookup"), Amount: cents(8000)}},
+			wantCount:  2,
+			wantHookUp: 8000,
+		},
+		{
+			name:    "update of another company's item",
+			prices:  []*model.Price{{ID: str("other"), Amount: cents(1)}},
+			wantErr: "not found",
+		},
+		{
+			name:    "price naming another company",
+			prices:  []*model.Price{{CompanyID: str("company-2"), ItemName: str("Winch"), Type: str(model.PriceTypeFlat), Amount: cents(2000)}},
+			wantErr: "belongs to another company",
+		},
+		{
+			name:    "new item with a taken name",
+			prices:  []*model.Price{{ItemName: str(" hook-UP "), Type: str(model.PriceTypeFlat), Amount: cents(2000)}},
+			wantErr: "duplicate item name",
+		},
+		{
+			name:    "rename onto a taken name",
+			prices:  []*model.Price{{ID: str("mileage"), ItemName: str("Hook-up")}},
+			wantErr: "duplicate item name",
+		},
+		{
+			name: "one invalid price saves nothing",
+			prices: []*model.Price{
+				{ID: str("hookup"), Amount: cents(8000)},
+				{ItemName: str("Winch"), Amount: cents(2000)},
+			},
+			wantErr: "type is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, prices := newCatalog(t)
+
+			err := svc.SetPrice(ctx, "company-1", tt.prices)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SetPrice() error = %v, want %q", err, tt.wantErr)
+				}
+				tt.wantCount, tt.wantHookUp = 2, 7500
+			} else if err != nil {
+				t.Fatalf("SetPrice() error = %v", err)
+			}
+
+			catalog, _ := svc.FindPricesByCompanyId(ctx, "company-1", true)
+			if len(catalog) != tt.wantCount {
+				t.Errorf("company-1 has %d prices, want %d", len(catalog), tt.wantCount)
+			}
+			hookUp := prices.get("hookup")
+			if intValue(hookUp.Amount) != tt.wantHookUp || stringValue(hookUp.ItemName) != "Hook-up" || stringValue(hookUp.Type) != model.PriceTypeFlat {
+				t.Errorf("hook-up = %+v, want Hook-up, flat, %d", hookUp, tt.wantHookUp)
+			}
+			if other := prices.get("other"); intValue(other.Amount) != 9000 {
+				t.Errorf("company-2's hook-up changed to %d", intValue(other.Amount))
+			}
+		})
+	}
+}
+
+func TestSetLegacyPrices(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	ctx := context.Background()
+	svc, prices := newCatalog(t)
+
+	if err := svc.SetLegacyPrices(ctx, []*model.Price{{ID: str("hookup"), Amount: cents(8000)}, {ID: str("other"), Amount: cents(9500)}}); err != nil {
+		t.Fatalf("SetLegacyPrices() error = %v", err)
+	}
+	if hookUp := prices.get("hookup"); intValue(hookUp.Amount) != 8000 || stringValue(hookUp.CompanyID) != "company-1" {
+		t.Errorf("hook-up = %+v, want 8000 for company-1", hookUp)
+	}
+	if other := prices.get("other"); intValue(other.Amount) != 9500 || stringValue(other.CompanyID) != "company-2" {
+		t.Errorf("other = %+v, want 9500 for company-2", other)
+	}
+
+	err := svc.SetLegacyPrices(ctx, []*model.Price{{ItemName: str("Winch"), Type: str(model.PriceTypeFlat), Amount: cents(2000)}})
+	if err == nil || !strings.Contains(err.Error(), "company id is required") {
+		t.Errorf("SetLegacyPrices() of a new price without a company error = %v", err)
+	}
+}
+
+func TestDeletePrice(t *testing.T) {
+	str := func(s string) *string { return &s }
+	charged := &model.Tow{
+		ID:        str("tow-1"),
+		CompanyID: str("company-1"),
+		LineItems: []model.PayableLineItem{{Name: "Mileage (10 miles at $3.00 per mile)", Amount: 3000, Quantity: 1}},
+	}
+
+	tests := []struct {
+		name         string
+		companyId    string
+		priceId      string
+		wantErr      string
+		wantArchived bool
+		wantStored   bool
+	}{
+		{name: "item charged on a tow is archived", companyId: "company-1", priceId: "mileage", wantArchived: true, wantStored: true},
+		{name: "unused item is deleted", companyId: "company-1", priceId: "hookup"},
+		{name: "another company's item", companyId: "company-1", priceId: "other", wantErr: "not found", wantStored: true},
+		{name: "no company", companyId: "", priceId: "hookup", wantErr: "company id is required", wantStored: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, prices := newCatalog(t, charged)
+
+			archived, err := svc.DeletePrice(context.Background(), tt.companyId, tt.priceId)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("DeletePrice() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("DeletePrice() error = %v", err)
+			}
+			if archived != tt.wantArchived {
+				t.Errorf("DeletePrice() archived = %v, want %v", archived, tt.wantArchived)
+			}
+
+			stored := prices.get(tt.priceId)
+			if (stored != nil) != tt.wantStored {
+				t.Fatalf("price stored = %v, want %v", stored != nil, tt.wantStored)
+			}
+			if stored != nil && (stored.Archived != nil && *stored.Archived) != tt.wantArchived {
+				t.Errorf("price archived flag = %v, want %v", stored.Archived, tt.wantArchived)
+			}
+		})
+	}
+}
+
+func TestSelectActivePriceList(t *testing.T) {
+	str := func(s string) *string { return &s }
+	version := func(v int) *int { return &v }
+	at := func(unix int64) *int64 { return &unix }
+	list := func(id string, status string, v int, effectiveFrom *int64) *model.PriceList {
+		return &model.PriceList{ID: str(id), Status: str(status), Version: version(v), EffectiveFrom: effectiveFrom}
+	}
+
+	v1 := list("v1", priceListStatusPublished, 1, at(1000))
+	v2 := list("v2", priceListStatusPublished, 2, at(2000))
+	v3 := list("v3", priceListStatusPublished, 3, at(2000))
+	future := list("v4", priceListStatusPublished, 4, at(5000))
+	draft := list("v5", priceListStatusDraft, 5, at(1500))
+	unscheduled := list("v6", priceListStatusPublished, 6, nil)
+
+	tests := []struct {
+		name  string
+		lists []*model.PriceList
+		at    int64
+		want  *model.PriceList
+	}{
+		{name: "nothing published", lists: []*model.PriceList{draft}, at: 3000},
+		{name: "before the first version takes effect", lists: []*model.PriceList{v1}, at: 999},
+		{name: "takes effect at its start", lists: []*model.PriceList{v1}, at: 1000, want: v1},
+		{name: "latest effective version wins", lists: []*model.PriceList{v2, v1}, at: 3000, want: v2},
+		{name: "earlier version until the next takes effect", lists: []*model.PriceList{v1, v2}, at: 1999, want: v1},
+		{name: "higher version wins a tie", lists: []*model.PriceList{v3, v2, v1}, at: 3000, want: v3},
+		{name: "scheduled version is ignored until it takes effect", lists: []*model.PriceList{v1, future}, at: 3000, want: v1},
+		{name: "drafts and unscheduled lists are ignored", lists: []*model.PriceList{v1, draft, unscheduled}, at: 3000, want: v1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectActivePriceList(tt.lists, tt.at); got != tt.want {
+				t.Errorf("selectActivePriceList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishPriceList(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	version := func(v int) *int { return &v }
+	complete := []*model.Price{
+		{ItemName: str("Hook-up"), Type: str(model.PriceTypeFlat), Amount: cents(7500)},
+		{ItemName: str("Mileage"), Type: str(model.PriceTypePerMile), Amount: cents(300)},
+	}
+
+	tests := []struct {
+		name          string
+		companyId     string
+		priceListId   string
+		effectiveFrom int64
+		wantErr       string
+	}{
+		{name: "draft takes effect now", companyId: "company-1", priceListId: "draft"},
+		{name: "draft scheduled for later", companyId: "company-1", priceListId: "draft", effectiveFrom: time.Now().Add(24 * time.Hour).Unix()},
+		{name: "another company's draft", companyId: "company-2", priceListId: "draft", wantErr: "not found"},
+		{name: "already published", companyId: "company-1", priceListId: "published", wantErr: "already published"},
+		{name: "incomplete draft", companyId: "company-1", priceListId: "incomplete", wantErr: "missing a flat hook-up fee"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			lists := &memoryStore[model.PriceList]{}
+			for _, list := range []*model.PriceList{
+				{ID: str("draft"), CompanyID: str("company-1"), Version: version(2), Status: str(priceListStatusDraft), Prices: complete},
+				{ID: str("published"), CompanyID: str("company-1"), Version: version(1), Status: str(priceListStatusPublished), Prices: complete},
+				{ID: str("incomplete"), CompanyID: str("company-1"), Version: version(3), Status: str(priceListStatusDraft), Prices: complete[1:]},
+			} {
+				if err := lists.Create(ctx, list); err != nil {
+					t.Fatal(err)
+				}
+			}
+			svc := NewPriceService(nil, lists, nil, nil, nil)
+
+			before := time.Now().Unix()
+			list, err := svc.PublishPriceList(ctx, tt.companyId, tt.priceListId, tt.effectiveFrom, "user-1")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("PublishPriceList() error = %v, want %q", err, tt.wantErr)
+				}
+				if stored := lists.get(tt.priceListId); tt.priceListId != "published" && stringValue(stored.Status) != priceListStatusDraft {
+					t.Errorf("status = %q after a refused publish, want draft", stringValue(stored.Status))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PublishPriceList() error = %v", err)
+			}
+
+			if stringValue(list.Status) != priceListStatusPublished || stringValue(list.PublishedBy) != "user-1" || list.PublishedAt == nil {
+				t.Errorf("list = %+v, want published by user-1", list)
+			}
+			switch {
+			case list.EffectiveFrom == nil:
+				t.Errorf("published list has no effective time")
+			case tt.effectiveFrom == 0 && *list.EffectiveFrom < before:
+				t.Errorf("effective from = %d, want now", *list.EffectiveFrom)
+			case tt.effectiveFrom != 0 && *list.EffectiveFrom != tt.effectiveFrom:
+				t.Errorf("effective from = %d, want %d", *list.EffectiveFrom, tt.effectiveFrom)
+			}
+
+			if _, err := svc.UpdatePriceListDraft(ctx, tt.companyId, tt.priceListId, complete, nil); err == nil || !strings.Contains(err.Error(), "only draft") {
+				t.Errorf("UpdatePriceListDraft() of a published list error = %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdatePriceListDraftOfAnotherCompany(t *testing.T) {
+	str := func(s string) *string { return &s }
+	notes := "raise the hook-up fee"
+	ctx := context.Background()
+
+	lists := &memoryStore[model.PriceList]{}
+	if err := lists.Create(ctx, &model.PriceList{ID: str("draft"), CompanyID: str("company-1"), Status: str(priceListStatusDraft)}); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewPriceService(nil, lists, nil, nil, nil)
+
+	if _, err := svc.UpdatePriceListDraft(ctx, "company-2", "draft", nil, &notes); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("UpdatePriceListDraft() error = %v, want not found", err)
+	}
+	if _, err := svc.FindPriceListById(ctx, "company-2", "draft"); err == nil {
+		t.Errorf("FindPriceListById() found another company's price list")
+	}
+	if list, err := svc.UpdatePriceListDraft(ctx, "company-1", "draft", nil, &notes); err != nil || stringValue(list.Notes) != notes {
+		t.Errorf("UpdatePriceListDraft() = %v, %v, want the notes saved", list, err)
+	}
+}
+
+func TestSimulatePriceList(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	distance := func(m float64) *float64 { return &m }
+	at := func(unix int64) *int64 { return &unix }
+	current := []*model.Price{
+		{ItemName: str("Hook Up"), Type: str(model.PriceTypeFlat), Amount: cents(8000)},
+		{ItemName: str("Per Mile"), Type: str(model.PriceTypePerMile), Amount: cents(300)},
+	}
+	raised := []*model.Price{
+		{ItemName: str("Hook Up"), Type: str(model.PriceTypeFlat), Amount: cents(9000)},
+		{ItemName: str("Per Mile"), Type: str(model.PriceTypePerMile), Amount: cents(300)},
+	}
+	const from, to, booked = 1772600000, 1772700000, 1772625600
+
+	tests := []struct {
+		name            string
+		priceListId     string
+		prices          []*model.Price
+		from            int64
+		wantErr         string
+		wantTows        []string
+		wantOldRevenue  int64
+		wantNewRevenue  int64
+		wantDeltaPct    float64
+		wantPriceListID string
+	}{
+		{
+			name:           "inline prices",
+			prices:         current,
+			from:           from,
+			wantTows:       []string{"no-completed-at", "completed", "no-route"},
+			wantOldRevenue: 24000,
+			wantNewRevenue: 25000,
+			wantDeltaPct:   4.17,
+		},
+		{
+			name:            "the company's draft",
+			priceListId:     "draft",
+			from:            from,
+			wantTows:        []string{"no-completed-at", "completed", "no-route"},
+			wantOldRevenue:  24000,
+			wantNewRevenue:  27000,
+			wantDeltaPct:    12.5,
+			wantPriceListID: "draft",
+		},
+		{name: "another company's draft", priceListId: "other", from: from, wantErr: "not found"},
+		{name: "no prices", from: from, wantErr: "price list or prices are required"},
+		{name: "window ends before it starts", prices: current, from: to, wantErr: "from must be before to"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			companies := &memoryStore[model.Company]{}
+			lists := &memoryStore[model.PriceList]{}
+			tows := &memoryStore[model.Tow]{}
+			for _, company := range []*model.Company{{ID: str("company-1"), Timezone: str("UTC")}, {ID: str("company-2")}} {
+				if err := companies.Create(ctx, company); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, list := range []*model.PriceList{
+				{ID: str("draft"), CompanyID: str("company-1"), Status: str(priceListStatusDraft), Prices: raised},
+				{ID: str("other"), CompanyID: str("company-2"), Status: str(priceListStatusDraft), Prices: raised},
+			} {
+				if err := lists.Create(ctx, list); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, tow := range []*model.Tow{
+				{ID: str("completed"), CompanyID: str("company-1"), Status: str("Completed"), Price: cents(12000), Miles: distance(10), Minutes: distance(20), CreatedAt: at(booked), CompletedAt: at(booked + 3600)},
+				{ID: str("no-completed-at"), CompanyID: str("company-1"), Status: str("COMPLETED"), Price: cents(12000), Miles: distance(20), Minutes: distance(30), CreatedAt: at(booked)},
+				{ID: str("no-route"), CompanyID: str("company-1"), Status: str("Completed"), Price: cents(9000), CreatedAt: at(booked), CompletedAt: at(booked + 7200)},
+				{ID: str("after-the-window"), CompanyID: str("company-1"), Status: str("Completed"), Price: cents(12000), Miles: distance(10), Minutes: distance(20), CreatedAt: at(booked), CompletedAt: at(to)},
+				{ID: str("scheduled"), CompanyID: str("company-1"), Status: str("Scheduled"), Price: cents(12000), Miles: distance(10), Minutes: distance(20), CreatedAt: at(booked)},
+				{ID: str("other-company"), CompanyID: str("company-2"), Status: str("Completed"), Price: cents(12000), Miles: distance(10), Minutes: distance(20), CreatedAt: at(booked), CompletedAt: at(booked + 3600)},
+			} {
+				if err := tows.Create(ctx, tow); err != nil {
+					t.Fatal(err)
+				}
+			}
+			svc := NewPriceService(nil, lists, &memoryPricingZones{}, tows, companies)
+
+			simulation, err := svc.SimulatePriceList(ctx, "company-1", tt.priceListId, tt.prices, tt.from, to)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SimulatePriceList() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SimulatePriceList() error = %v", err)
+			}
+
+			var ids []string
+			for _, tow := range simulation.Tows {
+				ids = append(ids, stringValue(tow.TowID))
+			}
+			if strings.Join(ids, "|") != strings.Join(tt.wantTows, "|") {
+				t.Errorf("simulated tows = %q, want %q", ids, tt.wantTows)
+			}
+			if simulation.TowCount != 2 || simulation.SkippedCount != 1 {
+				t.Errorf("%d tows repriced and %d skipped, want 2 and 1", simulation.TowCount, simulation.SkippedCount)
+			}
+			if skipped := simulation.Tows[len(simulation.Tows)-1].Skipped; stringValue(skipped) != "tow has no stored route" {
+				t.Errorf("no-route skipped = %q", stringValue(skipped))
+			}
+			if simulation.OldRevenue != tt.wantOldRevenue || simulation.NewRevenue != tt.wantNewRevenue {
+				t.Errorf("revenue %d -> %d, want %d -> %d", simulation.OldRevenue, simulation.NewRevenue, tt.wantOldRevenue, tt.wantNewRevenue)
+			}
+			if simulation.RevenueDelta != tt.wantNewRevenue-tt.wantOldRevenue || simulation.DeltaPercent != tt.wantDeltaPct {
+				t.Errorf("delta = %d (%.2f%%), want %d (%.2f%%)", simulation.RevenueDelta, simulation.DeltaPercent, tt.wantNewRevenue-tt.wantOldRevenue, tt.wantDeltaPct)
+			}
+			if stringValue(simulation.PriceListID) != tt.wantPriceListID {
+				t.Errorf("price list = %q, want %q", stringValue(simulation.PriceListID), tt.wantPriceListID)
+			}
+		})
+	}
+}
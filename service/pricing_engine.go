@@ -0,0 +1,744 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"tow-management-system-api/model"
+)
+
+// Item names used by price lists created before prices carried a type.
+const (
+	legacyHookUpFeeName = "Hook Up Fee"
+	legacyPerMileName   = "Per Mile Amount"
+)
+
+// priceTypeOrder fixes the order in which rule types are evaluated and itemized.
+var priceTypeOrder = map[string]int{
+	model.PriceTypeFlat:       0,
+	model.PriceTypePerMile:    1,
+	model.PriceTypePerMinute:  2,
+	model.PriceTypeEnRoute:    3,
+	model.PriceTypeReturn:     4,
+	model.PriceTypePercentage: 5,
+	model.PriceTypeMinimum:    6,
+}
+
+// defaultCompanyTimezone is used for time-based prices when a company has not set a timezone.
+const defaultCompanyTimezone = "America/New_York"
+
+// weekdayNames maps TimeWindow day names to time.Weekday.
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// pricingInput describes the trip being priced.
+type pricingInput struct {
+	Miles float64
+	// BilledMiles is Miles after the company's mileage rounding; every distance-based rule uses it
+	BilledMiles float64
+	Minutes     float64
+	// EnRouteMiles (yard to pickup) and ReturnMiles (destination to yard) are routed distances, only set when
+	// the company charges them; they are rounded with MileageRounding when priced
+	EnRouteMiles    float64
+	ReturnMiles     float64
+	MileageRounding string
+	// At is the request or scheduled time, already converted to the company's timezone
+	At       time.Time
+	Holidays map[string]struct{}
+	// VehicleClass is the resolved class of the towed vehicle
+	VehicleClass string
+	AddOns       map[string]struct{}
+	// TaxRate is the sales tax percent for the pickup location, applied to taxable items
+	TaxRate float64
+	TaxName string
+	// Promo is a validated promo code to discount the pre-tax total with
+	Promo *model.PromoCode
+	// Zone is the pricing zone containing both ends of the trip, if any
+	Zone *model.PricingZone
+}
+
+// minimumChargeAfterDiscount is the smallest pre-tax total a discount may leave; card payments need a positive charge.
+const minimumChargeAfterDiscount = 50
+
+// newPricingInput builds the input for a tow's trip, evaluated in the company's timezone against the
+// company's holiday calendar. Time-based rules use the tow's scheduled time when set, otherwise at.
+func newPricingInput(company *model.Company, tow *model.Tow, miles, minutes float64, at time.Time) (pricingInput, error) {
+	location, err := companyLocation(company)
+	if err != nil {
+		return pricingInput{}, err
+	}
+
+	billedMiles, err := roundMiles(miles, stringValue(company.MileageRounding))
+	if err != nil {
+		return pricingInput{}, err
+	}
+
+	holidays := map[string]struct{}{}
+	for _, holiday := range company.Holidays {
+		holidays[holiday] = struct{}{}
+	}
+
+	if tow.ScheduledAt != nil {
+		at = time.Unix(*tow.ScheduledAt, 0)
+	}
+
+	addOns := map[string]struct{}{}
+	for _, addOn := range normalizeAddOns(tow.AddOns) {
+		addOns[addOn] = struct{}{}
+	}
+
+	return pricingInput{
+		Miles:           miles,
+		BilledMiles:     billedMiles,
+		Minutes:         minutes,
+		MileageRounding: stringValue(company.MileageRounding),
+		At:              at.In(location),
+		Holidays:        holidays,
+		VehicleClass:    resolveVehicleClass(tow.Vehicle),
+		AddOns:          addOns,
+	}, nil
+}
+
+// roundMiles applies a company's mileage rounding rule to a routed distance. Rounding is always up,
+// and tolerates floating point noise so that 12.0000001 miles bills as 12.
+func roundMiles(miles float64, rule string) (float64, error) {
+	const epsilon = 1e-6
+
+	switch rule {
+	case "", model.MileageRoundingExact:
+		return miles, nil
+	case model.MileageRoundingTenth:
+		return math.Ceil(miles*10-epsilon) / 10, nil
+	case model.MileageRoundingWhole:
+		return math.Ceil(miles - epsilon), nil
+	default:
+		return 0, fmt.Errorf("unknown mileage rounding rule %q", rule)
+	}
+}
+
+// companyLocation loads the company's timezone, falling back to defaultCompanyTimezone.
+func companyLocation(company *model.Company) (*time.Location, error) {
+	name := defaultCompanyTimezone
+	if company != nil && company.Timezone != nil && *company.Timezone != "" {
+		name = *company.Timezone
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid company timezone %q: %w", name, err)
+	}
+
+	return location, nil
+}
+
+// pricingRule is a validated price with its type resolved.
+type pricingRule struct {
+	price    *model.Price
+	ruleType string
+	name     string
+}
+
+// calculatePrice evaluates a company's price list against a trip and returns an itemized breakdown.
+// The result only depends on the rules and the input (never on the order the rules were loaded in),
+// and returns an error when the price list cannot produce a complete price.
+func calculatePrice(prices []*model.Price, input pricingInput) (*model.PriceBreakdown, error) {
+	rules, err := resolvePricingRules(prices)
+	if err != nil {
+		return nil, err
+	}
+
+	rules = selectApplicableRules(rules, input)
+	if input.Zone != nil {
+		rules = applyPricingZone(rules, input.Zone)
+	}
+
+	breakdown := &model.PriceBreakdown{
+		LineItems:    []model.PayableLineItem{},
+		Miles:        input.Miles,
+		BilledMiles:  input.BilledMiles,
+		EnRouteMiles: input.EnRouteMiles,
+		ReturnMiles:  input.ReturnMiles,
+		Minutes:      input.Minutes,
+		PricedAt:     input.At.Unix(),
+	}
+
+	var subtotal int64
+	var taxableSubtotal int64
+	var minimum int64
+	var minimumRule pricingRule
+	var percentageRules []pricingRule
+
+	for _, rule := range rules {
+		var amount int64
+		if rule.price.Amount != nil {
+			amount = int64(*rule.price.Amount)
+		}
+
+		var cost int64
+		switch rule.ruleType {
+		case model.PriceTypeFlat:
+			cost = amount
+			addLineItem(breakdown, rule.name, cost)
+		case model.PriceTypePerMile:
+			cost = addMileageLineItems(breakdown, rule, input.BilledMiles)
+		case model.PriceTypeEnRoute, model.PriceTypeReturn:
+			miles := input.EnRouteMiles
+			if rule.ruleType == model.PriceTypeReturn {
+				miles = input.ReturnMiles
+			}
+			billed, err := roundMiles(miles, input.MileageRounding)
+			if err != nil {
+				return nil, err
+			}
+			cost = addMileageLineItems(breakdown, rule, billed)
+		case model.PriceTypePerMinute:
+			cost = int64(math.Round(float64(amount) * input.Minutes))
+			addLineItem(breakdown, fmt.Sprintf("%s (%.0f minutes at $%.2f per minute)", rule.name, input.Minutes, float64(amount)/100), cost)
+		case model.PriceTypePercentage:
+			percentageRules = append(percentageRules, rule)
+		case model.PriceTypeMinimum:
+			if amount > minimum {
+				minimum = amount
+				minimumRule = rule
+			}
+		}
+
+		subtotal += cost
+		if isTaxable(rule) {
+			taxableSubtotal += cost
+		}
+	}
+
+	// Percentages apply to the subtotal of the flat and rate-based items, never to each other
+	for _, rule := range percentageRules {
+		cost := int64(math.Round(float64(subtotal) * *rule.price.Percent / 100))
+		addLineItem(breakdown, fmt.Sprintf("%s (%g%%)", rule.name, *rule.price.Percent), cost)
+		if isTaxable(rule) {
+			taxableSubtotal += cost
+		}
+	}
+
+	if breakdown.Total < minimum {
+		adjustment := minimum - breakdown.Total
+		addLineItem(breakdown, "Minimum Charge Adjustment", adjustment)
+		if isTaxable(minimumRule) {
+			taxableSubtotal += adjustment
+		}
+	}
+
+	if breakdown.Total <= 0 {
+		return nil, fmt.Errorf("price list produced a total of zero")
+	}
+
+	if input.Promo != nil {
+		discount, err := promoDiscount(input.Promo, breakdown.Total)
+		if err != nil {
+			return nil, err
+		}
+		if discount > 0 {
+			// The discount reduces the tax base in proportion to the taxable share of the total
+			taxableSubtotal -= int64(math.Round(float64(discount) * float64(taxableSubtotal) / float64(breakdown.Total)))
+			addLineItem(breakdown, fmt.Sprintf("Promo %s", stringValue(input.Promo.Code)), -discount)
+			breakdown.Discount = discount
+		}
+	}
+
+	// Sales tax is itemized last so it is charged on the final pre-tax amounts
+	if input.TaxRate > 0 && taxableSubtotal > 0 {
+		tax := int64(math.Round(float64(taxableSubtotal) * input.TaxRate / 100))
+		name := input.TaxName
+		if name == "" {
+			name = "Sales Tax"
+		}
+		addLineItem(breakdown, fmt.Sprintf("%s (%g%%)", name, input.TaxRate), tax)
+		breakdown.TaxRate = input.TaxRate
+		breakdown.TaxAmount = tax
+	}
+
+	return breakdown, nil
+}
+
+// promoDiscount returns the discount a promo code gives on a pre-tax total. A discount never takes the
+// total below minimumChargeAfterDiscount.
+func promoDiscount(promo *model.PromoCode, total int64) (int64, error) {
+	if promo.MinOrder != nil && total < int64(*promo.MinOrder) {
+		return 0, fmt.Errorf("promo code requires a minimum order of $%s", formatCents(*promo.MinOrder))
+	}
+
+	var discount int64
+	switch stringValue(promo.Type) {
+	case model.PromoTypePercentage:
+		if promo.Percent != nil {
+			discount = int64(math.Round(float64(total) * *promo.Percent / 100))
+		}
+	case model.PromoTypeFixed:
+		discount = int64(intValue(promo.Amount))
+	}
+
+	if limit := total - minimumChargeAfterDiscount; discount > limit {
+		discount = max(limit, 0)
+	}
+
+	return discount, nil
+}
+
+// isTaxable reports whether a rule's charges are included in the sales tax base.
+func isTaxable(rule pricingRule) bool {
+	return rule.price != nil && rule.price.Taxable != nil && *rule.price.Taxable
+}
+
+// applyPricingZone replaces the unconditional base charges with a zone's pricing. A zone flat price replaces
+// the hook-up fee and the distance and time rates; a zone per-mile amount only replaces the per-mile rate.
+// Conditional rules such as surcharges and add-ons, percentages and minimums still apply.
+func applyPricingZone(rules []pricingRule, zone *model.PricingZone) []pricingRule {
+	result := make([]pricingRule, 0, len(rules)+1)
+
+	if zone.FlatPrice != nil {
+		name := fmt.Sprintf("%s Zone Rate", stringValue(zone.Name))
+		result = append(result, pricingRule{
+			price:    &model.Price{ID: zone.ID, ItemName: &name, Amount: zone.FlatPrice},
+			ruleType: model.PriceTypeFlat,
+			name:     name,
+		})
+	}
+
+	for _, rule := range rules {
+		isBase := conditionSpecificity(rule.price.Condition) == 0
+		switch {
+		case zone.FlatPrice != nil && isBase &&
+			(rule.ruleType == model.PriceTypeFlat || rule.ruleType == model.PriceTypePerMile || rule.ruleType == model.PriceTypePerMinute):
+			continue
+		case zone.PerMileAmount != nil && isBase && rule.ruleType == model.PriceTypePerMile:
+			price := *rule.price
+			price.Amount = zone.PerMileAmount
+			price.Tiers = nil
+			rule.price = &price
+		}
+		result = append(result, rule)
+	}
+
+	return result
+}
+
+// mileageBand is a stretch of billed miles charged at a single rate.
+type mileageBand struct {
+	miles float64
+	rate  int64
+}
+
+// mileageBands splits a trip's billed miles into the bands of a per-mile rule. Included miles are
+// taken off the start of the trip; tiers are counted from mile zero so "first 5 included, $4 up
+// to 50, $3 after" reads the same as it does on a rate sheet.
+func mileageBands(price *model.Price, miles float64) []mileageBand {
+	included := 0.0
+	if price.IncludedMiles != nil {
+		included = *price.IncludedMiles
+	}
+
+	if len(price.Tiers) == 0 {
+		var rate int64
+		if price.Amount != nil {
+			rate = int64(*price.Amount)
+		}
+		return []mileageBand{{miles: math.Max(miles-included, 0), rate: rate}}
+	}
+
+	var bands []mileageBand
+	previous := 0.0
+	for i, tier := range price.Tiers {
+		upTo := math.Inf(1)
+		if tier.UpToMiles != nil && i < len(price.Tiers)-1 {
+			upTo = *tier.UpToMiles
+		}
+
+		// Miles in this tier, less any included miles that fall inside it
+		start := math.Max(previous, included)
+		end := math.Min(upTo, miles)
+		if end > start {
+			bands = append(bands, mileageBand{miles: end - start, rate: int64(*tier.Amount)})
+		}
+
+		previous = upTo
+		if previous >= miles {
+			break
+		}
+	}
+
+	return bands
+}
+
+// addMileageLineItems itemizes a per-mile rule, one line item per band, and returns the amount added.
+func addMileageLineItems(b *model.PriceBreakdown, rule pricingRule, miles float64) int64 {
+	var added int64
+	for _, band := range mileageBands(rule.price, miles) {
+		cost := int64(math.Round(float64(band.rate) * band.miles))
+		addLineItem(b, fmt.Sprintf("%s (%s miles at $%.2f per mile)", rule.name, formatMiles(band.miles), float64(band.rate)/100), cost)
+		added += cost
+	}
+	return added
+}
+
+// formatMiles prints a distance with as many decimals as it needs, up to two.
+func formatMiles(miles float64) string {
+	return strconv.FormatFloat(math.Round(miles*100)/100, 'f', -1, 64)
+}
+
+// addLineItem appends a line item and keeps the total in sync. Zero-amount items are omitted.
+func addLineItem(b *model.PriceBreakdown, name string, amount int64) {
+	if amount == 0 {
+		return
+	}
+	b.LineItems = append(b.LineItems, model.PayableLineItem{Name: name, Amount: amount, Quantity: 1})
+	b.Total += amount
+}
+
+// resolvePricingRules validates a price list, resolves each rule's type and checks that the list
+// contains the base charge and the distance or time rate every tow needs.
+func resolvePricingRules(prices []*model.Price) ([]pricingRule, error) {
+	if len(prices) == 0 {
+		return nil, fmt.Errorf("company has no prices configured")
+	}
+
+	var rules []pricingRule
+	hasBaseCharge := false
+	hasRate := false
+
+	for _, price := range prices {
+		if price == nil {
+			continue
+		}
+
+		name := stringValue(price.ItemName)
+		ruleType := stringValue(price.Type)
+		if ruleType == "" {
+			switch name {
+			case legacyHookUpFeeName:
+				ruleType = model.PriceTypeFlat
+			case legacyPerMileName:
+				ruleType = model.PriceTypePerMile
+			default:
+				log.Printf("skipping untyped price %q (%s)", name, stringValue(price.ID))
+				continue
+			}
+		}
+
+		if err := validatePricingRule(price, ruleType); err != nil {
+			return nil, fmt.Errorf("price %q: %w", name, err)
+		}
+
+		if conditionSpecificity(price.Condition) == 0 {
+			switch ruleType {
+			case model.PriceTypeFlat:
+				hasBaseCharge = true
+			case model.PriceTypePerMile, model.PriceTypePerMinute:
+				hasRate = true
+			}
+		}
+
+		rules = append(rules, pricingRule{price: price, ruleType: ruleType, name: name})
+	}
+
+	if !hasBaseCharge {
+		return nil, fmt.Errorf("company price list is incomplete: missing a flat hook-up fee")
+	}
+	if !hasRate {
+		return nil, fmt.Errorf("company price list is incomplete: missing a per-mile or per-minute rate")
+	}
+
+	return rules, nil
+}
+
+// validatePricingRule checks that a price carries the fields its type needs.
+func validatePricingRule(price *model.Price, ruleType string) error {
+	if _, ok := priceTypeOrder[ruleType]; !ok {
+		return fmt.Errorf("unknown price type %q", ruleType)
+	}
+	if price.ItemName == nil || *price.ItemName == "" {
+		return fmt.Errorf("item name is required")
+	}
+	if price.Condition != nil {
+		for _, window := range price.Condition.TimeWindows {
+			if _, _, err := parseTimeWindow(window); err != nil {
+				return err
+			}
+			for _, day := range window.Days {
+				if _, ok := weekdayNames[strings.ToLower(day)]; !ok {
+					return fmt.Errorf("unknown day %q in time window", day)
+				}
+			}
+		}
+		for _, class := range price.Condition.VehicleClasses {
+			if !isValidVehicleClass(class) {
+				return fmt.Errorf("unknown vehicle class %q", class)
+			}
+		}
+	}
+
+	if price.IncludedMiles != nil && *price.IncludedMiles < 0 {
+		return fmt.Errorf("included miles must be zero or greater")
+	}
+	if len(price.Tiers) > 0 {
+		if !isPerMileType(ruleType) {
+			return fmt.Errorf("tiers are only supported on per-mile prices")
+		}
+		return validateMileageTiers(price.Tiers)
+	}
+
+	if ruleType == model.PriceTypePercentage {
+		if price.Percent == nil || *price.Percent < 0 {
+			return fmt.Errorf("percent must be zero or greater")
+		}
+		return nil
+	}
+
+	if price.Amount == nil || *price.Amount < 0 {
+		return fmt.Errorf("amount must be zero or greater")
+	}
+
+	return nil
+}
+
+// isPerMileType reports whether a rule type is charged per mile and supports included miles and tiers.
+func isPerMileType(ruleType string) bool {
+	return ruleType == model.PriceTypePerMile || ruleType == model.PriceTypeEnRoute || ruleType == model.PriceTypeReturn
+}
+
+// usesYardMiles reports whether any rule in a price list charges en-route or return miles, which need extra routes.
+func usesYardMiles(prices []*model.Price) bool {
+	for _, price := range prices {
+		if price == nil {
+			continue
+		}
+		if ruleType := stringValue(price.Type); ruleType == model.PriceTypeEnRoute || ruleType == model.PriceTypeReturn {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMileageTiers checks that tier rates are set and tier limits strictly increase. Only the last
+// tier may be open-ended.
+func validateMileageTiers(tiers []model.MileageTier) error {
+	previous := 0.0
+	for i, tier := range tiers {
+		if tier.Amount == nil || *tier.Amount < 0 {
+			return fmt.Errorf("tier %d: amount must be zero or greater", i+1)
+		}
+		if tier.UpToMiles == nil {
+			if i != len(tiers)-1 {
+				return fmt.Errorf("tier %d: only the last tier may omit upToMiles", i+1)
+			}
+			continue
+		}
+		if *tier.UpToMiles <= previous {
+			return fmt.Errorf("tier %d: upToMiles must be greater than the previous tier", i+1)
+		}
+		previous = *tier.UpToMiles
+	}
+	return nil
+}
+
+// selectApplicableRules drops rules whose condition does not match the trip. When several applicable rules
+// share an item name and type, only the most specific one is kept. The result is sorted deterministically.
+func selectApplicableRules(rules []pricingRule, input pricingInput) []pricingRule {
+	selected := map[string]pricingRule{}
+
+	for _, rule := range rules {
+		if !conditionApplies(rule.price.Condition, input) {
+			continue
+		}
+
+		key := rule.ruleType + "|" + rule.name
+		current, ok := selected[key]
+		if !ok || moreSpecific(rule, current) {
+			selected[key] = rule
+		}
+	}
+
+	result := make([]pricingRule, 0, len(selected))
+	for _, rule := range selected {
+		result = append(result, rule)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if priceTypeOrder[result[i].ruleType] != priceTypeOrder[result[j].ruleType] {
+			return priceTypeOrder[result[i].ruleType] < priceTypeOrder[result[j].ruleType]
+		}
+		// Unconditional charges come before conditional ones such as surcharges
+		specificityI := conditionSpecificity(result[i].price.Condition)
+		specificityJ := conditionSpecificity(result[j].price.Condition)
+		if specificityI != specificityJ {
+			return specificityI < specificityJ
+		}
+		return result[i].name < result[j].name
+	})
+
+	return result
+}
+
+// moreSpecific reports whether rule a should win over rule b. Ties are broken by price ID so the choice is stable.
+func moreSpecific(a, b pricingRule) bool {
+	specificityA := conditionSpecificity(a.price.Condition)
+	specificityB := conditionSpecificity(b.price.Condition)
+	if specificityA != specificityB {
+		return specificityA > specificityB
+	}
+	return stringValue(a.price.ID) < stringValue(b.price.ID)
+}
+
+// conditionSpecificity counts how many dimensions a condition restricts.
+func conditionSpecificity(condition *model.PriceCondition) int {
+	if condition == nil {
+		return 0
+	}
+
+	specificity := 0
+	if condition.MinMiles != nil {
+		specificity++
+	}
+	if condition.MaxMiles != nil {
+		specificity++
+	}
+	if len(condition.TimeWindows) > 0 {
+		specificity++
+	}
+	if condition.Holiday != nil && *condition.Holiday {
+		specificity++
+	}
+	if len(condition.VehicleClasses) > 0 {
+		specificity++
+	}
+	if len(condition.AddOns) > 0 {
+		specificity++
+	}
+
+	return specificity
+}
+
+// conditionApplies reports whether a rule's condition matches the trip.
+func conditionApplies(condition *model.PriceCondition, input pricingInput) bool {
+	if condition == nil {
+		return true
+	}
+	if condition.MinMiles != nil && input.BilledMiles < *condition.MinMiles {
+		return false
+	}
+	if condition.MaxMiles != nil && input.BilledMiles >= *condition.MaxMiles {
+		return false
+	}
+	if condition.Holiday != nil && *condition.Holiday {
+		if _, ok := input.Holidays[input.At.Format("2006-01-02")]; !ok {
+			return false
+		}
+	}
+	if len(condition.VehicleClasses) > 0 && !containsFold(condition.VehicleClasses, input.VehicleClass) {
+		return false
+	}
+	if len(condition.AddOns) > 0 {
+		usesAddOn := false
+		for _, addOn := range condition.AddOns {
+			if _, ok := input.AddOns[strings.ToLower(addOn)]; ok {
+				usesAddOn = true
+				break
+			}
+		}
+		if !usesAddOn {
+			return false
+		}
+	}
+	if len(condition.TimeWindows) > 0 {
+		inWindow := false
+		for _, window := range condition.TimeWindows {
+			if timeWindowContains(window, input.At) {
+				inWindow = true
+				break
+			}
+		}
+		if !inWindow {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold reports whether values contains target, ignoring case.
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// timeWindowContains reports whether t (in the company's timezone) falls inside the window.
+// For windows that run past midnight, the early-morning part belongs to the previous day.
+func timeWindowContains(window model.TimeWindow, t time.Time) bool {
+	start, end, err := parseTimeWindow(window)
+	if err != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	yesterday := (today + 6) % 7
+
+	if start <= end {
+		return minute >= start && minute < end && windowIncludesDay(window, today)
+	}
+
+	// Overnight window, e.g. 18:00-06:00
+	if minute >= start && windowIncludesDay(window, today) {
+		return true
+	}
+	return minute < end && windowIncludesDay(window, yesterday)
+}
+
+// windowIncludesDay reports whether the window is active on the given weekday.
+func windowIncludesDay(window model.TimeWindow, day time.Weekday) bool {
+	if len(window.Days) == 0 {
+		return true
+	}
+	for _, name := range window.Days {
+		if weekday, ok := weekdayNames[strings.ToLower(name)]; ok && weekday == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTimeWindow returns the window's start and end as minutes after midnight.
+func parseTimeWindow(window model.TimeWindow) (int, int, error) {
+	start, err := parseClock(stringValue(window.Start))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time window start: %w", err)
+	}
+	end, err := parseClock(stringValue(window.End))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time window end: %w", err)
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("time window start and end must differ")
+	}
+	return start, end, nil
+}
+
+// parseClock converts HH:MM into minutes after midnight.
+func parseClock(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
@@ -0,0 +1,433 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"tow-management-system-api/model"
+)
+
+func TestMileageBands(t *testing.T) {
+	cents := func(c int) *int { return &c }
+	miles := func(m float64) *float64 { return &m }
+	tiered := &model.Price{Tiers: []model.MileageTier{
+		{UpToMiles: miles(10), Amount: cents(400)},
+		{UpToMiles: miles(50), Amount: cents(300)},
+		{Amount: cents(200)},
+	}}
+
+	tests := []struct {
+		name  string
+		price *model.Price
+		miles float64
+		want  []mileageBand
+	}{
+		{name: "flat rate", price: &model.Price{Amount: cents(350)}, miles: 12, want: []mileageBand{{miles: 12, rate: 350}}},
+		{name: "flat rate inside the included miles", price: &model.Price{Amount: cents(350), IncludedMiles: miles(5)}, miles: 3, want: []mileageBand{{miles: 0, rate: 350}}},
+		{name: "flat rate past the included miles", price: &model.Price{Amount: cents(350), IncludedMiles: miles(5)}, miles: 8, want: []mileageBand{{miles: 3, rate: 350}}},
+		{name: "inside the first tier", price: tiered, miles: 7, want: []mileageBand{{miles: 7, rate: 400}}},
+		{name: "exactly at a tier edge", price: tiered, miles: 10, want: []mileageBand{{miles: 10, rate: 400}}},
+		{name: "just past a tier edge", price: tiered, miles: 10.5, want: []mileageBand{{miles: 10, rate: 400}, {miles: 0.5, rate: 300}}},
+		{name: "open-ended last tier", price: tiered, miles: 60, want: []mileageBand{{miles: 10, rate: 400}, {miles: 40, rate: 300}, {miles: 10, rate: 200}}},
+		{
+			name:  "included miles reach into the second tier",
+			price: &model.Price{IncludedMiles: miles(15), Tiers: tiered.Tiers},
+			miles: 20,
+			want:  []mileageBand{{miles: 5, rate: 300}},
+		},
+		{
+			name:  "last tier limit is ignored",
+			price: &model.Price{Tiers: []model.MileageTier{{UpToMiles: miles(10), Amount: cents(400)}, {UpToMiles: miles(20), Amount: cents(300)}}},
+			miles: 30,
+			want:  []mileageBand{{miles: 10, rate: 400}, {miles: 20, rate: 300}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mileageBands(tt.price, tt.miles)
+			if len(got) != len(tt.want) {
+				t.Fatalf("mileageBands() = %+v, want %+v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("mileageBands()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRoundMiles(t *testing.T) {
+	tests := []struct {
+		miles float64
+		rule  string
+		want  float64
+	}{
+		{miles: 12.34, rule: "", want: 12.34},
+		{miles: 12.34, rule: model.MileageRoundingExact, want: 12.34},
+		{miles: 12.31, rule: model.MileageRoundingTenth, want: 12.4},
+		{miles: 12.0000001, rule: model.MileageRoundingWhole, want: 12},
+		{miles: 12.01, rule: model.MileageRoundingWhole, want: 13},
+	}
+	for _, tt := range tests {
+		got, err := roundMiles(tt.miles, tt.rule)
+		if err != nil || got != tt.want {
+			t.Errorf("roundMiles(%v, %q) = %v, %v; want %v", tt.miles, tt.rule, got, err, tt.want)
+		}
+	}
+
+	if _, err := roundMiles(1, "quarter_mile"); err == nil {
+		t.Error("roundMiles() accepted an unknown rule")
+	}
+}
+
+func TestSelectApplicableRules(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	miles := func(m float64) *float64 { return &m }
+	yes := true
+	price := func(id, name, ruleType string, amount int, condition *model.PriceCondition) *model.Price {
+		return &model.Price{ID: str(id), ItemName: str(name), Type: str(ruleType), Amount: cents(amount), Condition: condition}
+	}
+
+	prices := []*model.Price{
+		price("p1", "Hook Up Fee", model.PriceTypeFlat, 7500, nil),
+		price("p2", "Hook Up Fee", model.PriceTypeFlat, 12500, &model.PriceCondition{VehicleClasses: []string{model.VehicleClassHeavy}}),
+		price("p3", "Hook Up Fee", model.PriceTypeFlat, 15000, &model.PriceCondition{VehicleClasses: []string{model.VehicleClassHeavy}, Holiday: &yes}),
+		price("p4", "Per Mile", model.PriceTypePerMile, 400, nil),
+		price("p5", "Long Distance", model.PriceTypeFlat, 2000, &model.PriceCondition{MinMiles: miles(50)}),
+		price("p6", "Short Trip", model.PriceTypeFlat, 1000, &model.PriceCondition{MaxMiles: miles(5)}),
+		price("p7", "Night Surcharge", model.PriceTypeFlat, 2500, &model.PriceCondition{TimeWindows: []model.TimeWindow{{Start: str("18:00"), End: str("06:00")}}}),
+	}
+	rules, err := resolvePricingRules(prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noon := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		input pricingInput
+		want  []string
+	}{
+		{name: "base rules", input: pricingInput{BilledMiles: 10, At: noon, VehicleClass: model.VehicleClassLight}, want: []string{"p1", "p4"}},
+		{name: "vehicle class wins over the base fee", input: pricingInput{BilledMiles: 10, At: noon, VehicleClass: model.VehicleClassHeavy}, want: []string{"p2", "p4"}},
+		{
+			name:  "most specific condition wins",
+			input: pricingInput{BilledMiles: 10, At: noon, VehicleClass: model.VehicleClassHeavy, Holidays: map[string]struct{}{"2026-03-04": {}}},
+			want:  []string{"p3", "p4"},
+		},
+		{name: "min miles is inclusive", input: pricingInput{BilledMiles: 50, At: noon}, want: []string{"p1", "p5", "p4"}},
+		{name: "max miles is exclusive", input: pricingInput{BilledMiles: 5, At: noon}, want: []string{"p1", "p4"}},
+		{name: "under max miles", input: pricingInput{BilledMiles: 4.9, At: noon}, want: []string{"p1", "p6", "p4"}},
+		{name: "overnight window after midnight", input: pricingInput{BilledMiles: 10, At: time.Date(2026, 3, 4, 5, 59, 0, 0, time.UTC)}, want: []string{"p1", "p7", "p4"}},
+		{name: "overnight window end is exclusive", input: pricingInput{BilledMiles: 10, At: time.Date(2026, 3, 4, 6, 0, 0, 0, time.UTC)}, want: []string{"p1", "p4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, rule := range selectApplicableRules(rules, tt.input) {
+				got = append(got, stringValue(rule.price.ID))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("selectApplicableRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvePricingRules(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+
+	tests := []struct {
+		name    string
+		prices  []*model.Price
+		wantErr string
+	}{
+		{name: "legacy names", prices: []*model.Price{{ItemName: str(legacyHookUpFeeName), Amount: cents(7500)}, {ItemName: str(legacyPerMileName), Amount: cents(400)}}},
+		{name: "no prices", wantErr: "no prices"},
+		{name: "missing hook-up fee", prices: []*model.Price{{ItemName: str("Per Mile"), Type: str(model.PriceTypePerMile), Amount: cents(400)}}, wantErr: "missing a flat hook-up fee"},
+		{name: "missing rate", prices: []*model.Price{{ItemName: str("Hook Up"), Type: str(model.PriceTypeFlat), Amount: cents(7500)}}, wantErr: "missing a per-mile or per-minute rate"},
+		{
+			name: "tiers on a flat price",
+			prices: []*model.Price{
+				{ItemName: str("Hook Up"), Type: str(model.PriceTypeFlat), Tiers: []model.MileageTier{{Amount: cents(1)}}},
+				{ItemName: str("Per Mile"), Type: str(model.PriceTypePerMile), Amount: cents(400)},
+			},
+			wantErr: "tiers are only supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolvePricingRules(tt.prices)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("resolvePricingRules() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("resolvePricingRules() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCalculatePrice(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	miles := func(m float64) *float64 { return &m }
+	percent := func(p float64) *float64 { return &p }
+	yes := true
+
+	hookUp := &model.Price{ID: str("hook"), ItemName: str("Hook Up"), Type: str(model.PriceTypeFlat), Amount: cents(10000), Taxable: &yes}
+	perMile := &model.Price{ID: str("mile"), ItemName: str("Per Mile"), Type: str(model.PriceTypePerMile), Amount: cents(200)}
+	heavy := &model.Price{ID: str("heavy"), ItemName: str("Heavy Duty Surcharge"), Type: str(model.PriceTypeFlat), Amount: cents(3000),
+		Condition: &model.PriceCondition{VehicleClasses: []string{model.VehicleClassHeavy}}}
+	noon := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		prices    []*model.Price
+		input     pricingInput
+		wantTotal int64
+		wantItems []string
+		wantTax   int64
+		wantErr   string
+	}{
+		{
+			name:      "hook-up and miles",
+			prices:    []*model.Price{hookUp, perMile},
+			input:     pricingInput{BilledMiles: 10, At: noon},
+			wantTotal: 12000,
+			wantItems: []string{"Hook Up", "Per Mile (10 miles at $2.00 per mile)"},
+		},
+		{
+			name: "percentage of the subtotal",
+			prices: []*model.Price{hookUp, perMile,
+				{ItemName: str("Fuel"), Type: str(model.PriceTypePercentage), Percent: percent(10)}},
+			input:     pricingInput{BilledMiles: 10, At: noon},
+			wantTotal: 13200,
+			wantItems: []string{"Hook Up", "Per Mile (10 miles at $2.00 per mile)", "Fuel (10%)"},
+		},
+		{
+			name: "minimum charge",
+			prices: []*model.Price{
+				{ItemName: str("Hook Up"), Type: str(model.PriceTypeFlat), Amount: cents(1000)},
+				perMile,
+				{ItemName: str("Minimum"), Type: str(model.PriceTypeMinimum), Amount: cents(5000)},
+			},
+			input:     pricingInput{BilledMiles: 2, At: noon},
+			wantTotal: 5000,
+			wantItems: []string{"Hook Up", "Per Mile (2 miles at $2.00 per mile)", "Minimum Charge Adjustment"},
+		},
+		{
+			name:      "minimum already met",
+			prices:    []*model.Price{hookUp, perMile, {ItemName: str("Minimum"), Type: str(model.PriceTypeMinimum), Amount: cents(5000)}},
+			input:     pricingInput{BilledMiles: 2, At: noon},
+			wantTotal: 10400,
+			wantItems: []string{"Hook Up", "Per Mile (2 miles at $2.00 per mile)"},
+		},
+		{
+			name:      "tax on the taxable items only",
+			prices:    []*model.Price{hookUp, perMile},
+			input:     pricingInput{BilledMiles: 10, At: noon, TaxRate: 8},
+			wantTotal: 12800,
+			wantItems: []string{"Hook Up", "Per Mile (10 miles at $2.00 per mile)", "Sales Tax (8%)"},
+			wantTax:   800,
+		},
+		{
+			// The $12.00 discount takes 10000/12000 of itself off the taxable $100.00, leaving $90.00 taxed
+			name:   "tax on the discounted base",
+			prices: []*model.Price{hookUp, perMile},
+			input: pricingInput{BilledMiles: 10, At: noon, TaxRate: 8, TaxName: "State Tax",
+				Promo: &model.PromoCode{Code: str("SAVE10"), Type: str(model.PromoTypePercentage), Percent: percent(10)}},
+			wantTotal: 11520,
+			wantItems: []string{"Hook Up", "Per Mile (10 miles at $2.00 per mile)", "Promo SAVE10", "State Tax (8%)"},
+			wantTax:   720,
+		},
+		{
+			name:   "discount never goes below the minimum charge",
+			prices: []*model.Price{hookUp, perMile},
+			input: pricingInput{BilledMiles: 10, At: noon,
+				Promo: &model.PromoCode{Code: str("FREE"), Type: str(model.PromoTypeFixed), Amount: cents(50000)}},
+			wantTotal: minimumChargeAfterDiscount,
+			wantItems: []string{"Hook Up", "Per Mile (10 miles at $2.00 per mile)", "Promo FREE"},
+		},
+		{
+			name:   "zone flat price replaces the base charges",
+			prices: []*model.Price{hookUp, perMile, heavy},
+			input: pricingInput{BilledMiles: 10, At: noon, VehicleClass: model.VehicleClassHeavy,
+				Zone: &model.PricingZone{ID: str("zone"), Name: str("Downtown"), FlatPrice: cents(9000)}},
+			wantTotal: 12000,
+			wantItems: []string{"Downtown Zone Rate", "Heavy Duty Surcharge"},
+		},
+		{
+			name:      "zone per-mile amount replaces the rate",
+			prices:    []*model.Price{hookUp, perMile},
+			input:     pricingInput{BilledMiles: 10, At: noon, Zone: &model.PricingZone{ID: str("zone"), Name: str("Airport"), PerMileAmount: cents(500)}},
+			wantTotal: 15000,
+			wantItems: []string{"Hook Up", "Per Mile (10 miles at $5.00 per mile)"},
+		},
+		{
+			name: "en-route and return miles with a free radius",
+			prices: []*model.Price{hookUp, perMile,
+				{ItemName: str("En Route"), Type: str(model.PriceTypeEnRoute), Amount: cents(300), IncludedMiles: miles(5)},
+				{ItemName: str("Return"), Type: str(model.PriceTypeReturn), Amount: cents(100)}},
+			input:     pricingInput{BilledMiles: 10, EnRouteMiles: 5.2, ReturnMiles: 3.5, MileageRounding: model.MileageRoundingWhole, At: noon},
+			wantTotal: 12700,
+			wantItems: []string{"Hook Up", "Per Mile (10 miles at $2.00 per mile)", "En Route (1 miles at $3.00 per mile)", "Return (4 miles at $1.00 per mile)"},
+		},
+		{
+			name: "zero total",
+			prices: []*model.Price{
+				{ItemName: str("Hook Up"), Type: str(model.PriceTypeFlat), Amount: cents(0)},
+				{ItemName: str("Per Mile"), Type: str(model.PriceTypePerMile), Amount: cents(0)},
+			},
+			input:   pricingInput{BilledMiles: 10, At: noon},
+			wantErr: "total of zero",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown, err := calculatePrice(tt.prices, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("calculatePrice() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("calculatePrice() error = %v", err)
+			}
+
+			var names []string
+			var sum int64
+			for _, item := range breakdown.LineItems {
+				names = append(names, item.Name)
+				sum += item.Amount
+			}
+			if breakdown.Total != tt.wantTotal || sum != breakdown.Total {
+				t.Errorf("calculatePrice() total = %d (line items add up to %d), want %d", breakdown.Total, sum, tt.wantTotal)
+			}
+			if strings.Join(names, "|") != strings.Join(tt.wantItems, "|") {
+				t.Errorf("calculatePrice() line items = %q, want %q", names, tt.wantItems)
+			}
+			if breakdown.TaxAmount != tt.wantTax {
+				t.Errorf("calculatePrice() tax = %d, want %d", breakdown.TaxAmount, tt.wantTax)
+			}
+		})
+	}
+}
+
+func TestUsesYardMiles(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	if usesYardMiles([]*model.Price{{Type: str(model.PriceTypeFlat)}, nil, {Type: str(model.PriceTypePerMile)}}) {
+		t.Error("usesYardMiles() = true for a price list without en-route or return rates")
+	}
+	if !usesYardMiles([]*model.Price{{Type: str(model.PriceTypeFlat)}, {Type: str(model.PriceTypeReturn)}}) {
+		t.Error("usesYardMiles() = false for a price list with a return rate")
+	}
+}
+
+func TestRepriceTow(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	distance := func(m float64) *float64 { return &m }
+	at := func(ts int64) *int64 { return &ts }
+
+	company := &model.Company{Timezone: str("UTC")}
+	prices := []*model.Price{
+		{ItemName: str("Hook Up"), Type: str(model.PriceTypeFlat), Amount: cents(8000)},
+		{ItemName: str("Per Mile"), Type: str(model.PriceTypePerMile), Amount: cents(300)},
+	}
+	yardPrices := append([]*model.Price{{ItemName: str("En Route"), Type: str(model.PriceTypeEnRoute), Amount: cents(100)}}, prices...)
+	square := &model.GeoJSONPolygon{Type: "Polygon", Coordinates: [][][]float64{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}}}
+	downtown := &model.PricingZone{ID: str("downtown"), Name: str("Downtown"), Polygon: square, FlatPrice: cents(9000)}
+	airport := &model.PricingZone{ID: str("airport"), Name: str("Airport"), PostalCodes: []string{"78719"}, PerMileAmount: cents(500)}
+	zones := []*model.PricingZone{downtown, airport}
+
+	// A 10 mile tow booked at noon UTC on March 4th 2026, charged $120.00 before tax and its $12.00 discount
+	tow := func(change func(tow *model.Tow)) *model.Tow {
+		tow := &model.Tow{Price: cents(11520), TaxAmount: cents(720), DiscountAmount: cents(1200), Miles: distance(10), Minutes: distance(20), CreatedAt: at(1772625600)}
+		if change != nil {
+			change(tow)
+		}
+		return tow
+	}
+
+	tests := []struct {
+		name       string
+		prices     []*model.Price
+		zones      []*model.PricingZone
+		tow        *model.Tow
+		wantTotal  int64
+		wantItems  []string
+		wantReason string
+	}{
+		{name: "stored route", prices: prices, tow: tow(nil), wantTotal: 11000, wantItems: []string{"Hook Up", "Per Mile (10 miles at $3.00 per mile)"}},
+		{name: "no price", prices: prices, tow: tow(func(tow *model.Tow) { tow.Price = nil }), wantReason: "tow has no price"},
+		{name: "no stored route", prices: prices, tow: tow(func(tow *model.Tow) { tow.Minutes = nil }), wantReason: "tow has no stored route"},
+		{name: "yard miles without en-route miles", prices: yardPrices, tow: tow(nil), wantReason: "tow has no stored en-route miles"},
+		{
+			name:      "stored en-route miles",
+			prices:    yardPrices,
+			tow:       tow(func(tow *model.Tow) { tow.EnRouteMiles = distance(4) }),
+			wantTotal: 11400,
+			wantItems: []string{"Hook Up", "Per Mile (10 miles at $3.00 per mile)", "En Route (4 miles at $1.00 per mile)"},
+		},
+		{
+			name:      "zone the tow was priced in",
+			prices:    prices,
+			zones:     zones,
+			tow:       tow(func(tow *model.Tow) { tow.PricingZoneID = str("airport") }),
+			wantTotal: 13000,
+			wantItems: []string{"Hook Up", "Per Mile (10 miles at $5.00 per mile)"},
+		},
+		{
+			name:   "zone containing the stored coordinates",
+			prices: prices,
+			zones:  zones,
+			tow: tow(func(tow *model.Tow) {
+				tow.PickupCoordinates, tow.DestinationCoordinates = []float64{2, 2}, []float64{8, 8}
+			}),
+			wantTotal: 9000,
+			wantItems: []string{"Downtown Zone Rate"},
+		},
+		{
+			name:      "deleted zone",
+			prices:    prices,
+			zones:     []*model.PricingZone{downtown},
+			tow:       tow(func(tow *model.Tow) { tow.PricingZoneID = str("airport") }),
+			wantTotal: 11000,
+			wantItems: []string{"Hook Up", "Per Mile (10 miles at $3.00 per mile)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, items, reason := repriceTow(company, tt.prices, tt.zones, tt.tow, usesYardMiles(tt.prices))
+			if reason != tt.wantReason {
+				t.Fatalf("repriceTow() skipped = %q, want %q", reason, tt.wantReason)
+			}
+
+			var names []string
+			for _, item := range items {
+				names = append(names, item.Name)
+			}
+			if total != tt.wantTotal || strings.Join(names, "|") != strings.Join(tt.wantItems, "|") {
+				t.Errorf("repriceTow() = %d in %q, want %d in %q", total, names, tt.wantTotal, tt.wantItems)
+			}
+		})
+	}
+
+	if got := chargedListPrice(tow(nil)); got != 12000 {
+		t.Errorf("chargedListPrice() = %d, want 12000", got)
+	}
+}
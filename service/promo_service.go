@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"tow-management-system-api/model"
+
+	"github.com/google/uuid"
+)
+
+type PromoCodeRepository interface {
+	Create(ctx context.Context, item *model.PromoCode) error
+	Find(ctx context.Context, filterModel *model.PromoCode) ([]*model.PromoCode, error)
+	Update(ctx context.Context, id string, updateData *model.PromoCode) error
+	ReserveUse(ctx context.Context, id string) (bool, error)
+	ReleaseUse(ctx context.Context, id string) error
+}
+
+type PromoRedemptionRepository interface {
+	Create(ctx context.Context, item *model.PromoRedemption) error
+	Find(ctx context.Context, filterModel *model.PromoRedemption) ([]*model.PromoRedemption, error)
+}
+
+// PromoService manages company promo codes and their redemption reporting.
+type PromoService struct {
+	promoCodeRepository       PromoCodeRepository
+	promoRedemptionRepository PromoRedemptionRepository
+}
+
+// NewPromoService creates a new PromoService instance.
+func NewPromoService(promoCodeRepo PromoCodeRepository, promoRedemptionRepo PromoRedemptionRepository) *PromoService {
+	return &PromoService{
+		promoCodeRepository:       promoCodeRepo,
+		promoRedemptionRepository: promoRedemptionRepo,
+	}
+}
+
+// CreatePromoCode validates and saves a new promo code for a company. Codes are unique per company.
+func (s *PromoService) CreatePromoCode(ctx context.Context, companyId string, promo *model.PromoCode) (*model.PromoCode, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+	if promo == nil {
+		return nil, fmt.Errorf("promo code is required")
+	}
+	if err := validatePromoCode(promo); err != nil {
+		return nil, err
+	}
+
+	code := normalizePromoCode(*promo.Code)
+	existing, err := s.promoCodeRepository.Find(ctx, &model.PromoCode{CompanyID: &companyId, Code: &code})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch promo codes: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("promo code %s already exists", code)
+	}
+
+	id := uuid.NewString()
+	uses := 0
+	now := time.Now().UTC().Unix()
+	promo.ID = &id
+	promo.CompanyID = &companyId
+	promo.Code = &code
+	promo.Uses = &uses
+	promo.CreatedAt = &now
+	if promo.Active == nil {
+		active := true
+		promo.Active = &active
+	}
+
+	if err := s.promoCodeRepository.Create(ctx, promo); err != nil {
+		return nil, fmt.Errorf("failed to create promo code: %w", err)
+	}
+
+	return promo, nil
+}
+
+// UpdatePromoCode changes the terms of one of a company's promo codes or deactivates it. The code itself and its use
+// count cannot change.
+func (s *PromoService) UpdatePromoCode(ctx context.Context, companyId string, promoId string, update *model.PromoCode) (*model.PromoCode, error) {
+	if update == nil {
+		return nil, fmt.Errorf("update body is required")
+	}
+
+	promo, err := s.FindPromoCodeById(ctx, companyId, promoId)
+	if err != nil {
+		return nil, err
+	}
+
+	update.ID = nil
+	update.CompanyID = nil
+	update.Code = nil
+	update.Uses = nil
+	update.CreatedAt = nil
+
+	// Validate the code as it will look after the update
+	merged := *promo
+	mergePromoCode(&merged, update)
+	if err := validatePromoCode(&merged); err != nil {
+		return nil, err
+	}
+
+	if err := s.promoCodeRepository.Update(ctx, promoId, update); err != nil {
+		return nil, fmt.Errorf("failed to update promo code: %w", err)
+	}
+
+	return &merged, nil
+}
+
+// FindPromoCodesByCompanyId returns a company's promo codes with their use counts.
+func (s *PromoService) FindPromoCodesByCompanyId(ctx context.Context, companyId string) ([]*model.PromoCode, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+
+	promos, err := s.promoCodeRepository.Find(ctx, &model.PromoCode{CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("find promo codes failed: %w", err)
+	}
+
+	sort.Slice(promos, func(i, j int) bool {
+		return stringValue(promos[i].Code) < stringValue(promos[j].Code)
+	})
+
+	return promos, nil
+}
+
+// FindPromoCodeById retrieves a single promo code of a company.
+func (s *PromoService) FindPromoCodeById(ctx context.Context, companyId string, promoId string) (*model.PromoCode, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+	if promoId == "" {
+		return nil, fmt.Errorf("promo code id is required")
+	}
+
+	promos, err := s.promoCodeRepository.Find(ctx, &model.PromoCode{ID: &promoId, CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch promo code: %w", err)
+	}
+	if len(promos) == 0 {
+		return nil, fmt.Errorf("promo code not found")
+	}
+
+	return promos[0], nil
+}
+
+// FindRedemptions returns the bookings made with one of a company's promo codes, newest first.
+func (s *PromoService) FindRedemptions(ctx context.Context, companyId string, promoId string) ([]*model.PromoRedemption, error) {
+	if _, err := s.FindPromoCodeById(ctx, companyId, promoId); err != nil {
+		return nil, err
+	}
+
+	redemptions, err := s.promoRedemptionRepository.Find(ctx, &model.PromoRedemption{PromoCodeID: &promoId, CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("find promo redemptions failed: %w", err)
+	}
+
+	sort.Slice(redemptions, func(i, j int) bool {
+		return int64Value(redemptions[i].RedeemedAt) > int64Value(redemptions[j].RedeemedAt)
+	})
+
+	return redemptions, nil
+}
+
+// validatePromoCode checks a promo code's terms.
+func validatePromoCode(promo *model.PromoCode) error {
+	if promo.Code == nil || strings.TrimSpace(*promo.Code) == "" {
+		return fmt.Errorf("code is required")
+	}
+
+	switch stringValue(promo.Type) {
+	case model.PromoTypePercentage:
+		if promo.Percent == nil || *promo.Percent <= 0 || *promo.Percent > 100 {
+			return fmt.Errorf("percent must be greater than 0 and at most 100")
+		}
+	case model.PromoTypeFixed:
+		if promo.Amount == nil || *promo.Amount <= 0 {
+			return fmt.Errorf("amount must be greater than zero")
+		}
+	default:
+		return fmt.Errorf("type must be percentage or fixed")
+	}
+
+	if promo.MinOrder != nil && *promo.MinOrder < 0 {
+		return fmt.Errorf("minimum order must be zero or greater")
+	}
+	if promo.MaxUses != nil && *promo.MaxUses <= 0 {
+		return fmt.Errorf("max uses must be greater than zero")
+	}
+	if promo.MaxUsesPerCustomer != nil && *promo.MaxUsesPerCustomer <= 0 {
+		return fmt.Errorf("max uses per customer must be greater than zero")
+	}
+	if promo.ValidFrom != nil && promo.ValidUntil != nil && *promo.ValidUntil <= *promo.ValidFrom {
+		return fmt.Errorf("validUntil must be after validFrom")
+	}
+
+	return nil
+}
+
+// mergePromoCode applies the set fields of update onto promo.
+func mergePromoCode(promo *model.PromoCode, update *model.PromoCode) {
+	if update.Description != nil {
+		promo.Description = update.Description
+	}
+	if update.Type != nil {
+		promo.Type = update.Type
+	}
+	if update.Percent != nil {
+		promo.Percent = update.Percent
+	}
+	if update.Amount != nil {
+		promo.Amount = update.Amount
+	}
+	if update.MinOrder != nil {
+		promo.MinOrder = update.MinOrder
+	}
+	if update.MaxUses != nil {
+		promo.MaxUses = update.MaxUses
+	}
+	if update.MaxUsesPerCustomer != nil {
+		promo.MaxUsesPerCustomer = update.MaxUsesPerCustomer
+	}
+	if update.ValidFrom != nil {
+		promo.ValidFrom = update.ValidFrom
+	}
+	if update.ValidUntil != nil {
+		promo.ValidUntil = update.ValidUntil
+	}
+	if update.Active != nil {
+		promo.Active = update.Active
+	}
+}
+
+// normalizePromoCode uppercases a code and trims surrounding whitespace.
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// checkPromoAvailable reports why a promo code cannot be used at the given unix time, if it cannot.
+// Per-customer limits are checked separately at booking, once the customer's email is known.
+func checkPromoAvailable(promo *model.PromoCode, at int64) error {
+	if promo.Active != nil && !*promo.Active {
+		return fmt.Errorf("promo code is not active")
+	}
+	if promo.ValidFrom != nil && at < *promo.ValidFrom {
+		return fmt.Errorf("promo code is not valid yet")
+	}
+	if promo.ValidUntil != nil && at >= *promo.ValidUntil {
+		return fmt.Errorf("promo code has expired")
+	}
+	if promo.MaxUses != nil && intValue(promo.Uses) >= *promo.MaxUses {
+		return fmt.Errorf("promo code has reached its usage limit")
+	}
+	return nil
+}
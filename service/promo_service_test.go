@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"tow-management-system-api/model"
+)
+
+type memoryPromoCodes struct {
+	memoryStore[model.PromoCode]
+}
+
+func (r *memoryPromoCodes) ReserveUse(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, doc := range r.docs {
+		if doc["_id"] != id {
+			continue
+		}
+		uses, _ := doc["uses"].(int32)
+		if maxUses, ok := doc["maxUses"].(int32); ok && uses >= maxUses {
+			return false, nil
+		}
+		doc["uses"] = uses + 1
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *memoryPromoCodes) ReleaseUse(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, doc := range r.docs {
+		if uses, _ := doc["uses"].(int32); doc["_id"] == id && uses > 0 {
+			doc["uses"] = uses - 1
+		}
+	}
+	return nil
+}
+
+type memoryPromoRedemptions struct {
+	memoryStore[model.PromoRedemption]
+}
+
+func (r *memoryPromoRedemptions) Reserve(ctx context.Context, item *model.PromoRedemption) (bool, error) {
+	if err := r.Create(ctx, item); err != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *memoryPromoRedemptions) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, doc := range r.docs {
+		if doc["_id"] == id {
+			r.docs = append(r.docs[:i], r.docs[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestCheckPromoAvailable(t *testing.T) {
+	flag := func(b bool) *bool { return &b }
+	count := func(n int) *int { return &n }
+	at := func(unix int64) *int64 { return &unix }
+
+	tests := []struct {
+		name    string
+		promo   *model.PromoCode
+		wantErr string
+	}{
+		{name: "no limits", promo: &model.PromoCode{}},
+		{name: "deactivated", promo: &model.PromoCode{Active: flag(false)}, wantErr: "not active"},
+		{name: "before it starts", promo: &model.PromoCode{ValidFrom: at(2000)}, wantErr: "not valid yet"},
+		{name: "on its first second", promo: &model.PromoCode{ValidFrom: at(1000)}},
+		{name: "at its end", promo: &model.PromoCode{ValidUntil: at(1000)}, wantErr: "expired"},
+		{name: "uses left", promo: &model.PromoCode{MaxUses: count(5), Uses: count(4)}},
+		{name: "no uses left", promo: &model.PromoCode{MaxUses: count(5), Uses: count(5)}, wantErr: "usage limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPromoAvailable(tt.promo, 1000)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("checkPromoAvailable() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("checkPromoAvailable() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPromoDiscount(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cents := func(c int) *int { return &c }
+	percent := func(p float64) *float64 { return &p }
+
+	tests := []struct {
+		name    string
+		promo   *model.PromoCode
+		total   int64
+		want    int64
+		wantErr string
+	}{
+		{name: "percentage rounds to the cent", promo: &model.PromoCode{Type: str(model.PromoTypePercentage), Percent: percent(15)}, total: 12345, want: 1852},
+		{name: "fixed amount", promo: &model.PromoCode{Type: str(model.PromoTypeFixed), Amount: cents(2000)}, total: 12500, want: 2000},
+		{name: "fixed amount leaves the minimum charge", promo: &model.PromoCode{Type: str(model.PromoTypeFixed), Amount: cents(20000)}, total: 12500, want: 12500 - minimumChargeAfterDiscount},
+		{name: "full percentage leaves the minimum charge", promo: &model.PromoCode{Type: str(model.PromoTypePercentage), Percent: percent(100)}, total: 12500, want: 12500 - minimumChargeAfterDiscount},
+		{name: "total below the minimum charge", promo: &model.PromoCode{Type: str(model.PromoTypeFixed), Amount: cents(100)}, total: 40, want: 0},
+		{name: "minimum order met", promo: &model.PromoCode{Type: str(model.PromoTypeFixed), Amount: cents(1000), MinOrder: cents(10000)}, total: 10000, want: 1000},
+		{name: "minimum order not met", promo: &model.PromoCode{Type: str(model.PromoTypeFixed), Amount: cents(1000), MinOrder: cents(10000)}, total: 9999, wantErr: "minimum order of $100.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := promoDiscount(tt.promo, tt.total)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("promoDiscount() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("promoDiscount() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("promoDiscount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// newPromoBooking returns a tow service over one company with the given promo code, and its promo stores.
+func newPromoBooking(t *testing.T, promo *model.PromoCode) (*TowService, *memoryPromoCodes, *memoryPromoRedemptions) {
+	t.Helper()
+	str := func(s string) *string { return &s }
+	uses := 0
+	promo.ID, promo.CompanyID, promo.Code, promo.Uses = str("promo-1"), str("company-1"), str("SPRING"), &uses
+
+	promos := &memoryPromoCodes{}
+	if err := promos.Create(context.Background(), promo); err != nil {
+		t.Fatal(err)
+	}
+	redemptions := &memoryPromoRedemptions{}
+	return NewTowService(nil, nil, nil, nil, nil, nil, nil, promos, redemptions, nil, nil, nil, nil), promos, redemptions
+}
+
+// promoTow is a booking of the SPRING code, by the customer with the given email when set.
+func promoTow(id string, email string) *model.Tow {
+	code := "spring"
+	tow := &model.Tow{ID: &id, PromoCode: &code}
+	if email != "" {
+		tow.PrimaryContact = &model.PrimaryContact{Email: &email}
+	}
+	return tow
+}
+
+func TestReservePromo(t *testing.T) {
+	count := func(n int) *int { return &n }
+	companyId := "company-1"
+	company := &model.Company{ID: &companyId}
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name     string
+		promo    *model.PromoCode
+		bookings []*model.Tow
+		wantErr  string
+		wantUses int
+	}{
+		{name: "unlimited code", promo: &model.PromoCode{}, bookings: []*model.Tow{promoTow("tow-1", ""), promoTow("tow-2", "")}, wantUses: 2},
+		{name: "total limit", promo: &model.PromoCode{MaxUses: count(1)}, bookings: []*model.Tow{promoTow("tow-1", "a@example.com"), promoTow("tow-2", "b@example.com")}, wantErr: "usage limit", wantUses: 1},
+		{name: "per-customer limit ignores case", promo: &model.PromoCode{MaxUsesPerCustomer: count(1)}, bookings: []*model.Tow{promoTow("tow-1", "a@example.com"), promoTow("tow-2", "A@Example.com")}, wantErr: "already been used by this customer", wantUses: 1},
+		{name: "per-customer limit counts each customer", promo: &model.PromoCode{MaxUsesPerCustomer: count(1)}, bookings: []*model.Tow{promoTow("tow-1", "a@example.com"), promoTow("tow-2", "b@example.com")}, wantUses: 2},
+		{name: "per-customer limit needs an email", promo: &model.PromoCode{MaxUsesPerCustomer: count(1)}, bookings: []*model.Tow{promoTow("tow-1", "")}, wantErr: "email is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, promos, redemptions := newPromoBooking(t, tt.promo)
+
+			var err error
+			for _, tow := range tt.bookings {
+				if _, err = svc.reservePromo(ctx, tow, company, now, 1000); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("reservePromo() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("reservePromo() error = %v", err)
+			}
+
+			if uses := intValue(promos.get("promo-1").Uses); uses != tt.wantUses {
+				t.Errorf("promo uses = %d, want %d", uses, tt.wantUses)
+			}
+			if recorded, _ := redemptions.Find(ctx, &model.PromoRedemption{}); len(recorded) != tt.wantUses {
+				t.Errorf("%d redemptions recorded, want %d", len(recorded), tt.wantUses)
+			}
+		})
+	}
+}
+
+func TestReleasePromoFreesTheCustomersUse(t *testing.T) {
+	ctx := context.Background()
+	companyId := "company-1"
+	company := &model.Company{ID: &companyId}
+	maxUses := 1
+	svc, promos, redemptions := newPromoBooking(t, &model.PromoCode{MaxUsesPerCustomer: &maxUses})
+
+	redemption, err := svc.reservePromo(ctx, promoTow("tow-1", "a@example.com"), company, time.Now().Unix(), 1000)
+	if err != nil {
+		t.Fatalf("reservePromo() error = %v", err)
+	}
+	if stringValue(redemption.TowID) != "tow-1" || intValue(redemption.DiscountAmount) != 1000 || stringValue(redemption.CustomerEmail) != "a@example.com" {
+		t.Errorf("redemption = %+v, want tow-1, $10.00 off, a@example.com", redemption)
+	}
+
+	// The booking failed, so the customer can book again
+	svc.releasePromo(ctx, redemption)
+	if uses := intValue(promos.get("promo-1").Uses); uses != 0 {
+		t.Errorf("promo uses = %d after release, want 0", uses)
+	}
+	if recorded, _ := redemptions.Find(ctx, &model.PromoRedemption{}); len(recorded) != 0 {
+		t.Errorf("%d redemptions kept after release, want 0", len(recorded))
+	}
+	if _, err := svc.reservePromo(ctx, promoTow("tow-2", "a@example.com"), company, time.Now().Unix(), 1000); err != nil {
+		t.Errorf("reservePromo() after release error = %v", err)
+	}
+}
+
+func TestReservePromoConcurrentBookingsBySameCustomer(t *testing.T) {
+	ctx := context.Background()
+	companyId := "company-1"
+	company := &model.Company{ID: &companyId}
+	maxUses := 2
+	svc, promos, _ := newPromoBooking(t, &model.PromoCode{MaxUsesPerCustomer: &maxUses})
+
+	const bookings = 8
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+	for i := 0; i < bookings; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := svc.reservePromo(ctx, promoTow(string(rune('a'+i)), "a@example.com"), company, time.Now().Unix(), 1000); err == nil {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if reserved != maxUses {
+		t.Errorf("%d concurrent bookings reserved the code, want %d", reserved, maxUses)
+	}
+	if uses := intValue(promos.get("promo-1").Uses); uses != maxUses {
+		t.Errorf("promo uses = %d, want %d", uses, maxUses)
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"tow-management-system-api/model"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+// refundIssuedByStripe marks refunds that were not issued through the API, e.g. from the Stripe dashboard.
+const refundIssuedByStripe = "stripe"
+
+// RefundTow refunds a paid tow in full or in part through Stripe and records the refund on the tow. An amount of
+// zero refunds everything not yet refunded. A tow paid with more than one checkout is refunded from its newest
+// Stripe payment first, never taking more from a payment than it holds. issuedBy is the user id of the company
+// user who issued the refund.
+func (s *PaymentService) RefundTow(ctx context.Context, towId string, amount int, reason, issuedBy string) (*model.Tow, error) {
+	if amount < 0 {
+		return nil, fmt.Errorf("refund amount must be greater than zero")
+	}
+
+	tow, err := s.findCompanyTow(ctx, towId, issuedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	switch stringValue(tow.PaymentStatus) {
+	case paymentStatusPaid, paymentStatusPartial, paymentStatusOverpaid, paymentStatusPartiallyRefunded:
+	default:
+		return nil, fmt.Errorf("only paid tows can be refunded")
+	}
+
+	// Only Stripe payments can be refunded; offline payments are returned outside the platform
+	payments, err := s.stripePayments(ctx, tow)
+	if err != nil {
+		return nil, err
+	}
+	refundable := 0
+	for i := range payments {
+		refunds, err := s.paymentGateway.ListRefunds(ctx, payments[i].paymentIntentId)
+		if err != nil {
+			return nil, err
+		}
+		payments[i].amount -= refundedAmount(refunds)
+		refundable += max(payments[i].amount, 0)
+	}
+	if amount == 0 {
+		amount = refundable
+	}
+	if amount <= 0 || amount > refundable {
+		return nil, fmt.Errorf("refund amount must be between 1 and %d cents", max(refundable, 0))
+	}
+
+	metadata := map[string]string{"towId": *tow.ID, "reason": reason, "issuedBy": issuedBy}
+	left := amount
+	for i := len(payments) - 1; i >= 0 && left > 0; i-- {
+		part := min(left, payments[i].amount)
+		if part <= 0 {
+			continue
+		}
+		if _, err := s.paymentGateway.CreateRefund(ctx, payments[i].paymentIntentId, int64(part), reason, metadata); err != nil {
+			// Keep the refunds already made on the tow before reporting the one that failed
+			if left < amount {
+				if _, syncErr := s.syncRefunds(ctx, tow); syncErr != nil {
+					log.Printf("failed to sync refunds of tow %s: %v", *tow.ID, syncErr)
+				}
+			}
+			return nil, err
+		}
+		left -= part
+	}
+
+	return s.syncRefunds(ctx, tow)
+}
+
+// stripePayment is a Stripe payment of a tow: the payment intent it was made with and the cents it collected.
+type stripePayment struct {
+	paymentIntentId string
+	amount          int
+}
+
+// stripePayments returns the tow's Stripe payments, oldest first. A tow paid before the ledger existed counts its
+// price as one payment. Ledger payments recorded before each payment kept its intent look it up from their
+// checkout session and store it.
+func (s *PaymentService) stripePayments(ctx context.Context, tow *model.Tow) ([]stripePayment, error) {
+	if len(tow.Payments) == 0 {
+		amount := paidAmount(tow, model.PaymentMethodStripe)
+		if amount <= 0 {
+			return nil, fmt.Errorf("tow has no payment to refund")
+		}
+		paymentIntentId, err := s.towPaymentIntent(ctx, tow)
+		if err != nil {
+			return nil, err
+		}
+		return []stripePayment{{paymentIntentId: paymentIntentId, amount: amount}}, nil
+	}
+
+	var payments []stripePayment
+	for i := range tow.Payments {
+		p := &tow.Payments[i]
+		if stringValue(p.Method) != model.PaymentMethodStripe || p.VoidedAt != nil {
+			continue
+		}
+
+		paymentIntentId := stringValue(p.PaymentIntentID)
+		if paymentIntentId == "" {
+			var err error
+			if paymentIntentId, err = s.ledgerPaymentIntent(ctx, tow, p); err != nil {
+				return nil, err
+			}
+		}
+		payments = append(payments, stripePayment{paymentIntentId: paymentIntentId, amount: intValue(p.Amount)})
+	}
+	if len(payments) == 0 {
+		return nil, fmt.Errorf("tow has no payment to refund")
+	}
+
+	return payments, nil
+}
+
+// ledgerPaymentIntent looks up the payment intent of a Stripe ledger payment recorded before payments kept their
+// intent and stores it on the payment. A payment backfilled without a checkout session can only have been made
+// with the intent recorded on the tow.
+func (s *PaymentService) ledgerPaymentIntent(ctx context.Context, tow *model.Tow, payment *model.Payment) (string, error) {
+	var paymentIntentId string
+	if reference := stringValue(payment.Reference); reference == "" || strings.HasPrefix(reference, "pre-ledger-") {
+		if paymentIntentId = stringValue(tow.PaymentIntentID); paymentIntentId == "" {
+			return "", fmt.Errorf("tow has no payment to refund")
+		}
+	} else {
+		session, err := s.paymentGateway.GetCheckoutSession(ctx, reference)
+		if err != nil {
+			return "", err
+		}
+		if session.PaymentIntent == nil || session.PaymentIntent.ID == "" {
+			return "", fmt.Errorf("tow has no payment to refund")
+		}
+		paymentIntentId = session.PaymentIntent.ID
+	}
+
+	if err := s.towDataRepository.SetPaymentIntent(ctx, *tow.ID, stringValue(payment.ID), paymentIntentId); err != nil {
+		return "", err
+	}
+	payment.PaymentIntentID = &paymentIntentId
+
+	return paymentIntentId, nil
+}
+
+// towPaymentIntent returns the payment intent of the tow's latest checkout, looking it up from the checkout
+// session for tows paid before it was recorded.
+func (s *PaymentService) towPaymentIntent(ctx context.Context, tow *model.Tow) (string, error) {
+	if tow.PaymentIntentID != nil && *tow.PaymentIntentID != "" {
+		return *tow.PaymentIntentID, nil
+	}
+	if tow.PaymentReference == nil || *tow.PaymentReference == "" {
+		return "", fmt.Errorf("tow has no payment to refund")
+	}
+
+	session, err := s.paymentGateway.GetCheckoutSession(ctx, *tow.PaymentReference)
+	if err != nil {
+		return "", err
+	}
+	if session.PaymentIntent == nil || session.PaymentIntent.ID == "" {
+		return "", fmt.Errorf("tow has no payment to refund")
+	}
+
+	if err := s.towDataRepository.Update(ctx, *tow.ID, &model.Tow{PaymentIntentID: &session.PaymentIntent.ID}); err != nil {
+		return "", fmt.Errorf("failed to update tow payment intent: %w", err)
+	}
+	tow.PaymentIntentID = &session.PaymentIntent.ID
+
+	return session.PaymentIntent.ID, nil
+}
+
+// syncRefunds replaces the tow's refund records with the refunds Stripe has for its Stripe payments and updates
+// the refunded amount, balance and payment status. Stripe is the source of truth, so the API and the
+// charge.refunded webhook can both run it in any order.
+func (s *PaymentService) syncRefunds(ctx context.Context, tow *model.Tow) (*model.Tow, error) {
+	payments, err := s.stripePayments(ctx, tow)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []model.Refund{}
+	refunded := 0
+	seen := map[string]bool{}
+	for _, p := range payments {
+		// Payments recorded before they kept their intent may resolve to the same one; count its refunds once
+		if seen[p.paymentIntentId] {
+			continue
+		}
+		seen[p.paymentIntentId] = true
+
+		refunds, err := s.paymentGateway.ListRefunds(ctx, p.paymentIntentId)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range refunds {
+			record := refundRecord(r)
+			record.PaymentIntentID = &p.paymentIntentId
+			records = append(records, record)
+		}
+		refunded += refundedAmount(refunds)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return int64Value(records[i].CreatedAt) < int64Value(records[j].CreatedAt)
+	})
+
+	tow.Refunds = records
+	tow.RefundedAmount = &refunded
+	update := settledPayment(tow)
+	update.Refunds = records
+	update.RefundedAmount = &refunded
+	if err := s.towDataRepository.Update(ctx, *tow.ID, update); err != nil {
+		return nil, fmt.Errorf("failed to update tow refunds: %w", err)
+	}
+
+	tow.PaidAmount = update.PaidAmount
+	tow.BalanceDue = update.BalanceDue
+	tow.PaymentStatus = update.PaymentStatus
+	return tow, nil
+}
+
+// refundedAmount returns the cents a payment's refunds returned or are returning to the customer.
+func refundedAmount(refunds []*stripe.Refund) int {
+	refunded := 0
+	for _, r := range refunds {
+		if r.Status == stripe.RefundStatusSucceeded || r.Status == stripe.RefundStatusPending {
+			refunded += int(r.Amount)
+		}
+	}
+	return refunded
+}
+
+// refundRecord converts a Stripe refund to the record kept on the tow.
+func refundRecord(r *stripe.Refund) model.Refund {
+	id := r.ID
+	amount := int(r.Amount)
+	status := string(r.Status)
+	createdAt := r.Created
+
+	reason := r.Metadata["reason"]
+	if reason == "" {
+		reason = string(r.Reason)
+	}
+	issuedBy := r.Metadata["issuedBy"]
+	if issuedBy == "" {
+		issuedBy = refundIssuedByStripe
+	}
+
+	record := model.Refund{ID: &id, Amount: &amount, Status: &status, CreatedAt: &createdAt, IssuedBy: &issuedBy}
+	if reason != "" {
+		record.Reason = &reason
+	}
+	return record
+}
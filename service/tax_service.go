@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"tow-management-system-api/model"
+
+	"github.com/google/uuid"
+)
+
+type TaxRateRepository interface {
+	Create(ctx context.Context, item *model.TaxRate) error
+	Find(ctx context.Context, filterModel *model.TaxRate) ([]*model.TaxRate, error)
+	Update(ctx context.Context, id string, updateData *model.TaxRate) error
+	Delete(ctx context.Context, id string) error
+}
+
+var (
+	stateCodePattern  = regexp.MustCompile(`^[A-Z]{2}$`)
+	postalCodePattern = regexp.MustCompile(`^[0-9]{5}$`)
+)
+
+// TaxService manages a company's sales tax rate table.
+type TaxService struct {
+	taxRateRepository TaxRateRepository
+}
+
+// NewTaxService creates a new TaxService instance.
+func NewTaxService(taxRateRepo TaxRateRepository) *TaxService {
+	return &TaxService{
+		taxRateRepository: taxRateRepo,
+	}
+}
+
+// FindTaxRates returns a company's tax rates.
+func (s *TaxService) FindTaxRates(ctx context.Context, companyId string) ([]*model.TaxRate, error) {
+	if companyId == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+
+	rates, err := s.taxRateRepository.Find(ctx, &model.TaxRate{CompanyID: &companyId})
+	if err != nil {
+		return nil, fmt.Errorf("find tax rates failed: %w", err)
+	}
+
+	return rates, nil
+}
+
+// SetTaxRates replaces a company's tax rate table. Rates with an ID are updated, rates without one are
+// created and existing rates missing from the list are removed, so a removed rate stops being charged.
+// Rates being updated must be the company's own. Nothing is saved unless every rate is valid.
+func (s *TaxService) SetTaxRates(ctx context.Context, companyId string, rates []*model.TaxRate) error {
+	if companyId == "" {
+		return fmt.Errorf("company id is required")
+	}
+	if rates == nil {
+		return fmt.Errorf("tax rates list is required")
+	}
+
+	existing, err := s.FindTaxRates(ctx, companyId)
+	if err != nil {
+		return err
+	}
+	current := map[string]struct{}{}
+	for _, rate := range existing {
+		current[stringValue(rate.ID)] = struct{}{}
+	}
+
+	updated := map[string]struct{}{}
+	for i, rate := range rates {
+		if err := normalizeTaxRate(rate); err != nil {
+			return fmt.Errorf("rates[%d]: %w", i, err)
+		}
+		if rate.ID == nil || *rate.ID == "" {
+			continue
+		}
+		if _, ok := current[*rate.ID]; !ok {
+			return fmt.Errorf("rates[%d]: tax rate %s not found", i, *rate.ID)
+		}
+		if _, ok := updated[*rate.ID]; ok {
+			return fmt.Errorf("rates[%d]: tax rate %s is listed twice", i, *rate.ID)
+		}
+		updated[*rate.ID] = struct{}{}
+	}
+
+	kept := map[string]struct{}{}
+	for _, rate := range rates {
+		rate.CompanyID = &companyId
+
+		if rate.ID == nil || *rate.ID == "" {
+			id := uuid.NewString()
+			rate.ID = &id
+			if err := s.taxRateRepository.Create(ctx, rate); err != nil {
+				return fmt.Errorf("failed to create tax rate: %w", err)
+			}
+		} else {
+			if err := s.taxRateRepository.Update(ctx, *rate.ID, rate); err != nil {
+				return fmt.Errorf("failed to update tax rate: %w", err)
+			}
+		}
+		kept[*rate.ID] = struct{}{}
+	}
+
+	for _, rate := range existing {
+		if _, ok := kept[stringValue(rate.ID)]; ok {
+			continue
+		}
+		if err := s.taxRateRepository.Delete(ctx, stringValue(rate.ID)); err != nil {
+			return fmt.Errorf("failed to delete tax rate: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// normalizeTaxRate validates a tax rate and normalizes its jurisdiction fields for matching.
+func normalizeTaxRate(rate *model.TaxRate) error {
+	if rate == nil {
+		return fmt.Errorf("tax rate is required")
+	}
+	if rate.Rate == nil || *rate.Rate < 0 || *rate.Rate > 100 {
+		return fmt.Errorf("rate must be between 0 and 100")
+	}
+
+	if rate.State != nil {
+		state := strings.ToUpper(strings.TrimSpace(*rate.State))
+		if !stateCodePattern.MatchString(state) {
+			return fmt.Errorf("state must be a two letter code")
+		}
+		rate.State = &state
+	}
+	if rate.County != nil {
+		county := normalizeCounty(*rate.County)
+		if stringValue(rate.State) == "" {
+			return fmt.Errorf("county rates require a state")
+		}
+		rate.County = &county
+	}
+	if rate.PostalCode != nil {
+		postalCode := strings.TrimSpace(*rate.PostalCode)
+		if !postalCodePattern.MatchString(postalCode) {
+			return fmt.Errorf("postal code must be a five digit ZIP code")
+		}
+		rate.PostalCode = &postalCode
+	}
+
+	if stringValue(rate.State) == "" && stringValue(rate.PostalCode) == "" {
+		return fmt.Errorf("a state or postal code is required")
+	}
+
+	return nil
+}
+
+// normalizeCounty lowercases a county name and drops a trailing "County" or "Parish".
+func normalizeCounty(county string) string {
+	county = strings.ToLower(strings.TrimSpace(county))
+	county = strings.TrimSuffix(county, " county")
+	county = strings.TrimSuffix(county, " parish")
+	return county
+}
+
+// matchTaxRate returns the most specific rate for a location: ZIP code, then county, then state.
+// It returns nil when none of the company's rates apply.
+func matchTaxRate(rates []*model.TaxRate, state, county, postalCode string) *model.TaxRate {
+	state = strings.ToUpper(state)
+	county = normalizeCounty(county)
+	if len(postalCode) > 5 {
+		postalCode = postalCode[:5]
+	}
+
+	var best *model.TaxRate
+	bestScore := 0
+	for _, rate := range rates {
+		score := 0
+		switch {
+		case stringValue(rate.PostalCode) != "":
+			if stringValue(rate.PostalCode) != postalCode {
+				continue
+			}
+			score = 3
+		case stringValue(rate.County) != "":
+			if stringValue(rate.State) != state || stringValue(rate.County) != county {
+				continue
+			}
+			score = 2
+		default:
+			if stringValue(rate.State) != state {
+				continue
+			}
+			score = 1
+		}
+
+		if score > bestScore || (score == bestScore && stringValue(rate.ID) < stringValue(best.ID)) {
+			best = rate
+			bestScore = score
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"tow-management-system-api/model"
+)
+
+type memoryTaxRates struct {
+	memoryStore[model.TaxRate]
+}
+
+func (r *memoryTaxRates) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, doc := range r.docs {
+		if doc["_id"] == id {
+			r.docs = append(r.docs[:i], r.docs[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestSetTaxRates(t *testing.T) {
+	str := func(s string) *string { return &s }
+	percent := func(p float64) *float64 { return &p }
+
+	tests := []struct {
+		name      string
+		rates     []*model.TaxRate
+		wantErr   string
+		wantRates int
+		wantTexas float64
+	}{
+		{
+			name:      "update a rate and add a ZIP code rate",
+			rates:     []*model.TaxRate{{ID: str("texas"), State: str("tx"), Rate: percent(6.5)}, {PostalCode: str("78701"), Rate: percent(8.25)}},
+			wantRates: 2,
+			wantTexas: 6.5,
+		},
+		{
+			name:      "missing rates are removed",
+			rates:     []*model.TaxRate{{ID: str("texas"), State: str("TX"), Rate: percent(6.25)}},
+			wantRates: 1,
+			wantTexas: 6.25,
+		},
+		{
+			name:    "rate of another company",
+			rates:   []*model.TaxRate{{ID: str("texas"), State: str("TX"), Rate: percent(6.25)}, {ID: str("other"), State: str("OK"), Rate: percent(0)}},
+			wantErr: "tax rate other not found",
+		},
+		{
+			name:    "same rate twice",
+			rates:   []*model.TaxRate{{ID: str("texas"), State: str("TX"), Rate: percent(6.25)}, {ID: str("texas"), State: str("TX"), Rate: percent(7)}},
+			wantErr: "listed twice",
+		},
+		{
+			name:    "one invalid rate saves nothing",
+			rates:   []*model.TaxRate{{ID: str("texas"), State: str("TX"), Rate: percent(7)}, {State: str("Texas"), Rate: percent(1)}},
+			wantErr: "two letter code",
+		},
+		{
+			name:    "county without a state",
+			rates:   []*model.TaxRate{{County: str("Travis"), Rate: percent(1)}},
+			wantErr: "county rates require a state",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			rates := &memoryTaxRates{}
+			for _, rate := range []*model.TaxRate{
+				{ID: str("texas"), CompanyID: str("company-1"), State: str("TX"), Rate: percent(6.25)},
+				{ID: str("travis"), CompanyID: str("company-1"), State: str("TX"), County: str("travis"), Rate: percent(8.25)},
+				{ID: str("other"), CompanyID: str("company-2"), State: str("OK"), Rate: percent(4.5)},
+			} {
+				if err := rates.Create(ctx, rate); err != nil {
+					t.Fatal(err)
+				}
+			}
+			svc := NewTaxService(rates)
+
+			err := svc.SetTaxRates(ctx, "company-1", tt.rates)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SetTaxRates() error = %v, want %q", err, tt.wantErr)
+				}
+				tt.wantRates, tt.wantTexas = 2, 6.25
+			} else if err != nil {
+				t.Fatalf("SetTaxRates() error = %v", err)
+			}
+
+			company, _ := svc.FindTaxRates(ctx, "company-1")
+			if len(company) != tt.wantRates {
+				t.Errorf("company-1 has %d tax rates, want %d", len(company), tt.wantRates)
+			}
+			if texas := rates.get("texas"); texas == nil || *texas.Rate != tt.wantTexas {
+				t.Errorf("texas = %+v, want %.2f%%", texas, tt.wantTexas)
+			}
+			if other := rates.get("other"); stringValue(other.CompanyID) != "company-2" || *other.Rate != 4.5 {
+				t.Errorf("company-2's rate = %+v, want it unchanged", other)
+			}
+		})
+	}
+}
+
+func TestMatchTaxRate(t *testing.T) {
+	str := func(s string) *string { return &s }
+	percent := func(p float64) *float64 { return &p }
+
+	state := &model.TaxRate{ID: str("state"), State: str("TX"), Rate: percent(6.25)}
+	county := &model.TaxRate{ID: str("county"), State: str("TX"), County: str("travis"), Rate: percent(8)}
+	zip := &model.TaxRate{ID: str("zip"), PostalCode: str("78701"), Rate: percent(8.25)}
+	otherCounty := &model.TaxRate{ID: str("williamson"), State: str("TX"), County: str("williamson"), Rate: percent(7)}
+	tie := &model.TaxRate{ID: str("a-state"), State: str("TX"), Rate: percent(6)}
+	all := []*model.TaxRate{state, county, zip, otherCounty}
+
+	tests := []struct {
+		name       string
+		rates      []*model.TaxRate
+		state      string
+		county     string
+		postalCode string
+		want       *model.TaxRate
+	}{
+		{name: "ZIP code over county and state", rates: all, state: "TX", county: "Travis County", postalCode: "78701", want: zip},
+		{name: "ZIP+4 matches the ZIP code", rates: all, state: "TX", county: "Travis", postalCode: "78701-1234", want: zip},
+		{name: "county over state", rates: all, state: "tx", county: "Travis County", postalCode: "78702", want: county},
+		{name: "state when no county matches", rates: all, state: "TX", county: "Hays County", postalCode: "78666", want: state},
+		{name: "county needs its state", rates: []*model.TaxRate{county}, state: "OK", county: "Travis", postalCode: "74000"},
+		{name: "no rate for the state", rates: all, state: "OK", county: "Tulsa", postalCode: "74103"},
+		{name: "lowest ID breaks a tie", rates: []*model.TaxRate{state, tie}, state: "TX", postalCode: "78702", want: tie},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchTaxRate(tt.rates, tt.state, tt.county, tt.postalCode); got != tt.want {
+				t.Errorf("matchTaxRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,8 +3,9 @@ package service
 import (
 	"context"
 	"fmt"
-	"math"
+	"log"
 	"os"
+	"strings"
 	"time"
 	"tow-management-system-api/model"
 	"tow-management-system-api/utilities"
@@ -18,33 +19,87 @@ type TowRepository interface {
 	Update(ctx context.Context, id string, updateData *model.Tow) error
 }
 
+// TruckFinder is the minimal dependency TowService needs to check a referenced truck.
+type TruckFinder interface {
+	Find(ctx context.Context, filterModel *model.Truck) ([]*model.Truck, error)
+}
+
+// QuoteRepository persists price quotes and guards them against being booked twice.
+type QuoteRepository interface {
+	Create(ctx context.Context, item *model.Quote) error
+	Find(ctx context.Context, filterModel *model.Quote) ([]*model.Quote, error)
+	Claim(ctx context.Context, id string, towId string) (bool, error)
+	Release(ctx context.Context, id string, towId string) error
+}
+
+// defaultQuoteValidity is how long a quote can be booked when the company has not configured it.
+const defaultQuoteValidity = 24 * time.Hour
+
 type PriceRepositoryForTowService interface {
 	Find(ctx context.Context, filterModel *model.Price) ([]*model.Price, error)
 }
 
+// PriceListFinder is the minimal dependency TowService needs to load published price list versions.
+type PriceListFinder interface {
+	Find(ctx context.Context, filterModel *model.PriceList) ([]*model.PriceList, error)
+}
+
+// TaxRateFinder is the minimal dependency TowService needs to look up a company's tax rates.
+type TaxRateFinder interface {
+	Find(ctx context.Context, filterModel *model.TaxRate) ([]*model.TaxRate, error)
+}
+
+// PromoRedemptionRecorder is the minimal dependency TowService needs to enforce per-customer promo limits
+// and record redemptions.
+type PromoRedemptionRecorder interface {
+	Reserve(ctx context.Context, item *model.PromoRedemption) (bool, error)
+	Find(ctx context.Context, filterModel *model.PromoRedemption) ([]*model.PromoRedemption, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// PricingZoneFinder is the minimal dependency TowService needs to load a company's pricing zones.
+type PricingZoneFinder interface {
+	Find(ctx context.Context, filterModel *model.PricingZone) ([]*model.PricingZone, error)
+}
+
 // TowService defines business logic for the Tow entity.
 type TowService struct {
-	towRepository     TowRepository
-	priceRepository   PriceRepositoryForTowService
-	companyRepository CompanyRepository
-	locationUtility   *utilities.LocationUtility
-	stripeClient      *utilities.StripeUtility
-	emailUtility      *utilities.AmazonSesUtility
+	towRepository             TowRepository
+	priceRepository           PriceRepositoryForTowService
+	priceListRepository       PriceListFinder
+	companyRepository         CompanyRepository
+	truckRepository           TruckFinder
+	quoteRepository           QuoteRepository
+	taxRateRepository         TaxRateFinder
+	promoCodeRepository       PromoCodeRepository
+	promoRedemptionRepository PromoRedemptionRecorder
+	pricingZoneRepository     PricingZoneFinder
+	locationUtility           *utilities.LocationUtility
+	paymentGateway            PaymentGateway
+	emailUtility              EmailSender
 }
 
 // NewTowService creates a new TowService instance.
-func NewTowService(towRepo TowRepository, priceRepo PriceRepositoryForTowService, companyRepo CompanyRepository, locationUtility *utilities.LocationUtility, stripeClient *utilities.StripeUtility, emailUtility *utilities.AmazonSesUtility) *TowService {
+func NewTowService(towRepo TowRepository, priceRepo PriceRepositoryForTowService, priceListRepo PriceListFinder, companyRepo CompanyRepository, truckRepo TruckFinder, quoteRepo QuoteRepository, taxRateRepo TaxRateFinder, promoCodeRepo PromoCodeRepository, promoRedemptionRepo PromoRedemptionRecorder, pricingZoneRepo PricingZoneFinder, locationUtility *utilities.LocationUtility, paymentGateway PaymentGateway, emailUtility EmailSender) *TowService {
 	return &TowService{
-		towRepository:     towRepo,
-		priceRepository:   priceRepo,
-		companyRepository: companyRepo,
-		locationUtility:   locationUtility,
-		stripeClient:      stripeClient,
-		emailUtility:      emailUtility,
+		towRepository:             towRepo,
+		priceRepository:           priceRepo,
+		priceListRepository:       priceListRepo,
+		companyRepository:         companyRepo,
+		truckRepository:           truckRepo,
+		quoteRepository:           quoteRepo,
+		taxRateRepository:         taxRateRepo,
+		promoCodeRepository:       promoCodeRepo,
+		promoRedemptionRepository: promoRedemptionRepo,
+		pricingZoneRepository:     pricingZoneRepo,
+		locationUtility:           locationUtility,
+		paymentGateway:            paymentGateway,
+		emailUtility:              emailUtility,
 	}
 }
 
 // ScheduleTow calculates pricing, creates a payable invoice, persists the tow, and returns the saved entity.
+// When the request carries a quote ID, the tow is booked at the quoted price and trip without re-pricing.
 func (s *TowService) ScheduleTow(ctx context.Context, towRequest *model.Tow, schedulingLink string) (*model.Tow, error) {
 	if towRequest == nil {
 		return nil, fmt.Errorf("tow request is required")
@@ -53,100 +108,128 @@ func (s *TowService) ScheduleTow(ctx context.Context, towRequest *model.Tow, sch
 	if schedulingLink == "" {
 		return nil, fmt.Errorf("schedulingLink is required")
 	}
+	clearServerFields(towRequest)
 
-	// Fetch company information
-	companies, err := s.companyRepository.Find(ctx, &model.Company{
-		SchedulingLink: &schedulingLink,
-	})
+	company, err := s.findCompanyBySchedulingLink(ctx, schedulingLink)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch company: %w", err)
-	}
-	if len(companies) == 0 {
-		return nil, fmt.Errorf("company not found")
+		return nil, err
 	}
 
-	pricingInfo, err := s.priceRepository.Find(ctx, &model.Price{
-		CompanyID: companies[0].ID,
-	})
+	if err := checkCanAcceptPayments(company); err != nil {
+		return nil, err
+	}
 
-	towRequest.CompanyID = companies[0].ID
+	towRequest.CompanyID = company.ID
+	id := uuid.NewString()
+	towRequest.ID = &id
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to load pricing information: %w", err)
+	// A truck named at booking must be the company's and able to run the tow; there is no dispatcher to warn yet
+	if towRequest.TruckID != nil && *towRequest.TruckID != "" {
+		warning, err := s.checkTruckInService(ctx, stringValue(company.ID), *towRequest.TruckID)
+		if err != nil {
+			return nil, err
+		}
+		if warning != "" {
+			return nil, fmt.Errorf("%s", warning)
+		}
 	}
 
-	// Calculate total miles
-	pickupAddress, err := s.locationUtility.ParseGeocodeFromAddress(*towRequest.Pickup)
+	var breakdown *model.PriceBreakdown
+	pricedAt := time.Now().Unix()
+	if towRequest.QuoteID != nil && *towRequest.QuoteID != "" {
+		quote, err := s.claimQuote(ctx, *towRequest.QuoteID, company, id)
+		if err != nil {
+			return nil, err
+		}
+		applyQuote(towRequest, quote)
+		breakdown = quote.Breakdown
+		pricedAt = int64Value(quote.CreatedAt)
+	} else {
+		if towRequest.Pickup == nil || *towRequest.Pickup == "" {
+			return nil, fmt.Errorf("pickup is required")
+		}
+		if towRequest.Destination == nil || *towRequest.Destination == "" {
+			return nil, fmt.Errorf("destination is required")
+		}
+
+		prepareVehicleAndAddOns(towRequest)
+
+		breakdown, err = s.priceTrip(ctx, company, towRequest)
+		if err != nil {
+			return nil, err
+		}
+	}
 
+	total := int(breakdown.Total)
+	towRequest.Price = &total
+	towRequest.LineItems = breakdown.LineItems
+	towRequest.Miles = &breakdown.Miles
+	towRequest.BilledMiles = &breakdown.BilledMiles
+	if breakdown.EnRouteMiles > 0 || breakdown.ReturnMiles > 0 {
+		towRequest.EnRouteMiles = &breakdown.EnRouteMiles
+		towRequest.ReturnMiles = &breakdown.ReturnMiles
+	}
+	towRequest.Minutes = &breakdown.Minutes
+	taxAmount := int(breakdown.TaxAmount)
+	towRequest.TaxAmount = &taxAmount
+
+	discount := int(breakdown.Discount)
+	redemption, err := s.reservePromo(ctx, towRequest, company, pricedAt, discount)
 	if err != nil {
+		s.releaseQuote(ctx, towRequest)
 		return nil, err
 	}
+	if redemption != nil {
+		towRequest.DiscountAmount = &discount
+	}
 
-	destinationAddress, err := s.locationUtility.ParseGeocodeFromAddress(*towRequest.Destination)
-
+	fee, err := applicationFee(company, breakdown.Total)
 	if err != nil {
+		s.releaseQuote(ctx, towRequest)
+		s.releasePromo(ctx, redemption)
 		return nil, err
 	}
 
-	totalMiles, err := s.locationUtility.CalculateDistanceBetweenCoordinates(pickupAddress, destinationAddress)
-
+	accessToken, err := newAccessToken()
 	if err != nil {
+		s.releaseQuote(ctx, towRequest)
+		s.releasePromo(ctx, redemption)
 		return nil, err
 	}
+	towRequest.AccessToken = &accessToken
+	successURL, cancelURL := checkoutReturnURLs(company, id, accessToken)
 
-	// generate the payable line item for the hookup fee
-	var lineItems []model.PayableLineItem
-
-	lineItems = append(lineItems, model.PayableLineItem{
-		Name:     "Hook Up Fee",
-		Amount:   int64(*pricingInfo[0].Amount),
-		Quantity: 1,
-	})
-
-	mileageCost := int64(*pricingInfo[1].Amount) * int64(math.Ceil(totalMiles))
-
-	lineItems = append(lineItems, model.PayableLineItem{
-		Name:     fmt.Sprintf("%f miles at $%f per mile", totalMiles, float64(*pricingInfo[1].Amount/100)), // must divide by 100 to get it into dollar amounts
-		Amount:   mileageCost,
-		Quantity: 1,
-	})
-
-	totalFloat := calculatePriceFromMiles(pricingInfo, totalMiles)
-	total := int(math.Round(totalFloat))
-
-	towRequest.Price = &total
-
-	checkoutSessionId, checkoutURL, err := s.stripeClient.CreatePayableItem(int64(total), lineItems)
+	checkoutSessionId, checkoutURL, err := s.paymentGateway.CreatePayableItem(ctx, id, breakdown.Total, breakdown.LineItems, *company.StripeAccountId, fee, successURL, cancelURL)
 
 	if err != nil {
+		s.releaseQuote(ctx, towRequest)
+		s.releasePromo(ctx, redemption)
 		return nil, fmt.Errorf("failed to create payable item: %w", err)
 	}
 
-	paymentStatus := "unpaid"
+	applicationFeeAmount := int(fee)
+	towRequest.ApplicationFee = &applicationFeeAmount
+
+	paymentStatus := paymentStatusUnpaid
 	towRequest.PaymentStatus = &paymentStatus
+	paid := 0
+	towRequest.PaidAmount = &paid
+	towRequest.BalanceDue = &total
 	towRequest.PaymentReference = &checkoutSessionId
 	towRequest.CheckoutUrl = &checkoutURL
 	now := time.Now().UTC().Unix()
 	towRequest.CreatedAt = &now
-	id := uuid.NewString()
-	towRequest.ID = &id
 	// TODO: update the status to check if the requestor was a driver or company
 	status := "ACCEPTED"
 	towRequest.Status = &status
 
 	if err := s.towRepository.Create(ctx, towRequest); err != nil {
+		s.releaseQuote(ctx, towRequest)
+		s.releasePromo(ctx, redemption)
 		return nil, fmt.Errorf("failed to save tow: %w", err)
 	}
 
-	emailContent, err := s.formatPaymentEmail(ctx, towRequest)
-	if err != nil {
-		return nil, fmt.Errorf("failed to format email: %w", err)
-	}
-
-	subject := "Service Confirmation – Complete Your Payment"
-	err = s.emailUtility.SendEmail(ctx, *towRequest.PrimaryContact.Email, subject, emailContent)
-
-	if err != nil {
+	if err := s.sendPaymentEmail(ctx, towRequest); err != nil {
 		return nil, err
 	}
 
@@ -171,92 +254,645 @@ func (s *TowService) FindTowsByCompanyId(ctx context.Context, companyId string)
 }
 
 // UpdateTow updates a tow by its ID with the provided partial fields.
-func (s *TowService) UpdateTow(ctx context.Context, towId string, update *model.Tow) error {
+// Returns warnings for the dispatcher, e.g. when the assigned truck is out of service.
+// Dispatch may override the vehicle class inferred at booking. The override does not reprice the tow: it is
+// what price list simulations and later bookings see, and dispatch sets the price in the same update to charge for it.
+func (s *TowService) UpdateTow(ctx context.Context, towId string, update *model.Tow) ([]string, error) {
 	if towId == "" {
-		return fmt.Errorf("tow id is required")
+		return nil, fmt.Errorf("tow id is required")
 	}
 	if update == nil {
-		return fmt.Errorf("update body is required")
+		return nil, fmt.Errorf("update body is required")
+	}
+	if field := ledgerField(update); field != "" {
+		return nil, fmt.Errorf("%s cannot be updated; it is kept by the payment ledger", field)
+	}
+
+	if update.Vehicle != nil && update.Vehicle.Class != nil {
+		if !isValidVehicleClass(*update.Vehicle.Class) {
+			return nil, fmt.Errorf("unknown vehicle class %q", *update.Vehicle.Class)
+		}
+		class := strings.ToLower(*update.Vehicle.Class)
+		update.Vehicle.Class = &class
+	}
+
+	// Stamp status transitions used for driver hourly pay
+	if update.Status != nil {
+		now := time.Now().UTC().Unix()
+		switch strings.ToUpper(*update.Status) {
+		case "DISPATCHED":
+			if update.DispatchedAt == nil {
+				update.DispatchedAt = &now
+			}
+		case "COMPLETED":
+			if update.CompletedAt == nil {
+				update.CompletedAt = &now
+			}
+		}
+	}
+
+	var warnings []string
+	if update.TruckID != nil && *update.TruckID != "" {
+		tows, err := s.towRepository.Find(ctx, &model.Tow{ID: &towId})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find tow: %w", err)
+		}
+		if len(tows) == 0 {
+			return nil, fmt.Errorf("tow not found")
+		}
+
+		warning, err := s.checkTruckInService(ctx, stringValue(tows[0].CompanyID), *update.TruckID)
+		if err != nil {
+			return nil, err
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
 	}
 
 	if err := s.towRepository.Update(ctx, towId, update); err != nil {
-		return fmt.Errorf("update tow failed: %w", err)
+		return nil, fmt.Errorf("update tow failed: %w", err)
+	}
+
+	// A price change moves the balance due
+	if update.Price != nil {
+		if err := s.settleTowPayments(ctx, towId); err != nil {
+			return nil, err
+		}
+	}
+	return warnings, nil
+}
+
+// clearServerFields drops what a customer cannot set on a booking: the payment ledger, refunds, dispute, invoice
+// and checkout state, kept by the payment service, the pricing details the server works out, and the driver and
+// dispatch times set by dispatch.
+func clearServerFields(tow *model.Tow) {
+	tow.Payments = nil
+	tow.Refunds = nil
+	tow.RefundedAmount = nil
+	tow.Dispute = nil
+	tow.PaidAmount = nil
+	tow.BalanceDue = nil
+	tow.PaymentIntentID = nil
+	tow.CheckoutEventAt = nil
+	tow.ApplicationFee = nil
+	tow.InvoiceNumber = nil
+	tow.InvoicedAt = nil
+	tow.DiscountAmount = nil
+	tow.PriceListID = nil
+	tow.PriceListVersion = nil
+	tow.PricingZoneID = nil
+	tow.EnRouteMiles = nil
+	tow.ReturnMiles = nil
+	tow.DriverID = nil
+	tow.DispatchedAt = nil
+	tow.CompletedAt = nil
+}
+
+// ledgerField returns the JSON name of the first field in a tow update that only the payment service may change,
+// or an empty string. Payments, refunds and disputes change through RecordPayment, syncRefunds and recordDispute,
+// and the checkout through RegeneratePaymentLink; the invoice number is assigned once.
+func ledgerField(update *model.Tow) string {
+	switch {
+	case update.Payments != nil:
+		return "payments"
+	case update.Refunds != nil:
+		return "refunds"
+	case update.RefundedAmount != nil:
+		return "refundedAmount"
+	case update.Dispute != nil:
+		return "dispute"
+	case update.PaidAmount != nil:
+		return "paidAmount"
+	case update.BalanceDue != nil:
+		return "balanceDue"
+	case update.PaymentStatus != nil:
+		return "paymentStatus"
+	case update.PaymentReference != nil:
+		return "paymentReference"
+	case update.PaymentIntentID != nil:
+		return "paymentIntentId"
+	case update.CheckoutUrl != nil:
+		return "checkoutUrl"
+	case update.CheckoutEventAt != nil:
+		return "checkoutEventAt"
+	case update.ApplicationFee != nil:
+		return "applicationFee"
+	case update.InvoiceNumber != nil:
+		return "invoiceNumber"
+	case update.InvoicedAt != nil:
+		return "invoicedAt"
+	}
+	return ""
+}
+
+// settleTowPayments recomputes the tow's balance due and payment status after its price changed.
+func (s *TowService) settleTowPayments(ctx context.Context, towId string) error {
+	tows, err := s.towRepository.Find(ctx, &model.Tow{ID: &towId})
+	if err != nil {
+		return fmt.Errorf("failed to find tow: %w", err)
+	}
+	if len(tows) == 0 {
+		return fmt.Errorf("tow not found")
+	}
+
+	if err := s.towRepository.Update(ctx, towId, settledPayment(tows[0])); err != nil {
+		return fmt.Errorf("failed to update tow balance: %w", err)
 	}
 	return nil
 }
 
-// GetEstimate calculates and returns a price estimate for a tow request without creating a tow or payment reference.
-func (s *TowService) GetEstimate(ctx context.Context, companySchedulingLink string, pickup string, dropoff string) (int64, error) {
+// checkTruckInService returns a warning if the truck is out of service. The truck must belong to the company.
+func (s *TowService) checkTruckInService(ctx context.Context, companyId string, truckId string) (string, error) {
+	if companyId == "" {
+		return "", fmt.Errorf("truck not found")
+	}
+	trucks, err := s.truckRepository.Find(ctx, &model.Truck{ID: &truckId, CompanyID: &companyId})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch truck: %w", err)
+	}
+	if len(trucks) == 0 {
+		return "", fmt.Errorf("truck not found")
+	}
+
+	truck := trucks[0]
+	if truck.Status != nil && *truck.Status == truckStatusOutOfService {
+		return fmt.Sprintf("truck %s is out of service", stringValue(truck.UnitNumber)), nil
+	}
+
+	return "", nil
+}
+
+// GetEstimate prices a tow request without saving anything. The quote it returns has no ID or link and cannot be
+// booked; use CreateQuote for that. The request carries the pickup, destination and, optionally, the scheduled time,
+// vehicle and add-ons.
+func (s *TowService) GetEstimate(ctx context.Context, companySchedulingLink string, request *model.Tow) (*model.Quote, error) {
+	quote, _, err := s.priceQuote(ctx, companySchedulingLink, request)
+	return quote, err
+}
+
+// CreateQuote prices a tow request like GetEstimate and saves the result as a quote the customer can book until it
+// expires.
+func (s *TowService) CreateQuote(ctx context.Context, companySchedulingLink string, request *model.Tow) (*model.Quote, error) {
+	quote, company, err := s.priceQuote(ctx, companySchedulingLink, request)
+	if err != nil {
+		return nil, err
+	}
+
+	validity := defaultQuoteValidity
+	if company.QuoteValidMinutes != nil && *company.QuoteValidMinutes > 0 {
+		validity = time.Duration(*company.QuoteValidMinutes) * time.Minute
+	}
+
+	now := time.Now().UTC()
+	id := uuid.NewString()
+	link := fmt.Sprintf("%s/quotes/%s", platformWebsite(), id)
+	createdAt := now.Unix()
+	expiresAt := now.Add(validity).Unix()
+
+	quote.ID = &id
+	quote.Link = &link
+	quote.CreatedAt = &createdAt
+	quote.ExpiresAt = &expiresAt
+
+	if err := s.quoteRepository.Create(ctx, quote); err != nil {
+		return nil, fmt.Errorf("failed to save quote: %w", err)
+	}
+
+	return quote, nil
+}
+
+// priceQuote validates and prices a tow request for the company behind the scheduling link.
+func (s *TowService) priceQuote(ctx context.Context, companySchedulingLink string, request *model.Tow) (*model.Quote, *model.Company, error) {
 	if companySchedulingLink == "" {
-		return 0, fmt.Errorf("company id is required")
+		return nil, nil, fmt.Errorf("company id is required")
 	}
-	if pickup == "" {
-		return 0, fmt.Errorf("pickup is required")
+	if request == nil {
+		return nil, nil, fmt.Errorf("estimate request is required")
 	}
-	if dropoff == "" {
-		return 0, fmt.Errorf("dropoff is required")
+	if request.Pickup == nil || *request.Pickup == "" {
+		return nil, nil, fmt.Errorf("pickup is required")
+	}
+	if request.Destination == nil || *request.Destination == "" {
+		return nil, nil, fmt.Errorf("dropoff is required")
 	}
 
-	// Fetch company information
+	company, err := s.findCompanyBySchedulingLink(ctx, companySchedulingLink)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prepareVehicleAndAddOns(request)
+
+	breakdown, err := s.priceTrip(ctx, company, request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	quote := &model.Quote{
+		CompanyID:              company.ID,
+		Pickup:                 request.Pickup,
+		Destination:            request.Destination,
+		PickupCoordinates:      request.PickupCoordinates,
+		DestinationCoordinates: request.DestinationCoordinates,
+		Vehicle:                request.Vehicle,
+		AddOns:                 request.AddOns,
+		ScheduledAt:            request.ScheduledAt,
+		Breakdown:              breakdown,
+		PriceListID:            request.PriceListID,
+		PriceListVersion:       request.PriceListVersion,
+		PromoCode:              request.PromoCode,
+		PricingZoneID:          request.PricingZoneID,
+	}
+
+	return quote, company, nil
+}
+
+// FindQuoteById retrieves a saved quote.
+func (s *TowService) FindQuoteById(ctx context.Context, quoteId string) (*model.Quote, error) {
+	if quoteId == "" {
+		return nil, fmt.Errorf("quote id is required")
+	}
+
+	quotes, err := s.quoteRepository.Find(ctx, &model.Quote{ID: &quoteId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch quote: %w", err)
+	}
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("quote not found")
+	}
+
+	return quotes[0], nil
+}
+
+// claimQuote checks that a quote belongs to the company and is still valid, then reserves it for towId.
+func (s *TowService) claimQuote(ctx context.Context, quoteId string, company *model.Company, towId string) (*model.Quote, error) {
+	quote, err := s.FindQuoteById(ctx, quoteId)
+	if err != nil {
+		return nil, err
+	}
+
+	if stringValue(quote.CompanyID) != stringValue(company.ID) {
+		return nil, fmt.Errorf("quote not found")
+	}
+	if quote.ExpiresAt != nil && time.Now().Unix() >= *quote.ExpiresAt {
+		return nil, fmt.Errorf("quote has expired")
+	}
+	if quote.Breakdown == nil {
+		return nil, fmt.Errorf("quote has no price")
+	}
+
+	claimed, err := s.quoteRepository.Claim(ctx, quoteId, towId)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("quote has already been booked")
+	}
+
+	return quote, nil
+}
+
+// releaseQuote frees the tow's quote after a failed booking so the customer can try again.
+func (s *TowService) releaseQuote(ctx context.Context, towRequest *model.Tow) {
+	if towRequest.QuoteID == nil || *towRequest.QuoteID == "" {
+		return
+	}
+	if err := s.quoteRepository.Release(ctx, *towRequest.QuoteID, stringValue(towRequest.ID)); err != nil {
+		log.Println(err.Error())
+	}
+}
+
+// applyQuote copies the priced trip from a quote onto a tow request. The customer supplies contact details
+// and may add to the vehicle description, but the trip and everything that affected the price come from the quote.
+func applyQuote(towRequest *model.Tow, quote *model.Quote) {
+	towRequest.Pickup = quote.Pickup
+	towRequest.Destination = quote.Destination
+	towRequest.PickupCoordinates = quote.PickupCoordinates
+	towRequest.DestinationCoordinates = quote.DestinationCoordinates
+	towRequest.ScheduledAt = quote.ScheduledAt
+	towRequest.AddOns = quote.AddOns
+	towRequest.PriceListID = quote.PriceListID
+	towRequest.PriceListVersion = quote.PriceListVersion
+	towRequest.PromoCode = quote.PromoCode
+	towRequest.PricingZoneID = quote.PricingZoneID
+
+	if towRequest.Vehicle == nil {
+		towRequest.Vehicle = quote.Vehicle
+	} else if quote.Vehicle != nil {
+		towRequest.Vehicle.Class = quote.Vehicle.Class
+	}
+}
+
+// prepareVehicleAndAddOns infers the vehicle class of a customer's request from the make and model and normalizes
+// its add-ons. A class sent by the customer is ignored; only dispatch may override it.
+func prepareVehicleAndAddOns(towRequest *model.Tow) {
+	if towRequest.Vehicle == nil {
+		towRequest.Vehicle = &model.Vehicle{}
+	}
+
+	class := inferVehicleClass(towRequest.Vehicle)
+	towRequest.Vehicle.Class = &class
+	towRequest.AddOns = normalizeAddOns(towRequest.AddOns)
+}
+
+// findCompanyBySchedulingLink resolves the company that owns a public scheduling link.
+func (s *TowService) findCompanyBySchedulingLink(ctx context.Context, schedulingLink string) (*model.Company, error) {
 	companies, err := s.companyRepository.Find(ctx, &model.Company{
-		SchedulingLink: &companySchedulingLink,
+		SchedulingLink: &schedulingLink,
 	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch company: %w", err)
+		return nil, fmt.Errorf("failed to fetch company: %w", err)
 	}
 	if len(companies) == 0 {
-		return 0, fmt.Errorf("company not found")
+		return nil, fmt.Errorf("company not found")
 	}
 
-	// load prices
-	pricingInfo, err := s.priceRepository.Find(ctx, &model.Price{
-		CompanyID: companies[0].ID,
-	})
+	return companies[0], nil
+}
 
+// priceTrip routes the tow from pickup to destination and prices it with the company's price list.
+// Both GetEstimate and ScheduleTow price through here so an estimate and the booked tow always agree.
+// The geocoded coordinates and the price list version used are recorded on the request.
+func (s *TowService) priceTrip(ctx context.Context, company *model.Company, towRequest *model.Tow) (*model.PriceBreakdown, error) {
+	now := time.Now()
+
+	pricingInfo, err := s.loadPrices(ctx, company, now, towRequest)
 	if err != nil {
-		return 0, fmt.Errorf("failed to load pricing information: %w", err)
+		return nil, err
 	}
 
 	// Convert the addresses to geo positions
-	pickupCoordinates, err := s.locationUtility.ParseGeocodeFromAddress(pickup)
+	pickup, err := s.locationUtility.GeocodeAddress(*towRequest.Pickup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pickup location: %w", err)
+	}
+	pickupCoordinates := pickup.Position
+
+	destination, err := s.locationUtility.GeocodeAddress(*towRequest.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse destination location: %w", err)
+	}
+	destinationCoordinates := destination.Position
+
+	towRequest.PickupCoordinates = pickupCoordinates
+	towRequest.DestinationCoordinates = destinationCoordinates
+
+	miles, minutes, err := s.locationUtility.CalculateRouteBetweenCoordinates(pickupCoordinates, destinationCoordinates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate route: %w", err)
+	}
+
+	input, err := newPricingInput(company, towRequest, miles, minutes, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// En-route and return miles need two more routes, so they are only calculated when the price list charges them
+	if usesYardMiles(pricingInfo) {
+		yardCoordinates, err := s.companyYard(ctx, company)
+		if err != nil {
+			return nil, err
+		}
+
+		input.EnRouteMiles, _, err = s.locationUtility.CalculateRouteBetweenCoordinates(yardCoordinates, pickupCoordinates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate en-route miles: %w", err)
+		}
+		input.ReturnMiles, _, err = s.locationUtility.CalculateRouteBetweenCoordinates(destinationCoordinates, yardCoordinates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate return miles: %w", err)
+		}
+	}
+
+	// Sales tax follows the pickup location, where the service starts
+	taxRates, err := s.taxRateRepository.Find(ctx, &model.TaxRate{CompanyID: company.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tax rates: %w", err)
+	}
+	if rate := matchTaxRate(taxRates, pickup.State, pickup.County, pickup.PostalCode); rate != nil {
+		input.TaxRate = *rate.Rate
+		input.TaxName = stringValue(rate.Name)
+	}
+
+	// Zones are checked before falling back to the price list's mileage pricing
+	zones, err := s.pricingZoneRepository.Find(ctx, &model.PricingZone{CompanyID: company.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pricing zones: %w", err)
+	}
+	if zone := matchPricingZone(zones, pickup, destination); zone != nil {
+		towRequest.PricingZoneID = zone.ID
+		input.Zone = zone
+	}
+
+	if towRequest.PromoCode != nil && *towRequest.PromoCode != "" {
+		promo, err := s.findPromoCode(ctx, company, *towRequest.PromoCode)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPromoAvailable(promo, now.Unix()); err != nil {
+			return nil, err
+		}
+		towRequest.PromoCode = promo.Code
+		input.Promo = promo
+	}
+
+	breakdown, err := calculatePrice(pricingInfo, input)
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse pickup location: %w", err)
+		return nil, fmt.Errorf("failed to calculate tow price: %w", err)
 	}
 
-	dropoffCoordinates, err := s.locationUtility.ParseGeocodeFromAddress(dropoff)
+	return breakdown, nil
+}
+
+// companyYard returns the coordinates of the company's yard (its street address). The geocode is cached on the
+// company and refreshed when the address changes.
+func (s *TowService) companyYard(ctx context.Context, company *model.Company) ([]float64, error) {
+	var parts []string
+	for _, part := range []*string{company.Street, company.City, company.State, company.ZipCode} {
+		if part != nil && strings.TrimSpace(*part) != "" {
+			parts = append(parts, strings.TrimSpace(*part))
+		}
+	}
+	if company.Street == nil || *company.Street == "" {
+		return nil, fmt.Errorf("company yard address is required for en-route pricing")
+	}
+	address := strings.Join(parts, ", ")
+
+	if len(company.YardCoordinates) == 2 && stringValue(company.YardGeocodedAddress) == address {
+		return company.YardCoordinates, nil
+	}
+
+	coordinates, err := s.locationUtility.ParseGeocodeFromAddress(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to geocode company yard: %w", err)
+	}
+
+	// Caching is best effort; pricing can go ahead with the fresh geocode
+	if err := s.companyRepository.Update(ctx, stringValue(company.ID), &model.Company{
+		YardCoordinates:     coordinates,
+		YardGeocodedAddress: &address,
+	}); err != nil {
+		log.Printf("failed to cache yard coordinates for company %s: %s", stringValue(company.ID), err.Error())
+	}
+
+	return coordinates, nil
+}
+
+// loadPrices returns the rules of the company's published price list in effect at the given time and
+// records the version on the request. Companies that have never published a list use the legacy price catalog.
+func (s *TowService) loadPrices(ctx context.Context, company *model.Company, at time.Time, towRequest *model.Tow) ([]*model.Price, error) {
+	published := priceListStatusPublished
+	lists, err := s.priceListRepository.Find(ctx, &model.PriceList{
+		CompanyID: company.ID,
+		Status:    &published,
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse dropoff location: %w", err)
+		return nil, fmt.Errorf("failed to load price lists: %w", err)
 	}
 
-	totalMiles, err := s.locationUtility.CalculateDistanceBetweenCoordinates(pickupCoordinates, dropoffCoordinates)
+	if active := selectActivePriceList(lists, at.Unix()); active != nil {
+		towRequest.PriceListID = active.ID
+		towRequest.PriceListVersion = active.Version
+		return active.Prices, nil
+	}
+
+	prices, err := s.priceRepository.Find(ctx, &model.Price{
+		CompanyID: company.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pricing information: %w", err)
+	}
 
-	totalAmount := calculatePriceFromMiles(pricingInfo, totalMiles)
+	return activePrices(prices), nil
+}
 
+// findPromoCode looks up one of the company's promo codes, ignoring case.
+func (s *TowService) findPromoCode(ctx context.Context, company *model.Company, code string) (*model.PromoCode, error) {
+	code = normalizePromoCode(code)
+	promos, err := s.promoCodeRepository.Find(ctx, &model.PromoCode{CompanyID: company.ID, Code: &code})
 	if err != nil {
-		return 0, fmt.Errorf("failed to calculate tow price: %w", err)
+		return nil, fmt.Errorf("failed to fetch promo code: %w", err)
+	}
+	if len(promos) == 0 {
+		return nil, fmt.Errorf("promo code not found")
 	}
 
-	return int64(totalAmount), nil
+	return promos[0], nil
 }
 
-// calculatePriceFromMiles calculates the total price in cents based on pricing information and total miles.
-// Prices array always contains an item with "Hook Up Fee" and "Per Mile Amount".
-func calculatePriceFromMiles(prices []*model.Price, totalMiles float64) float64 {
-	total := 0.0
+// reservePromo enforces the limits of the tow's promo code, counts the booking against it and records the
+// redemption. pricedAt is when the price was calculated, so a quote priced while the code was valid can still be
+// booked. It returns nil when the tow has no promo code; releasePromo undoes the reservation of a failed booking.
+func (s *TowService) reservePromo(ctx context.Context, towRequest *model.Tow, company *model.Company, pricedAt int64, discount int) (*model.PromoRedemption, error) {
+	if towRequest.PromoCode == nil || *towRequest.PromoCode == "" {
+		return nil, nil
+	}
+
+	promo, err := s.findPromoCode(ctx, company, *towRequest.PromoCode)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPromoAvailable(promo, pricedAt); err != nil {
+		return nil, err
+	}
 
-	for _, price := range prices {
-		if price.ItemName == nil || price.Amount == nil {
-			continue
+	id := uuid.NewString()
+	email := customerEmail(towRequest)
+	now := time.Now().UTC().Unix()
+	redemption := &model.PromoRedemption{
+		ID:             &id,
+		CompanyID:      company.ID,
+		PromoCodeID:    promo.ID,
+		Code:           promo.Code,
+		TowID:          towRequest.ID,
+		DiscountAmount: &discount,
+		RedeemedAt:     &now,
+	}
+	if email != "" {
+		redemption.CustomerEmail = &email
+	}
+
+	if promo.MaxUsesPerCustomer != nil {
+		if email == "" {
+			return nil, fmt.Errorf("primary contact email is required to use this promo code")
+		}
+		reserved, err := s.reserveCustomerUse(ctx, promo, redemption)
+		if err != nil {
+			return nil, err
 		}
+		if !reserved {
+			return nil, fmt.Errorf("promo code has already been used by this customer")
+		}
+	} else if _, err := s.promoRedemptionRepository.Reserve(ctx, redemption); err != nil {
+		return nil, fmt.Errorf("failed to record promo redemption: %w", err)
+	}
+
+	reserved, err := s.promoCodeRepository.ReserveUse(ctx, stringValue(promo.ID))
+	if err == nil && !reserved {
+		err = fmt.Errorf("promo code has reached its usage limit")
+	}
+	if err != nil {
+		s.deletePromoRedemption(ctx, redemption)
+		return nil, err
+	}
+
+	return redemption, nil
+}
+
+// reserveCustomerUse records the redemption in the first free use of the customer's MaxUsesPerCustomer. Each use has
+// an ID derived from the code, the customer and the use number, so two bookings racing for the customer's last use
+// cannot both record it. It returns false when the customer has no use left.
+func (s *TowService) reserveCustomerUse(ctx context.Context, promo *model.PromoCode, redemption *model.PromoRedemption) (bool, error) {
+	redemptions, err := s.promoRedemptionRepository.Find(ctx, &model.PromoRedemption{
+		PromoCodeID:   promo.ID,
+		CustomerEmail: redemption.CustomerEmail,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch promo redemptions: %w", err)
+	}
+
+	for use := len(redemptions) + 1; use <= *promo.MaxUsesPerCustomer; use++ {
+		key := fmt.Sprintf("%s|%s|%d", stringValue(promo.ID), stringValue(redemption.CustomerEmail), use)
+		id := uuid.NewSHA1(uuid.NameSpaceOID, []byte(key)).String()
+		redemption.ID = &id
 
-		if *price.ItemName == "Hook Up Fee" {
-			total += float64(*price.Amount)
-		} else if *price.ItemName == "Per Mile Amount" {
-			total += float64(*price.Amount) * totalMiles
+		reserved, err := s.promoRedemptionRepository.Reserve(ctx, redemption)
+		if err != nil {
+			return false, fmt.Errorf("failed to record promo redemption: %w", err)
 		}
+		if reserved {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// releasePromo gives back a promo code use and removes its redemption after a failed booking.
+func (s *TowService) releasePromo(ctx context.Context, redemption *model.PromoRedemption) {
+	if redemption == nil {
+		return
+	}
+	if err := s.promoCodeRepository.ReleaseUse(ctx, stringValue(redemption.PromoCodeID)); err != nil {
+		log.Println(err.Error())
+	}
+	s.deletePromoRedemption(ctx, redemption)
+}
+
+// deletePromoRedemption removes a redemption recorded for a booking that did not go through.
+func (s *TowService) deletePromoRedemption(ctx context.Context, redemption *model.PromoRedemption) {
+	if err := s.promoRedemptionRepository.Delete(ctx, stringValue(redemption.ID)); err != nil {
+		log.Println(err.Error())
 	}
+}
 
-	return total
+// customerEmail returns the tow's primary contact email, lowercased.
+func customerEmail(tow *model.Tow) string {
+	if tow.PrimaryContact == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(stringValue(tow.PrimaryContact.Email)))
 }
 
 // formatPaymentEmail formats the payment confirmation email content using company and tow information.
@@ -307,11 +943,6 @@ func (s *TowService) formatPaymentEmail(ctx context.Context, towRequest *model.T
 		platformSupportEmail = "support@towmanagementplatform.com"
 	}
 
-	platformWebsite := os.Getenv("PLATFORM_WEBSITE")
-	if platformWebsite == "" {
-		platformWebsite = "https://towmanagementplatform.com"
-	}
-
 	// Format the email content
 	emailContent := fmt.Sprintf(`Thank you for choosing %s for your service.
 
@@ -335,7 +966,16 @@ Best regards,
 %s
 Customer Support Team
 %s
-`, platformName, platformWebsite)
+`, platformName, platformWebsite())
 
 	return emailContent, nil
 }
+
+// platformWebsite returns the public website used in emails and shareable links.
+func platformWebsite() string {
+	website := os.Getenv("PLATFORM_WEBSITE")
+	if website == "" {
+		website = "https://towmanagementplatform.com"
+	}
+	return website
+}
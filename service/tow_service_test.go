@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"tow-management-system-api/model"
+)
+
+func TestUpdateTowChecksTruck(t *testing.T) {
+	str := func(s string) *string { return &s }
+	ctx := context.Background()
+
+	tows := &memoryTows{}
+	if err := tows.Create(ctx, &model.Tow{ID: str("tow-1"), CompanyID: str("company-1")}); err != nil {
+		t.Fatal(err)
+	}
+	trucks := &memoryStore[model.Truck]{}
+	for _, truck := range []*model.Truck{
+		{ID: str("truck-ok"), CompanyID: str("company-1"), Status: str(truckStatusInService)},
+		{ID: str("truck-down"), CompanyID: str("company-1"), UnitNumber: str("7"), Status: str(truckStatusOutOfService)},
+		{ID: str("truck-other"), CompanyID: str("company-2"), Status: str(truckStatusInService)},
+	} {
+		if err := trucks.Create(ctx, truck); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := NewTowService(tows, nil, nil, nil, trucks, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		truckId      string
+		wantWarnings int
+		wantErr      string
+	}{
+		{truckId: "truck-ok"},
+		{truckId: "truck-down", wantWarnings: 1},
+		{truckId: "truck-other", wantErr: "truck not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.truckId, func(t *testing.T) {
+			warnings, err := svc.UpdateTow(ctx, "tow-1", &model.Tow{TruckID: str(tt.truckId)})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("UpdateTow() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateTow() error = %v", err)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("UpdateTow() warnings = %v, want %d", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+	"tow-management-system-api/model"
+)
+
+// validVehicleClasses lists the classes a vehicle or price condition may use.
+var validVehicleClasses = map[string]struct{}{
+	model.VehicleClassLight:      {},
+	model.VehicleClassMedium:     {},
+	model.VehicleClassHeavy:      {},
+	model.VehicleClassMotorcycle: {},
+	model.VehicleClassExotic:     {},
+}
+
+// Makes that identify a vehicle class on their own. Keys are lowercase with spaces and hyphens removed.
+var (
+	motorcycleMakes = map[string]struct{}{
+		"harleydavidson": {}, "harley": {}, "ducati": {}, "triumph": {}, "ktm": {}, "indian": {},
+		"aprilia": {}, "mvagusta": {}, "motoguzzi": {}, "royalenfield": {}, "husqvarna": {}, "vespa": {},
+	}
+	exoticMakes = map[string]struct{}{
+		"ferrari": {}, "lamborghini": {}, "mclaren": {}, "bugatti": {}, "rollsroyce": {}, "bentley": {},
+		"astonmartin": {}, "maserati": {}, "lotus": {}, "pagani": {}, "koenigsegg": {},
+	}
+	heavyMakes = map[string]struct{}{
+		"freightliner": {}, "peterbilt": {}, "kenworth": {}, "mack": {}, "westernstar": {},
+		"international": {}, "navistar": {},
+	}
+)
+
+// Model patterns matched against the lowercase model name.
+var (
+	heavyModelPattern  = regexp.MustCompile(`\b(f-?(450|550|650|750)|[cr]?[4-8]500(hd)?|box truck|bus|semi)\b`)
+	mediumModelPattern = regexp.MustCompile(`\b(f-?(250|350)|[0-9]*(2500|3500)(hd)?|econoline|sprinter|transit|express|savana|promaster)\b`)
+)
+
+// inferVehicleClass guesses a vehicle's class from its make and model, defaulting to light duty.
+func inferVehicleClass(vehicle *model.Vehicle) string {
+	if vehicle == nil {
+		return model.VehicleClassLight
+	}
+
+	vehicleMake := strings.NewReplacer(" ", "", "-", "").Replace(strings.ToLower(stringValue(vehicle.Make)))
+	vehicleModel := strings.ToLower(stringValue(vehicle.Model))
+
+	if _, ok := motorcycleMakes[vehicleMake]; ok {
+		return model.VehicleClassMotorcycle
+	}
+	if _, ok := exoticMakes[vehicleMake]; ok {
+		return model.VehicleClassExotic
+	}
+	if _, ok := heavyMakes[vehicleMake]; ok {
+		return model.VehicleClassHeavy
+	}
+	if heavyModelPattern.MatchString(vehicleModel) {
+		return model.VehicleClassHeavy
+	}
+	if mediumModelPattern.MatchString(vehicleModel) {
+		return model.VehicleClassMedium
+	}
+
+	return model.VehicleClassLight
+}
+
+// resolveVehicleClass returns the vehicle's class, inferring it when dispatch has not set one.
+func resolveVehicleClass(vehicle *model.Vehicle) string {
+	if vehicle != nil && vehicle.Class != nil && *vehicle.Class != "" {
+		return strings.ToLower(*vehicle.Class)
+	}
+	return inferVehicleClass(vehicle)
+}
+
+// isValidVehicleClass reports whether class is one of the supported vehicle classes.
+func isValidVehicleClass(class string) bool {
+	_, ok := validVehicleClasses[strings.ToLower(class)]
+	return ok
+}
+
+// normalizeAddOns lowercases and de-duplicates add-on names.
+func normalizeAddOns(addOns []string) []string {
+	seen := map[string]struct{}{}
+	var normalized []string
+	for _, addOn := range addOns {
+		addOn = strings.ToLower(strings.TrimSpace(addOn))
+		if addOn == "" {
+			continue
+		}
+		if _, ok := seen[addOn]; ok {
+			continue
+		}
+		seen[addOn] = struct{}{}
+		normalized = append(normalized, addOn)
+	}
+	return normalized
+}
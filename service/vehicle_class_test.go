@@ -0,0 +1,58 @@
+package service
+
+import (
+	"testing"
+	"tow-management-system-api/model"
+)
+
+func TestInferVehicleClass(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name    string
+		vehicle *model.Vehicle
+		want    string
+	}{
+		{name: "no vehicle", vehicle: nil, want: model.VehicleClassLight},
+		{name: "sedan", vehicle: &model.Vehicle{Make: str("Toyota"), Model: str("Camry")}, want: model.VehicleClassLight},
+		{name: "half-ton pickup", vehicle: &model.Vehicle{Make: str("Ford"), Model: str("F-150")}, want: model.VehicleClassLight},
+		{name: "three-quarter-ton pickup", vehicle: &model.Vehicle{Make: str("Ford"), Model: str("F-250")}, want: model.VehicleClassMedium},
+		{name: "heavy duty pickup", vehicle: &model.Vehicle{Make: str("Chevrolet"), Model: str("Silverado 3500HD")}, want: model.VehicleClassMedium},
+		{name: "cargo van", vehicle: &model.Vehicle{Make: str("Mercedes-Benz"), Model: str("Sprinter")}, want: model.VehicleClassMedium},
+		{name: "chassis cab", vehicle: &model.Vehicle{Make: str("Ford"), Model: str("F-550")}, want: model.VehicleClassHeavy},
+		{name: "box truck", vehicle: &model.Vehicle{Make: str("Isuzu"), Model: str("NPR Box Truck")}, want: model.VehicleClassHeavy},
+		{name: "semi make", vehicle: &model.Vehicle{Make: str("Freightliner"), Model: str("Cascadia")}, want: model.VehicleClassHeavy},
+		{name: "make with spaces and case", vehicle: &model.Vehicle{Make: str("Western Star"), Model: str("49X")}, want: model.VehicleClassHeavy},
+		{name: "motorcycle make", vehicle: &model.Vehicle{Make: str("Harley-Davidson"), Model: str("Street Glide")}, want: model.VehicleClassMotorcycle},
+		{name: "exotic make", vehicle: &model.Vehicle{Make: str("Aston Martin"), Model: str("DB11")}, want: model.VehicleClassExotic},
+		{name: "class sent with the vehicle is not trusted", vehicle: &model.Vehicle{Make: str("Peterbilt"), Model: str("579"), Class: str(model.VehicleClassLight)}, want: model.VehicleClassHeavy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferVehicleClass(tt.vehicle); got != tt.want {
+				t.Errorf("inferVehicleClass() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrepareVehicleAndAddOnsIgnoresTheCustomersClass(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	request := &model.Tow{
+		Vehicle: &model.Vehicle{Make: str("Isuzu"), Model: str("NPR Box Truck"), Class: str(model.VehicleClassLight)},
+		AddOns:  []string{" Dolly", "dolly", ""},
+	}
+	prepareVehicleAndAddOns(request)
+
+	if got := stringValue(request.Vehicle.Class); got != model.VehicleClassHeavy {
+		t.Errorf("class = %q, want %q inferred from the box truck", got, model.VehicleClassHeavy)
+	}
+	if len(request.AddOns) != 1 || request.AddOns[0] != "dolly" {
+		t.Errorf("add-ons = %v, want [dolly]", request.AddOns)
+	}
+	if resolveVehicleClass(&model.Vehicle{Make: str("Toyota"), Class: str("Heavy")}) != model.VehicleClassHeavy {
+		t.Errorf("resolveVehicleClass() ignored the class dispatch set on a stored tow")
+	}
+}
@@ -75,6 +75,23 @@ const (
 	CompanyCollection = "companies"
 	TowCollection     = "tows"
 	PriceCollection   = "prices"
+
+	CommissionRuleCollection = "commission_rules"
+	PayrollRunCollection     = "payroll_runs"
+
+	TruckCollection      = "trucks"
+	InspectionCollection = "inspections"
+	WorkOrderCollection  = "work_orders"
+
+	QuoteCollection     = "quotes"
+	PriceListCollection = "price_lists"
+	TaxRateCollection   = "tax_rates"
+
+	PromoCodeCollection       = "promo_codes"
+	PromoRedemptionCollection = "promo_redemptions"
+	PricingZoneCollection     = "pricing_zones"
+
+	WebhookEventCollection = "webhook_events"
 )
 
 // CreateUserRepository returns a Mongo-backed user repository.
@@ -100,3 +117,75 @@ func (d *Database) CreatePriceRepository() *repository.PriceMongoRepository {
 	coll := PriceCollection
 	return repository.NewMongoPriceRepository(d.db, coll)
 }
+
+// CreateCommissionRuleRepository returns a Mongo-backed commission rule repository.
+func (d *Database) CreateCommissionRuleRepository() *repository.CommissionRuleMongoRepository {
+	coll := CommissionRuleCollection
+	return repository.NewMongoCommissionRuleRepository(d.db, coll)
+}
+
+// CreatePayrollRunRepository returns a Mongo-backed payroll run repository.
+func (d *Database) CreatePayrollRunRepository() *repository.PayrollRunMongoRepository {
+	coll := PayrollRunCollection
+	return repository.NewMongoPayrollRunRepository(d.db, coll)
+}
+
+// CreateTruckRepository returns a Mongo-backed truck repository.
+func (d *Database) CreateTruckRepository() *repository.TruckMongoRepository {
+	coll := TruckCollection
+	return repository.NewMongoTruckRepository(d.db, coll)
+}
+
+// CreateInspectionRepository returns a Mongo-backed inspection repository.
+func (d *Database) CreateInspectionRepository() *repository.InspectionMongoRepository {
+	coll := InspectionCollection
+	return repository.NewMongoInspectionRepository(d.db, coll)
+}
+
+// CreateWorkOrderRepository returns a Mongo-backed work order repository.
+func (d *Database) CreateWorkOrderRepository() *repository.WorkOrderMongoRepository {
+	coll := WorkOrderCollection
+	return repository.NewMongoWorkOrderRepository(d.db, coll)
+}
+
+// CreateQuoteRepository returns a Mongo-backed quote repository.
+func (d *Database) CreateQuoteRepository() *repository.QuoteMongoRepository {
+	coll := QuoteCollection
+	return repository.NewMongoQuoteRepository(d.db, coll)
+}
+
+// CreatePriceListRepository returns a Mongo-backed price list repository.
+func (d *Database) CreatePriceListRepository() *repository.PriceListMongoRepository {
+	coll := PriceListCollection
+	return repository.NewMongoPriceListRepository(d.db, coll)
+}
+
+// CreateTaxRateRepository returns a Mongo-backed tax rate repository.
+func (d *Database) CreateTaxRateRepository() *repository.TaxRateMongoRepository {
+	coll := TaxRateCollection
+	return repository.NewMongoTaxRateRepository(d.db, coll)
+}
+
+// CreatePromoCodeRepository returns a Mongo-backed promo code repository.
+func (d *Database) CreatePromoCodeRepository() *repository.PromoCodeMongoRepository {
+	coll := PromoCodeCollection
+	return repository.NewMongoPromoCodeRepository(d.db, coll)
+}
+
+// CreatePromoRedemptionRepository returns a Mongo-backed promo redemption repository.
+func (d *Database) CreatePromoRedemptionRepository() *repository.PromoRedemptionMongoRepository {
+	coll := PromoRedemptionCollection
+	return repository.NewMongoPromoRedemptionRepository(d.db, coll)
+}
+
+// CreatePricingZoneRepository returns a Mongo-backed pricing zone repository.
+func (d *Database) CreatePricingZoneRepository() *repository.PricingZoneMongoRepository {
+	coll := PricingZoneCollection
+	return repository.NewMongoPricingZoneRepository(d.db, coll)
+}
+
+// CreateWebhookEventRepository returns a Mongo-backed webhook event repository.
+func (d *Database) CreateWebhookEventRepository() *repository.WebhookEventMongoRepository {
+	coll := WebhookEventCollection
+	return repository.NewMongoWebhookEventRepository(d.db, coll)
+}
@@ -0,0 +1,67 @@
+package utilities
+
+import "errors"
+
+// PointInPolygon reports whether a [longitude, latitude] point lies inside a GeoJSON polygon.
+// The first ring is the outer boundary and any further rings are holes. Points exactly on an edge
+// may fall on either side.
+func PointInPolygon(point []float64, polygon [][][]float64) bool {
+	if len(point) < 2 || len(polygon) == 0 {
+		return false
+	}
+
+	if !pointInRing(point, polygon[0]) {
+		return false
+	}
+	for _, hole := range polygon[1:] {
+		if pointInRing(point, hole) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pointInRing casts a ray from the point and counts how many ring edges it crosses.
+func pointInRing(point []float64, ring [][]float64) bool {
+	x, y := point[0], point[1]
+	inside := false
+
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// ValidatePolygon checks that a GeoJSON polygon has closed rings of at least four [longitude, latitude] positions.
+func ValidatePolygon(polygon [][][]float64) error {
+	if len(polygon) == 0 {
+		return errors.New("polygon must have at least one ring")
+	}
+
+	for _, ring := range polygon {
+		if len(ring) < 4 {
+			return errors.New("polygon rings need at least four positions")
+		}
+		for _, position := range ring {
+			if len(position) < 2 {
+				return errors.New("polygon positions must be [longitude, latitude]")
+			}
+			if position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+				return errors.New("polygon position is out of range")
+			}
+		}
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			return errors.New("polygon rings must be closed")
+		}
+	}
+
+	return nil
+}
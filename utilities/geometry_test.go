@@ -0,0 +1,61 @@
+package utilities
+
+import "testing"
+
+func TestPointInPolygon(t *testing.T) {
+	// A 10x10 square with a 2x2 hole in the middle.
+	square := [][][]float64{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+		{{4, 4}, {6, 4}, {6, 6}, {4, 6}, {4, 4}},
+	}
+
+	tests := []struct {
+		name  string
+		point []float64
+		want  bool
+	}{
+		{name: "inside the boundary", point: []float64{1, 1}, want: true},
+		{name: "outside the boundary", point: []float64{11, 5}},
+		{name: "left of the boundary", point: []float64{-1, 5}},
+		{name: "inside the hole", point: []float64{5, 5}},
+		{name: "between the hole and the boundary", point: []float64{5, 8}, want: true},
+		{name: "missing latitude", point: []float64{5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PointInPolygon(tt.point, square); got != tt.want {
+				t.Errorf("PointInPolygon(%v) = %v, want %v", tt.point, got, tt.want)
+			}
+		})
+	}
+
+	if PointInPolygon([]float64{1, 1}, nil) {
+		t.Errorf("PointInPolygon() with no rings = true, want false")
+	}
+}
+
+func TestValidatePolygon(t *testing.T) {
+	tests := []struct {
+		name    string
+		polygon [][][]float64
+		wantErr bool
+	}{
+		{name: "closed ring", polygon: [][][]float64{{{-97.8, 30.2}, {-97.6, 30.2}, {-97.6, 30.4}, {-97.8, 30.2}}}},
+		{name: "no rings", polygon: nil, wantErr: true},
+		{name: "too few positions", polygon: [][][]float64{{{0, 0}, {1, 0}, {0, 0}}}, wantErr: true},
+		{name: "open ring", polygon: [][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}}, wantErr: true},
+		{name: "position without latitude", polygon: [][][]float64{{{0, 0}, {1}, {1, 1}, {0, 0}}}, wantErr: true},
+		{name: "latitude out of range", polygon: [][][]float64{{{0, 0}, {1, 91}, {1, 1}, {0, 0}}}, wantErr: true},
+		{name: "longitude out of range", polygon: [][][]float64{{{0, 0}, {181, 0}, {1, 1}, {0, 0}}}, wantErr: true},
+		{name: "open hole", polygon: [][][]float64{{{0, 0}, {10, 0}, {10, 10}, {0, 0}}, {{1, 1}, {2, 1}, {2, 2}, {1, 2}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePolygon(tt.polygon); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePolygon() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -63,9 +63,28 @@ func (a *LocationUtility) GenerateAddressSuggestions(query string, biasLocation
 	return results
 }
 
+// GeocodedAddress is the position and jurisdiction of a geocoded address.
+type GeocodedAddress struct {
+	Position   []float64 // longitude, latitude
+	State      string    // region code, e.g. TX
+	County     string
+	PostalCode string
+}
+
 // ParseGeocodeFromAddress takes an address string and returns
 // a longitude and latitude nearest the location of the address.
 func (a *LocationUtility) ParseGeocodeFromAddress(address string) ([]float64, error) {
+	geocoded, err := a.GeocodeAddress(address)
+	if err != nil {
+		return []float64{}, err
+	}
+
+	return geocoded.Position, nil
+}
+
+// GeocodeAddress takes an address string and returns its position along with
+// the state, county and postal code used for tax and zone lookups.
+func (a *LocationUtility) GeocodeAddress(address string) (*GeocodedAddress, error) {
 
 	// Create the input geocode using provided address
 	params := &geoplaces.GeocodeInput{
@@ -76,15 +95,42 @@ func (a *LocationUtility) ParseGeocodeFromAddress(address string) ([]float64, er
 	geocodeOutput, err := a.geoplacesClient.Geocode(context.Background(), params)
 
 	if err != nil {
-		return []float64{}, err
+		return nil, err
+	}
+
+	if len(geocodeOutput.ResultItems) == 0 || len(geocodeOutput.ResultItems[0].Position) < 2 {
+		return nil, errors.New("no geocode result for address")
 	}
 
+	item := geocodeOutput.ResultItems[0]
+
 	// return in long/lat position
-	return []float64{geocodeOutput.ResultItems[0].Position[0], geocodeOutput.ResultItems[0].Position[1]}, nil
+	geocoded := &GeocodedAddress{
+		Position: []float64{item.Position[0], item.Position[1]},
+	}
+
+	if item.Address != nil {
+		if item.Address.Region != nil {
+			geocoded.State = aws.ToString(item.Address.Region.Code)
+		}
+		if item.Address.SubRegion != nil {
+			geocoded.County = aws.ToString(item.Address.SubRegion.Name)
+		}
+		geocoded.PostalCode = aws.ToString(item.Address.PostalCode)
+	}
+
+	return geocoded, nil
 }
 
 // CalculateDistanceBetweenCoordinates calculates the distance between two coordinates in miles.
 func (a *LocationUtility) CalculateDistanceBetweenCoordinates(coordinates1, coordinates2 []float64) (float64, error) {
+	miles, _, err := a.CalculateRouteBetweenCoordinates(coordinates1, coordinates2)
+	return miles, err
+}
+
+// CalculateRouteBetweenCoordinates calculates the driving distance in miles and the drive time in minutes
+// between two coordinates.
+func (a *LocationUtility) CalculateRouteBetweenCoordinates(coordinates1, coordinates2 []float64) (float64, float64, error) {
 
 	params := &georoutes.CalculateRoutesInput{
 		Origin:                        coordinates1,
@@ -92,13 +138,18 @@ func (a *LocationUtility) CalculateDistanceBetweenCoordinates(coordinates1, coor
 		InstructionsMeasurementSystem: types.MeasurementSystemMetric,
 	}
 
-	// Calculate the distance in meters between the two coordinates using the Haversine formula
 	routesOutput, err := a.georoutesClient.CalculateRoutes(context.TODO(), params)
 
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
-	// Convert the distance to miles
-	return float64(routesOutput.Routes[0].Summary.Distance) / 1609.00, nil
+	if len(routesOutput.Routes) == 0 || routesOutput.Routes[0].Summary == nil {
+		return 0, 0, errors.New("no route found between coordinates")
+	}
+
+	summary := routesOutput.Routes[0].Summary
+
+	// Convert meters to miles and seconds to minutes
+	return float64(summary.Distance) / 1609.00, float64(summary.Duration) / 60, nil
 }
@@ -0,0 +1,440 @@
+// Package paymenttest provides an in-memory payment gateway for tests. It is only imported by _test.go files,
+// so its webhook signature bypass never reaches the API binary.
+package paymenttest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"tow-management-system-api/model"
+	"tow-management-system-api/utilities"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+// FakeWebhookSignature is the only Stripe-Signature header FakePaymentGateway accepts.
+const FakeWebhookSignature = "fake-signature"
+
+// FakeCheckout is a checkout session created through FakePaymentGateway, with the arguments it was created with.
+type FakeCheckout struct {
+	Session              *stripe.CheckoutSession
+	TowID                string
+	LineItems            []model.PayableLineItem
+	DestinationAccountID string
+	ApplicationFee       int64
+}
+
+// FakePaymentGateway is an in-memory stand-in for utilities.StripeUtility in tests. It checks arguments the way
+// Stripe does, keeps accounts, checkout sessions, payments and refunds in memory and builds webhook payloads for
+// them. IDs are numbered in creation order, e.g. acct_fake_1, cs_fake_2, pi_fake_3.
+type FakePaymentGateway struct {
+	// Err, when set, is returned by every call instead of reaching the fake provider.
+	Err error
+
+	mu           sync.Mutex
+	next         int
+	accounts     map[string]*stripe.Account
+	checkouts    map[string]*FakeCheckout
+	payments     map[string]*stripe.PaymentIntent
+	refunds      map[string][]*stripe.Refund
+	balances     map[string][2]int64
+	payouts      map[string][]*stripe.Payout
+	transactions map[string][]*stripe.BalanceTransaction
+}
+
+// NewFakePaymentGateway returns an empty fake payment gateway.
+func NewFakePaymentGateway() *FakePaymentGateway {
+	return &FakePaymentGateway{
+		accounts:     map[string]*stripe.Account{},
+		checkouts:    map[string]*FakeCheckout{},
+		payments:     map[string]*stripe.PaymentIntent{},
+		refunds:      map[string][]*stripe.Refund{},
+		balances:     map[string][2]int64{},
+		payouts:      map[string][]*stripe.Payout{},
+		transactions: map[string][]*stripe.BalanceTransaction{},
+	}
+}
+
+// newID returns the next ID with the given prefix. The caller holds the lock.
+func (f *FakePaymentGateway) newID(prefix string) string {
+	f.next++
+	return fmt.Sprintf("%s_fake_%d", prefix, f.next)
+}
+
+// CreateConnectedAccount creates a connected account that can accept charges and payouts.
+func (f *FakePaymentGateway) CreateConnectedAccount(ctx context.Context) (string, error) {
+	if f.Err != nil {
+		return "", f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := f.newID("acct")
+	f.accounts[id] = &stripe.Account{ID: id, ChargesEnabled: true, PayoutsEnabled: true, DetailsSubmitted: true}
+	return id, nil
+}
+
+// SetAccount adds or replaces a connected account, e.g. one that has not finished onboarding.
+func (f *FakePaymentGateway) SetAccount(account *stripe.Account) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored := *account
+	f.accounts[account.ID] = &stored
+}
+
+// GetAccount returns a connected account.
+func (f *FakePaymentGateway) GetAccount(ctx context.Context, accountId string) (*stripe.Account, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if accountId == "" {
+		return nil, errors.New("accountId is required")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	account, ok := f.accounts[accountId]
+	if !ok {
+		return nil, errors.New("failed to retrieve account: no such account " + accountId)
+	}
+	found := *account
+	return &found, nil
+}
+
+// CreateLoginLink returns a dashboard URL for a connected account.
+func (f *FakePaymentGateway) CreateLoginLink(ctx context.Context, accountId string) (string, error) {
+	if _, err := f.GetAccount(ctx, accountId); err != nil {
+		return "", err
+	}
+	return "https://connect.stripe.test/express/" + accountId, nil
+}
+
+// CreateAccountLink returns an onboarding URL for a connected account.
+func (f *FakePaymentGateway) CreateAccountLink(ctx context.Context, accountId, returnURL, refreshURL string) (string, error) {
+	if _, err := f.GetAccount(ctx, accountId); err != nil {
+		return "", err
+	}
+	if returnURL == "" {
+		return "", errors.New("returnURL is required")
+	}
+	if refreshURL == "" {
+		return "", errors.New("refreshURL is required")
+	}
+	return "https://connect.stripe.test/setup/" + accountId, nil
+}
+
+// CreatePayableItem opens a checkout session for a tow, checked like utilities.StripeUtility checks it. The
+// destination must be a connected account of the fake.
+func (f *FakePaymentGateway) CreatePayableItem(ctx context.Context, towId string, total int64, lineItems []model.PayableLineItem, destinationAccountId string, applicationFee int64, successURL, cancelURL string) (string, string, error) {
+	if f.Err != nil {
+		return "", "", f.Err
+	}
+	if err := utilities.ValidatePayableItem(towId, total, lineItems, destinationAccountId, applicationFee, successURL, cancelURL); err != nil {
+		return "", "", err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.accounts[destinationAccountId]; !ok {
+		return "", "", errors.New("failed to create checkout session: no such destination " + destinationAccountId)
+	}
+
+	id := f.newID("cs")
+	session := &stripe.CheckoutSession{
+		ID:            id,
+		Object:        "checkout.session",
+		URL:           "https://checkout.stripe.test/pay/" + id,
+		Mode:          stripe.CheckoutSessionModePayment,
+		Status:        stripe.CheckoutSessionStatusOpen,
+		PaymentStatus: stripe.CheckoutSessionPaymentStatusUnpaid,
+		AmountTotal:   total,
+		Currency:      stripe.CurrencyUSD,
+		SuccessURL:    successURL,
+		CancelURL:     cancelURL,
+		Created:       time.Now().Unix(),
+	}
+	f.checkouts[id] = &FakeCheckout{
+		Session:              session,
+		TowID:                towId,
+		LineItems:            append([]model.PayableLineItem(nil), lineItems...),
+		DestinationAccountID: destinationAccountId,
+		ApplicationFee:       applicationFee,
+	}
+	return id, session.URL, nil
+}
+
+// Checkout returns a checkout session with the arguments it was created with, or nil when there is none.
+func (f *FakePaymentGateway) Checkout(sessionId string) *FakeCheckout {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	checkout, ok := f.checkouts[sessionId]
+	if !ok {
+		return nil
+	}
+	found := *checkout
+	session := *checkout.Session
+	found.Session = &session
+	return &found
+}
+
+// GetCheckoutSession returns a checkout session with its payment intent, once paid.
+func (f *FakePaymentGateway) GetCheckoutSession(ctx context.Context, sessionId string) (*stripe.CheckoutSession, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if sessionId == "" {
+		return nil, errors.New("sessionId is required")
+	}
+
+	checkout := f.Checkout(sessionId)
+	if checkout == nil {
+		return nil, errors.New("failed to retrieve checkout session: no such checkout session " + sessionId)
+	}
+	return checkout.Session, nil
+}
+
+// ExpireCheckoutSession expires an open checkout session.
+func (f *FakePaymentGateway) ExpireCheckoutSession(ctx context.Context, sessionId string) (*stripe.CheckoutSession, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if sessionId == "" {
+		return nil, errors.New("sessionId is required")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	checkout, ok := f.checkouts[sessionId]
+	if !ok {
+		return nil, errors.New("failed to expire checkout session: no such checkout session " + sessionId)
+	}
+	if checkout.Session.Status != stripe.CheckoutSessionStatusOpen {
+		return nil, fmt.Errorf("failed to expire checkout session: session is %s", checkout.Session.Status)
+	}
+	checkout.Session.Status = stripe.CheckoutSessionStatusExpired
+	session := *checkout.Session
+	return &session, nil
+}
+
+// PayCheckout completes an open checkout session as the customer paying by card would: the session is paid
+// through a new payment intent tagged with the tow. It returns the checkout.session.completed webhook payload
+// Stripe would send.
+func (f *FakePaymentGateway) PayCheckout(sessionId string) ([]byte, error) {
+	f.mu.Lock()
+	checkout, ok := f.checkouts[sessionId]
+	if !ok {
+		f.mu.Unlock()
+		return nil, errors.New("no such checkout session " + sessionId)
+	}
+	if checkout.Session.Status != stripe.CheckoutSessionStatusOpen {
+		f.mu.Unlock()
+		return nil, fmt.Errorf("checkout session %s is %s", sessionId, checkout.Session.Status)
+	}
+
+	intent := &stripe.PaymentIntent{
+		ID:            f.newID("pi"),
+		Object:        "payment_intent",
+		Amount:        checkout.Session.AmountTotal,
+		Currency:      stripe.CurrencyUSD,
+		Status:        stripe.PaymentIntentStatusSucceeded,
+		Metadata:      map[string]string{"towId": checkout.TowID},
+		TransferGroup: checkout.TowID,
+		Created:       time.Now().Unix(),
+	}
+	f.payments[intent.ID] = intent
+	checkout.Session.Status = stripe.CheckoutSessionStatusComplete
+	checkout.Session.PaymentStatus = stripe.CheckoutSessionPaymentStatusPaid
+	checkout.Session.PaymentIntent = intent
+	session := *checkout.Session
+	f.mu.Unlock()
+
+	return f.WebhookPayload(stripe.EventTypeCheckoutSessionCompleted, &session)
+}
+
+// CreateRefund refunds part of a paid payment intent. Like Stripe, it refuses to refund more than is left.
+func (f *FakePaymentGateway) CreateRefund(ctx context.Context, paymentIntentId string, amount int64, reason string, metadata map[string]string) (*stripe.Refund, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if paymentIntentId == "" {
+		return nil, errors.New("paymentIntentId is required")
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be greater than 0")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent, ok := f.payments[paymentIntentId]
+	if !ok {
+		return nil, errors.New("failed to create refund: no such payment intent " + paymentIntentId)
+	}
+	var refunded int64
+	for _, r := range f.refunds[paymentIntentId] {
+		refunded += r.Amount
+	}
+	if refunded+amount > intent.Amount {
+		return nil, fmt.Errorf("failed to create refund: amount %d is greater than the unrefunded %d", amount, intent.Amount-refunded)
+	}
+
+	refund := &stripe.Refund{
+		ID:            f.newID("re"),
+		Object:        "refund",
+		Amount:        amount,
+		Currency:      stripe.CurrencyUSD,
+		Status:        stripe.RefundStatusSucceeded,
+		PaymentIntent: &stripe.PaymentIntent{ID: paymentIntentId},
+		Metadata:      metadata,
+		Created:       time.Now().Unix(),
+	}
+	switch stripe.RefundReason(reason) {
+	case stripe.RefundReasonDuplicate, stripe.RefundReasonFraudulent, stripe.RefundReasonRequestedByCustomer:
+		refund.Reason = stripe.RefundReason(reason)
+	}
+	f.refunds[paymentIntentId] = append(f.refunds[paymentIntentId], refund)
+
+	created := *refund
+	return &created, nil
+}
+
+// ListRefunds returns the refunds of a payment intent, oldest first.
+func (f *FakePaymentGateway) ListRefunds(ctx context.Context, paymentIntentId string) ([]*stripe.Refund, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if paymentIntentId == "" {
+		return nil, errors.New("paymentIntentId is required")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	refunds := make([]*stripe.Refund, 0, len(f.refunds[paymentIntentId]))
+	for _, r := range f.refunds[paymentIntentId] {
+		listed := *r
+		refunds = append(refunds, &listed)
+	}
+	return refunds, nil
+}
+
+// SetAccountBalance sets the available and pending balance of a connected account, in cents.
+func (f *FakePaymentGateway) SetAccountBalance(accountId string, available, pending int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.balances[accountId] = [2]int64{available, pending}
+}
+
+// GetAccountBalance returns the available and pending balance of a connected account.
+func (f *FakePaymentGateway) GetAccountBalance(ctx context.Context, accountId string) (int64, int64, error) {
+	if f.Err != nil {
+		return 0, 0, f.Err
+	}
+	if accountId == "" {
+		return 0, 0, errors.New("accountId is required")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.accounts[accountId]; !ok {
+		return 0, 0, errors.New("failed to retrieve account balance: no such account " + accountId)
+	}
+	balance := f.balances[accountId]
+	return balance[0], balance[1], nil
+}
+
+// AddPayout adds a payout of a connected account and the balance transactions it settled.
+func (f *FakePaymentGateway) AddPayout(accountId string, payout *stripe.Payout, transactions []*stripe.BalanceTransaction) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.payouts[accountId] = append(f.payouts[accountId], payout)
+	f.transactions[payout.ID] = transactions
+}
+
+// ListPayouts returns the payouts of a connected account created in [from, to), newest first. A zero from or to
+// leaves that end open.
+func (f *FakePaymentGateway) ListPayouts(ctx context.Context, accountId string, from, to int64) ([]*stripe.Payout, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if accountId == "" {
+		return nil, errors.New("accountId is required")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var payouts []*stripe.Payout
+	for _, p := range f.payouts[accountId] {
+		if p.Created >= from && (to == 0 || p.Created < to) {
+			payouts = append(payouts, p)
+		}
+	}
+	sort.SliceStable(payouts, func(i, j int) bool {
+		return payouts[i].Created > payouts[j].Created
+	})
+	return payouts, nil
+}
+
+// ListPayoutTransactions returns the balance transactions a payout settled.
+func (f *FakePaymentGateway) ListPayoutTransactions(ctx context.Context, accountId, payoutId string) ([]*stripe.BalanceTransaction, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if accountId == "" || payoutId == "" {
+		return nil, errors.New("accountId and payoutId are required")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.transactions[payoutId], nil
+}
+
+// WebhookPayload returns the body of a webhook delivery of an event about object, e.g. a checkout session,
+// charge or dispute. Deliver it with FakeWebhookSignature.
+func (f *FakePaymentGateway) WebhookPayload(eventType stripe.EventType, object any) ([]byte, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook object: %w", err)
+	}
+
+	f.mu.Lock()
+	id := f.newID("evt")
+	f.mu.Unlock()
+
+	return json.Marshal(map[string]any{
+		"id":          id,
+		"object":      "event",
+		"api_version": stripe.APIVersion,
+		"type":        eventType,
+		"created":     time.Now().Unix(),
+		"data":        map[string]json.RawMessage{"object": data},
+	})
+}
+
+// ConstructWebhookEvent parses a webhook payload delivered with FakeWebhookSignature.
+func (f *FakePaymentGateway) ConstructWebhookEvent(payload []byte, signature string) (stripe.Event, error) {
+	if signature != FakeWebhookSignature {
+		return stripe.Event{}, errors.New("invalid webhook signature")
+	}
+
+	var event stripe.Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return stripe.Event{}, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	return event, nil
+}
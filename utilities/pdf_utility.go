@@ -0,0 +1,208 @@
+package utilities
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// US Letter page size in points.
+const (
+	PDFPageWidth  = 612.0
+	PDFPageHeight = 792.0
+)
+
+// PDFDocument builds a PDF in memory from text, lines and filled rectangles. It uses the standard Helvetica
+// fonts, which every PDF reader has, so no font files are embedded and nothing runs outside the process.
+// Coordinates are in points from the top-left corner of the page; text is placed by its baseline.
+type PDFDocument struct {
+	pages     []*bytes.Buffer
+	textColor [3]float64
+}
+
+// NewPDFDocument creates a document with one empty page.
+func NewPDFDocument() *PDFDocument {
+	d := &PDFDocument{}
+	d.AddPage()
+	return d
+}
+
+// AddPage starts a new page; later drawing goes to it.
+func (d *PDFDocument) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// PageCount returns the number of pages.
+func (d *PDFDocument) PageCount() int {
+	return len(d.pages)
+}
+
+// SetTextColor sets the RGB color, each 0-1, used by later text.
+func (d *PDFDocument) SetTextColor(r, g, b float64) {
+	d.textColor = [3]float64{r, g, b}
+}
+
+// Text draws text with its left edge at x.
+func (d *PDFDocument) Text(x, y, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page(), "BT /%s %.2f Tf %.3f %.3f %.3f rg %.2f %.2f Td (%s) Tj ET\n",
+		font, size, d.textColor[0], d.textColor[1], d.textColor[2], x, PDFPageHeight-y, pdfString(text))
+}
+
+// TextRight draws text with its right edge at right, e.g. for a column of amounts.
+func (d *PDFDocument) TextRight(right, y, size float64, bold bool, text string) {
+	d.Text(right-PDFTextWidth(text, size, bold), y, size, bold, text)
+}
+
+// Line draws a black line of the given width.
+func (d *PDFDocument) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page(), "%.2f w 0 0 0 RG %.2f %.2f m %.2f %.2f l S\n", width, x1, PDFPageHeight-y1, x2, PDFPageHeight-y2)
+}
+
+// FillRect fills a rectangle whose top-left corner is at x, y with an RGB color, each 0-1.
+func (d *PDFDocument) FillRect(x, y, w, h, r, g, b float64) {
+	fmt.Fprintf(d.page(), "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n", r, g, b, x, PDFPageHeight-y-h, w, h)
+}
+
+// Bytes renders the document.
+func (d *PDFDocument) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// The binary comment marks the file as binary for transfer tools
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page then takes a page and a content object
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			PDFPageWidth, PDFPageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// page returns the content stream of the current page.
+func (d *PDFDocument) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// PDFTextWidth returns the width in points of text in Helvetica at size.
+func PDFTextWidth(text string, size float64, bold bool) float64 {
+	widths := helveticaWidths
+	if bold {
+		widths = helveticaBoldWidths
+	}
+
+	units := 0
+	for _, c := range pdfEncode(text) {
+		if c >= 32 && c <= 126 {
+			units += widths[c-32]
+		} else {
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// PDFWrapText splits text into lines no wider than width, breaking at spaces. A word longer than width is
+// kept on its own line.
+func PDFWrapText(text string, width, size float64, bold bool) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && PDFTextWidth(candidate, size, bold) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// pdfString encodes text as the contents of a PDF literal string.
+func pdfString(text string) string {
+	var out strings.Builder
+	for _, c := range pdfEncode(text) {
+		switch c {
+		case '(', ')', '\\':
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// winAnsiPunctuation maps the typographic characters customers and companies commonly type to WinAnsiEncoding.
+var winAnsiPunctuation = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+}
+
+// pdfEncode converts text to WinAnsiEncoding bytes. Characters the standard fonts cannot show become "?".
+func pdfEncode(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			out = append(out, ' ')
+		case r >= 32 && r <= 126, r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		case winAnsiPunctuation[r] != 0:
+			out = append(out, winAnsiPunctuation[r])
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// Glyph widths of the printable ASCII characters (space through ~) in thousandths of the font size, from the
+// Adobe font metrics of the standard fonts.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
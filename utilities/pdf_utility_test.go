@@ -0,0 +1,66 @@
+package utilities
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPDFDocumentBytes(t *testing.T) {
+	doc := NewPDFDocument()
+	doc.Text(50, 50, 12, true, "Invoice (copy) \\ Café – 50%")
+	doc.AddPage()
+	doc.FillRect(0, 0, PDFPageWidth, 90, 0.1, 0.2, 0.3)
+	doc.Line(50, 100, 562, 100, 0.5)
+	out := doc.Bytes()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("missing PDF header or trailer")
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Errorf("page tree does not count 2 pages")
+	}
+	if !bytes.Contains(out, []byte("(Invoice \\(copy\\) \\\\ Caf\xe9 \x96 50%) Tj")) {
+		t.Errorf("text was not escaped and WinAnsi encoded")
+	}
+
+	// Every xref entry must point at the start of its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if startxref == nil {
+		t.Fatalf("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	if len(entries) != 8 {
+		t.Fatalf("xref has %d objects, want 8", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		want := fmt.Sprintf("%d 0 obj\n", i+1)
+		if !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", i+1, out[offset:offset+len(want)], want)
+		}
+	}
+}
+
+func TestPDFWrapText(t *testing.T) {
+	text := "1600 Amphitheatre Parkway, Mountain View, CA 94043, United States of America"
+	lines := PDFWrapText(text, 200, 10, false)
+	if len(lines) < 2 {
+		t.Fatalf("PDFWrapText() = %q, want several lines", lines)
+	}
+	for _, line := range lines {
+		if PDFTextWidth(line, 10, false) > 200 {
+			t.Errorf("line %q is wider than 200 points", line)
+		}
+	}
+	if got := strings.Join(lines, " "); got != text {
+		t.Errorf("wrapped lines join to %q, want %q", got, text)
+	}
+}
@@ -16,9 +16,14 @@ type Router struct {
 	paymentHandler  *handler.PaymentHandler
 	stripeHandler   *handler.StripeHandler
 	locationHandler *handler.LocationHandler
+	payrollHandler  *handler.PayrollHandler
+	fleetHandler    *handler.FleetHandler
+	taxHandler      *handler.TaxHandler
+	promoHandler    *handler.PromoHandler
+	invoiceHandler  *handler.InvoiceHandler
 }
 
-func NewRouter(user *handler.UserHandler, company *handler.CompanyHandler, towHandler *handler.TowHandler, metricHandler *handler.MetricHandler, priceHandler *handler.PriceHandler, paymentHandler *handler.PaymentHandler, stripeHandler *handler.StripeHandler, locationHandler *handler.LocationHandler) *Router {
+func NewRouter(user *handler.UserHandler, company *handler.CompanyHandler, towHandler *handler.TowHandler, metricHandler *handler.MetricHandler, priceHandler *handler.PriceHandler, paymentHandler *handler.PaymentHandler, stripeHandler *handler.StripeHandler, locationHandler *handler.LocationHandler, payrollHandler *handler.PayrollHandler, fleetHandler *handler.FleetHandler, taxHandler *handler.TaxHandler, promoHandler *handler.PromoHandler, invoiceHandler *handler.InvoiceHandler) *Router {
 	return &Router{
 		userHandler:     user,
 		companyHandler:  company,
@@ -28,6 +33,11 @@ func NewRouter(user *handler.UserHandler, company *handler.CompanyHandler, towHa
 		paymentHandler:  paymentHandler,
 		stripeHandler:   stripeHandler,
 		locationHandler: locationHandler,
+		payrollHandler:  payrollHandler,
+		fleetHandler:    fleetHandler,
+		taxHandler:      taxHandler,
+		promoHandler:    promoHandler,
+		invoiceHandler:  invoiceHandler,
 	}
 }
 
@@ -59,32 +69,96 @@ func (r *Router) InitializeRouter() *gin.Engine {
 	engine.PUT("/user/:userId", r.userHandler.PutUser) // Update a user
 
 	// ==== Company routes ====
-	engine.POST("/company", r.companyHandler.PostCompany)   // Create a company
-	engine.GET("/company/:id", r.companyHandler.GetCompany) // Get a company
-	engine.PUT("/company/:id", r.companyHandler.PutCompany) // Update a company
+	engine.POST("/company", r.companyHandler.PostCompany)            // Create a company
+	engine.GET("/company/:id", r.companyHandler.GetCompany)          // Get a company
+	engine.PUT("/company/:id", r.companyHandler.PutCompany)          // Update a company
+	engine.PUT("/company/:id/plan", r.companyHandler.PutCompanyPlan) // Set a company's plan and fees (admin)
 
 	// ==== Tow routes ====
 	engine.GET("/tows/company/:companyId", r.towHandler.GetTowHistory) // Get tow history
 	engine.POST("/tows/:schedulingLink", r.towHandler.PostTow)         // Create tow
 	engine.PUT("/tows/:towId", r.towHandler.PutUpdateTow)              // Update tow
 	engine.GET("/tows/estimates", r.towHandler.GetEstimate)            // Get price estimate
+	engine.POST("/quotes", r.towHandler.PostQuote)                     // Price and save a bookable quote
+	engine.GET("/quotes/:quoteId", r.towHandler.GetQuote)              // Get saved quote
 
 	// ==== Metric routes ====
 	engine.GET("/metrics/:companyId", r.metricHandler.GetCompanyMetrics) // Get metrics
 
 	// ==== Price routes ====
-	engine.GET("/pricing/company/:companyId", r.priceHandler.GetPrices) // Get prices by company
-	engine.PUT("/pricing", r.priceHandler.PutPrices)                    // Set prices
+	engine.GET("/pricing/company/:companyId", r.priceHandler.GetPrices)                                        // Get prices by company
+	engine.PUT("/pricing", r.priceHandler.PutPrices)                                                           // Set prices
+	engine.PUT("/pricing/company/:companyId", r.priceHandler.PutPrices)                                        // Set company catalog prices
+	engine.DELETE("/pricing/company/:companyId/:priceId", r.priceHandler.DeletePrice)                          // Delete or archive a catalog price
+	engine.POST("/pricing/lists/company/:companyId", r.priceHandler.PostPriceListDraft)                        // Start a draft price list version
+	engine.GET("/pricing/lists/company/:companyId", r.priceHandler.GetPriceLists)                              // List price list versions
+	engine.GET("/pricing/lists/company/:companyId/:priceListId", r.priceHandler.GetPriceList)                  // Get price list version
+	engine.PUT("/pricing/lists/company/:companyId/:priceListId", r.priceHandler.PutPriceListDraft)             // Edit draft price list
+	engine.POST("/pricing/lists/company/:companyId/:priceListId/publish", r.priceHandler.PostPublishPriceList) // Publish price list
+	engine.POST("/pricing/lists/company/:companyId/simulate", r.priceHandler.PostPriceSimulation)              // Replay a price list over past tows
+	engine.POST("/pricing/zones/company/:companyId", r.priceHandler.PostPricingZone)                           // Create pricing zone
+	engine.GET("/pricing/zones/company/:companyId", r.priceHandler.GetPricingZones)                            // List pricing zones
+	engine.PUT("/pricing/zones/company/:companyId/:zoneId", r.priceHandler.PutPricingZone)                     // Update pricing zone
+	engine.DELETE("/pricing/zones/company/:companyId/:zoneId", r.priceHandler.DeletePricingZone)               // Delete pricing zone
+
+	// ==== Tax routes ====
+	engine.GET("/tax/rates/company/:companyId", r.taxHandler.GetTaxRates) // Get tax rates
+	engine.PUT("/tax/rates/company/:companyId", r.taxHandler.PutTaxRates) // Replace tax rates
+
+	// ==== Promo code routes ====
+	engine.POST("/promos/company/:companyId", r.promoHandler.PostPromoCode)                           // Create promo code
+	engine.GET("/promos/company/:companyId", r.promoHandler.GetPromoCodes)                            // List promo codes
+	engine.PUT("/promos/company/:companyId/:promoId", r.promoHandler.PutPromoCode)                    // Update promo code
+	engine.GET("/promos/company/:companyId/:promoId/redemptions", r.promoHandler.GetPromoRedemptions) // Promo redemption report
 
 	// ==== Payment routes ====
-	engine.GET("/payments/account/:companyId", r.paymentHandler.GetPaymentAccount)   // Get payment account
-	engine.POST("/payments/account/:companyId", r.paymentHandler.PostPaymentAccount) // Generate dashboard link
+	engine.GET("/payments/account/:companyId", r.paymentHandler.GetPaymentAccount)                     // Get payment account
+	engine.POST("/payments/account/:companyId", r.paymentHandler.PostPaymentAccount)                   // Generate dashboard link
+	engine.GET("/payments/tows/:towId", r.paymentHandler.GetTowPaymentOutcome)                         // Payment outcome for the checkout return page
+	engine.POST("/payments/tows/:towId/refunds", r.paymentHandler.PostTowRefund)                       // Refund a tow in full or in part
+	engine.POST("/payments/tows/:towId/checkout", r.towHandler.PostRegeneratePaymentLink)              // Replace an expired checkout link
+	engine.POST("/payments/tows/:towId/checkout/resend", r.towHandler.PostResendPaymentLink)           // Email the checkout link again
+	engine.POST("/payments/tows/:towId/payments", r.paymentHandler.PostTowPayment)                     // Record an offline payment
+	engine.POST("/payments/tows/:towId/payments/:paymentId/void", r.paymentHandler.PostVoidTowPayment) // Void a mistaken offline payment
+	engine.GET("/payments/tows/:towId/invoice", r.invoiceHandler.GetPublicInvoice)                     // Invoice PDF for the customer's tow link
+	engine.GET("/payments/summary/company/:companyId", r.paymentHandler.GetPaymentSummary)             // Collected, fees, refunds, net and balances
+	engine.GET("/payments/payouts/company/:companyId", r.paymentHandler.GetPayouts)                    // Stripe payouts with the tows they covered
+
+	// ==== Invoice routes ====
+	engine.GET("/invoices/tows/:towId", r.invoiceHandler.GetInvoice)                  // Invoice PDF for company users
+	engine.GET("/disputes/tows/:towId/evidence", r.invoiceHandler.GetDisputeEvidence) // Evidence for answering a payment dispute
 
 	// ==== Stripe Webhook routes ====
-	engine.POST("/webhooks/stripe", r.stripeHandler.PostWebhook) // Handle Stripe webhooks
+	engine.POST("/webhooks/stripe", r.stripeHandler.PostWebhook)                                   // Handle Stripe webhooks
+	engine.GET("/webhooks/stripe/events", r.stripeHandler.GetWebhookEvents)                        // List recorded webhook events (admin)
+	engine.POST("/webhooks/stripe/events/:eventId/replay", r.stripeHandler.PostReplayWebhookEvent) // Replay a failed webhook event (admin)
 
 	// ==== Location routes ====
 	engine.GET("/locations/suggest", r.locationHandler.SuggestLocations) // Get location suggestions
 
+	// ==== Payroll routes ====
+	engine.GET("/payroll/rules/company/:companyId", r.payrollHandler.GetCommissionRules)                     // Get commission rules
+	engine.PUT("/payroll/rules/company/:companyId", r.payrollHandler.PutCommissionRules)                     // Set commission rules
+	engine.POST("/payroll/company/:companyId", r.payrollHandler.PostPayrollRun)                              // Generate payroll for a pay period
+	engine.GET("/payroll/company/:companyId", r.payrollHandler.GetPayrollRuns)                               // Get payroll runs
+	engine.GET("/payroll/company/:companyId/:payrollId", r.payrollHandler.GetPayrollRun)                     // Get payroll run
+	engine.PUT("/payroll/company/:companyId/:payrollId/entries/:driverId", r.payrollHandler.PutPayrollEntry) // Adjust driver earnings
+	engine.POST("/payroll/company/:companyId/:payrollId/approve", r.payrollHandler.PostApprovePayrollRun)    // Approve payroll run
+	engine.GET("/payroll/company/:companyId/:payrollId/export", r.payrollHandler.GetPayrollExport)           // Export payroll CSV
+
+	// ==== Fleet routes ====
+	engine.POST("/fleet/trucks/company/:companyId", r.fleetHandler.PostTruck)                     // Register a truck
+	engine.GET("/fleet/trucks/company/:companyId", r.fleetHandler.GetTrucks)                      // Get trucks by company
+	engine.GET("/fleet/trucks/:truckId", r.fleetHandler.GetTruck)                                 // Get a truck
+	engine.PUT("/fleet/trucks/:truckId", r.fleetHandler.PutTruck)                                 // Update a truck
+	engine.GET("/fleet/checklist/company/:companyId", r.fleetHandler.GetInspectionChecklist)      // Get DVIR checklist
+	engine.PUT("/fleet/checklist/company/:companyId", r.fleetHandler.PutInspectionChecklist)      // Set DVIR checklist
+	engine.POST("/fleet/trucks/:truckId/inspections", r.fleetHandler.PostInspection)              // Submit a DVIR
+	engine.GET("/fleet/trucks/:truckId/inspections", r.fleetHandler.GetInspections)               // Get DVIRs for a truck
+	engine.POST("/fleet/trucks/:truckId/work-orders", r.fleetHandler.PostWorkOrder)               // Open a work order
+	engine.GET("/fleet/trucks/:truckId/work-orders", r.fleetHandler.GetWorkOrders)                // Get work orders for a truck
+	engine.POST("/fleet/work-orders/:workOrderId/complete", r.fleetHandler.PostCompleteWorkOrder) // Complete a work order
+	engine.GET("/fleet/maintenance/company/:companyId", r.fleetHandler.GetMaintenanceDue)         // Get due maintenance
+
 	return engine
 }
@@ -1,26 +1,28 @@
 package utilities
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"log"
 	"os"
+	"strings"
 	"tow-management-system-api/model"
 
-	"github.com/stripe/stripe-go/v83/loginlink"
-
 	"github.com/stripe/stripe-go/v83"
-	"github.com/stripe/stripe-go/v83/account"
-	"github.com/stripe/stripe-go/v83/accountlink"
-	checkoutsession "github.com/stripe/stripe-go/v83/checkout/session"
+	"github.com/stripe/stripe-go/v83/webhook"
 )
 
+// StripeUtility is the Stripe payment gateway. Every call goes through its own stripe.Client, so the package-level
+// stripe.Key is never set and several instances (e.g. one pointed at a test server) can coexist.
 type StripeUtility struct {
-	client *stripe.Client
+	client        *stripe.Client
+	webhookSecret string
 }
 
-// NewStripeClient initializes stripe geoplacesClient using STRIPE_API_KEY and returns a singleton.
+// NewStripeClient initializes a Stripe client using STRIPE_API_KEY.
+// STRIPE_WEBHOOK_SECRET is the signing secret of the webhook endpoint; webhooks are rejected while it is unset.
 func NewStripeClient() (*StripeUtility, error) {
 	apiKey := os.Getenv("STRIPE_API_KEY")
 
@@ -28,34 +30,53 @@ func NewStripeClient() (*StripeUtility, error) {
 		return nil, errors.New("STRIPE_API_KEY not set")
 	}
 
-	sc := stripe.NewClient(apiKey)
+	return NewStripeUtility(stripe.NewClient(apiKey), os.Getenv("STRIPE_WEBHOOK_SECRET")), nil
+}
 
-	stripe.Key = apiKey
+// NewStripeUtility wraps a configured Stripe client, e.g. one whose backends point at a local server.
+func NewStripeUtility(client *stripe.Client, webhookSecret string) *StripeUtility {
+	return &StripeUtility{client: client, webhookSecret: webhookSecret}
+}
+
+// ConstructWebhookEvent verifies the Stripe-Signature header of a webhook payload against the endpoint secret
+// and returns the parsed event. Events signed more than five minutes ago are rejected to prevent replays.
+func (sc *StripeUtility) ConstructWebhookEvent(payload []byte, signature string) (stripe.Event, error) {
+	if sc.webhookSecret == "" {
+		return stripe.Event{}, errors.New("STRIPE_WEBHOOK_SECRET not set")
+	}
 
-	return &StripeUtility{client: sc}, nil
+	// The endpoint's API version is configured in the Stripe dashboard and may lag behind the SDK's
+	event, err := webhook.ConstructEventWithOptions(payload, signature, sc.webhookSecret, webhook.ConstructEventOptions{
+		IgnoreAPIVersionMismatch: true,
+	})
+	if err != nil {
+		return stripe.Event{}, fmt.Errorf("invalid webhook signature: %w", err)
+	}
+
+	return event, nil
 }
 
 // CreateConnectedAccount creates a Stripe connected account and returns an onboarding URL.
 // If accountID is provided and not empty, it will use the existing account; otherwise, it creates a new one.
 // The returned URL allows service providers to enter their identity and banking information.
-func (sc *StripeUtility) CreateConnectedAccount() (string, error) {
+func (sc *StripeUtility) CreateConnectedAccount(ctx context.Context) (string, error) {
 
-	params := &stripe.AccountParams{
+	params := &stripe.AccountCreateParams{
 		Country: stripe.String("US"),
-		Controller: &stripe.AccountControllerParams{
-			Fees: &stripe.AccountControllerFeesParams{
+		Controller: &stripe.AccountCreateControllerParams{
+			Fees: &stripe.AccountCreateControllerFeesParams{
 				Payer: stripe.String(stripe.AccountControllerFeesPayerApplication),
 			},
-			Losses: &stripe.AccountControllerLossesParams{
+			Losses: &stripe.AccountCreateControllerLossesParams{
 				Payments: stripe.String(stripe.AccountControllerLossesPaymentsApplication),
 			},
-			StripeDashboard: &stripe.AccountControllerStripeDashboardParams{
+			StripeDashboard: &stripe.AccountCreateControllerStripeDashboardParams{
 				Type: stripe.String(stripe.AccountControllerStripeDashboardTypeExpress),
 			},
 		},
 	}
 
-	account, err := account.New(params)
+	account, err := sc.client.V1Accounts.Create(ctx, params)
 
 	if err != nil {
 		return "", errors.New("An error occurred when calling the Stripe API to create an account: " + err.Error())
@@ -67,16 +88,16 @@ func (sc *StripeUtility) CreateConnectedAccount() (string, error) {
 
 // CreateLoginLink creates a login link for the connected account.
 // Returns a URL that allows the account holder to access their Stripe Express dashboard.
-func (sc *StripeUtility) CreateLoginLink(accountId string) (string, error) {
+func (sc *StripeUtility) CreateLoginLink(ctx context.Context, accountId string) (string, error) {
 	if accountId == "" {
 		return "", errors.New("accountId is required")
 	}
 
-	params := &stripe.LoginLinkParams{
+	params := &stripe.LoginLinkCreateParams{
 		Account: stripe.String(accountId),
 	}
 
-	loginLink, err := loginlink.New(params)
+	loginLink, err := sc.client.V1LoginLinks.Create(ctx, params)
 	if err != nil {
 		return "", errors.New("failed to create login link: " + err.Error())
 	}
@@ -86,7 +107,7 @@ func (sc *StripeUtility) CreateLoginLink(accountId string) (string, error) {
 
 // CreateAccountLink creates an account link for onboarding or updating account information.
 // Returns a URL that allows the account holder to complete their account setup.
-func (sc *StripeUtility) CreateAccountLink(accountId, returnURL, refreshURL string) (string, error) {
+func (sc *StripeUtility) CreateAccountLink(ctx context.Context, accountId, returnURL, refreshURL string) (string, error) {
 	if accountId == "" {
 		return "", errors.New("accountId is required")
 	}
@@ -97,14 +118,14 @@ func (sc *StripeUtility) CreateAccountLink(accountId, returnURL, refreshURL stri
 		return "", errors.New("refreshURL is required")
 	}
 
-	params := &stripe.AccountLinkParams{
+	params := &stripe.AccountLinkCreateParams{
 		Account:    stripe.String(accountId),
 		RefreshURL: stripe.String(refreshURL),
 		ReturnURL:  stripe.String(returnURL),
 		Type:       stripe.String(string(stripe.AccountLinkTypeAccountOnboarding)),
 	}
 
-	accountLink, err := accountlink.New(params)
+	accountLink, err := sc.client.V1AccountLinks.Create(ctx, params)
 	if err != nil {
 		return "", errors.New("failed to create account link: " + err.Error())
 	}
@@ -114,12 +135,12 @@ func (sc *StripeUtility) CreateAccountLink(accountId, returnURL, refreshURL stri
 
 // GetAccount retrieves a Stripe connected account by its ID.
 // Returns the full account object with all account details.
-func (sc *StripeUtility) GetAccount(accountId string) (*stripe.Account, error) {
+func (sc *StripeUtility) GetAccount(ctx context.Context, accountId string) (*stripe.Account, error) {
 	if accountId == "" {
 		return nil, errors.New("accountId is required")
 	}
 
-	acct, err := account.GetByID(accountId, nil)
+	acct, err := sc.client.V1Accounts.GetByID(ctx, accountId, nil)
 	if err != nil {
 		return nil, errors.New("failed to retrieve account: " + err.Error())
 	}
@@ -128,47 +149,43 @@ func (sc *StripeUtility) GetAccount(accountId string) (*stripe.Account, error) {
 }
 
 // CreatePayableItem creates a Stripe Checkout Session (one-time payment) and returns the checkout session ID and URL.
+// The payment is a destination charge: it settles to the company's connected account, on whose behalf it is made,
+// and the platform keeps applicationFee. Checkout does not accept negative line items, so discounts (negative
+// amounts) are applied as a single-use coupon.
 //
 // Parameters:
+// - towId: the tow being paid for; kept on the payment intent and as the transfer group so payouts can be traced to tows
 // - total: total amount in cents (integer)
 // - lineItems: array of (name, amount) pairs, amounts in cents
+// - destinationAccountId: the company's connected Stripe account
+// - applicationFee: platform fee in cents, at most total
+// - successURL, cancelURL: pages Stripe returns the customer to; {CHECKOUT_SESSION_ID} is filled in by Stripe
 //
 // Returns:
 // - checkoutSessionId: the Stripe checkout session ID
 // - checkoutURL: URL string for Stripe-hosted checkout
 // - error: any error that occurred
-func (sc *StripeUtility) CreatePayableItem(total int64, lineItems []model.PayableLineItem) (string, string, error) {
-	if total <= 0 {
-		return "", "", errors.New("total must be greater than 0")
-	}
-	if len(lineItems) == 0 {
-		return "", "", errors.New("at least one line item is required")
-	}
-
-	successURL := "https://www.google.com/"
-	cancelURL := "https://www.google.com/"
-	if successURL == "" {
-		return "", "", errors.New("STRIPE_CHECKOUT_SUCCESS_URL not set")
-	}
-	if cancelURL == "" {
-		return "", "", errors.New("STRIPE_CHECKOUT_CANCEL_URL not set")
+func (sc *StripeUtility) CreatePayableItem(ctx context.Context, towId string, total int64, lineItems []model.PayableLineItem, destinationAccountId string, applicationFee int64, successURL, cancelURL string) (string, string, error) {
+	if err := ValidatePayableItem(towId, total, lineItems, destinationAccountId, applicationFee, successURL, cancelURL); err != nil {
+		return "", "", err
 	}
 
-	sessionLineItems := make([]*stripe.CheckoutSessionLineItemParams, 0, len(lineItems))
+	sessionLineItems := make([]*stripe.CheckoutSessionCreateLineItemParams, 0, len(lineItems))
+	var discount int64
+	var discountNames []string
 
-	for i, li := range lineItems {
-		if li.Name == "" {
-			return "", "", fmt.Errorf("lineItems[%d].Name is required", i)
-		}
-		if li.Amount <= 0 {
-			return "", "", fmt.Errorf("lineItems[%d].Amount must be > 0", i)
+	for _, li := range lineItems {
+		if li.Amount < 0 {
+			discount += -li.Amount
+			discountNames = append(discountNames, li.Name)
+			continue
 		}
 
-		sessionLineItems = append(sessionLineItems, &stripe.CheckoutSessionLineItemParams{
+		sessionLineItems = append(sessionLineItems, &stripe.CheckoutSessionCreateLineItemParams{
 			Quantity: stripe.Int64(1), // the number of miles or the one per mile amount
-			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+			PriceData: &stripe.CheckoutSessionCreateLineItemPriceDataParams{
 				Currency: stripe.String(string(stripe.CurrencyUSD)),
-				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+				ProductData: &stripe.CheckoutSessionCreateLineItemPriceDataProductDataParams{
 					Name: stripe.String(li.Name),
 				},
 				UnitAmount: stripe.Int64(li.Amount),
@@ -176,20 +193,42 @@ func (sc *StripeUtility) CreatePayableItem(total int64, lineItems []model.Payabl
 		})
 	}
 
-	params := &stripe.CheckoutSessionParams{
+	params := &stripe.CheckoutSessionCreateParams{
 		Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
 		SuccessURL: stripe.String(successURL),
 		CancelURL:  stripe.String(cancelURL),
 		LineItems:  sessionLineItems,
 		Currency:   stripe.String("USD"),
+		PaymentIntentData: &stripe.CheckoutSessionCreatePaymentIntentDataParams{
+			Metadata:      map[string]string{"towId": towId},
+			TransferGroup: stripe.String(towId),
+			OnBehalfOf:    stripe.String(destinationAccountId),
+			TransferData: &stripe.CheckoutSessionCreatePaymentIntentDataTransferDataParams{
+				Destination: stripe.String(destinationAccountId),
+			},
+		},
+	}
+	if applicationFee > 0 {
+		params.PaymentIntentData.ApplicationFeeAmount = stripe.Int64(applicationFee)
+	}
+
+	if discount > 0 {
+		couponId, err := sc.createDiscountCoupon(ctx, discount, strings.Join(discountNames, ", "))
+		if err != nil {
+			return "", "", err
+		}
+		params.Discounts = []*stripe.CheckoutSessionCreateDiscountParams{{Coupon: stripe.String(couponId)}}
 	}
 
 	// Best-effort idempotency key to avoid duplicates if caller retries.
 	// If you have a stable internal ID (e.g., service_request_id), pass it via metadata and use it here instead.
 	params.SetIdempotencyKey(fmt.Sprintf("payable_%d_%s", total, uuid.NewString()))
 
-	sess, err := checkoutsession.New(params)
+	sess, err := sc.client.V1CheckoutSessions.Create(ctx, params)
 	if err != nil {
+		if params.Discounts != nil {
+			sc.deleteDiscountCoupon(ctx, *params.Discounts[0].Coupon)
+		}
 		return "", "", errors.New("failed to create checkout session: " + err.Error())
 	}
 
@@ -205,3 +244,242 @@ func (sc *StripeUtility) CreatePayableItem(total int64, lineItems []model.Payabl
 
 	return sess.ID, sess.URL, nil
 }
+
+// GetCheckoutSession retrieves a checkout session by its ID, with its payment intent expanded.
+func (sc *StripeUtility) GetCheckoutSession(ctx context.Context, sessionId string) (*stripe.CheckoutSession, error) {
+	if sessionId == "" {
+		return nil, errors.New("sessionId is required")
+	}
+
+	params := &stripe.CheckoutSessionRetrieveParams{}
+	params.AddExpand("payment_intent")
+
+	sess, err := sc.client.V1CheckoutSessions.Retrieve(ctx, sessionId, params)
+	if err != nil {
+		return nil, errors.New("failed to retrieve checkout session: " + err.Error())
+	}
+
+	return sess, nil
+}
+
+// ExpireCheckoutSession expires an open checkout session so its link can no longer be paid.
+func (sc *StripeUtility) ExpireCheckoutSession(ctx context.Context, sessionId string) (*stripe.CheckoutSession, error) {
+	if sessionId == "" {
+		return nil, errors.New("sessionId is required")
+	}
+
+	sess, err := sc.client.V1CheckoutSessions.Expire(ctx, sessionId, &stripe.CheckoutSessionExpireParams{})
+	if err != nil {
+		return nil, errors.New("failed to expire checkout session: " + err.Error())
+	}
+
+	return sess, nil
+}
+
+// CreateRefund refunds amount cents of a payment intent. The transfer to the connected account is reversed and
+// the platform fee is refunded in proportion, so the refund comes out of the company's balance. Metadata is kept
+// on the refund so webhook reconciliation can restore who issued it and why.
+func (sc *StripeUtility) CreateRefund(ctx context.Context, paymentIntentId string, amount int64, reason string, metadata map[string]string) (*stripe.Refund, error) {
+	if paymentIntentId == "" {
+		return nil, errors.New("paymentIntentId is required")
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be greater than 0")
+	}
+
+	params := &stripe.RefundCreateParams{
+		PaymentIntent:        stripe.String(paymentIntentId),
+		Amount:               stripe.Int64(amount),
+		ReverseTransfer:      stripe.Bool(true),
+		RefundApplicationFee: stripe.Bool(true),
+		Metadata:             metadata,
+	}
+	switch stripe.RefundReason(reason) {
+	case stripe.RefundReasonDuplicate, stripe.RefundReasonFraudulent, stripe.RefundReasonRequestedByCustomer:
+		params.Reason = stripe.String(reason)
+	}
+
+	r, err := sc.client.V1Refunds.Create(ctx, params)
+	if err != nil {
+		return nil, errors.New("failed to create refund: " + err.Error())
+	}
+
+	return r, nil
+}
+
+// ListRefunds returns every refund of a payment intent, oldest first.
+func (sc *StripeUtility) ListRefunds(ctx context.Context, paymentIntentId string) ([]*stripe.Refund, error) {
+	if paymentIntentId == "" {
+		return nil, errors.New("paymentIntentId is required")
+	}
+
+	params := &stripe.RefundListParams{PaymentIntent: stripe.String(paymentIntentId)}
+	var refunds []*stripe.Refund
+	for r, err := range sc.client.V1Refunds.List(ctx, params) {
+		if err != nil {
+			return nil, errors.New("failed to list refunds: " + err.Error())
+		}
+		refunds = append(refunds, r)
+	}
+
+	// Stripe lists newest first
+	for i, j := 0, len(refunds)-1; i < j; i, j = i+1, j-1 {
+		refunds[i], refunds[j] = refunds[j], refunds[i]
+	}
+
+	return refunds, nil
+}
+
+// GetAccountBalance returns the USD balance of a connected account in cents: what is available to pay out and
+// what is still pending settlement.
+func (sc *StripeUtility) GetAccountBalance(ctx context.Context, accountId string) (int64, int64, error) {
+	if accountId == "" {
+		return 0, 0, errors.New("accountId is required")
+	}
+
+	params := &stripe.BalanceRetrieveParams{}
+	params.SetStripeAccount(accountId)
+	bal, err := sc.client.V1Balance.Retrieve(ctx, params)
+	if err != nil {
+		return 0, 0, errors.New("failed to retrieve account balance: " + err.Error())
+	}
+
+	var available, pending int64
+	for _, amount := range bal.Available {
+		if amount.Currency == stripe.CurrencyUSD {
+			available += amount.Amount
+		}
+	}
+	for _, amount := range bal.Pending {
+		if amount.Currency == stripe.CurrencyUSD {
+			pending += amount.Amount
+		}
+	}
+
+	return available, pending, nil
+}
+
+// ListPayouts returns the payouts of a connected account created in [from, to), newest first.
+func (sc *StripeUtility) ListPayouts(ctx context.Context, accountId string, from, to int64) ([]*stripe.Payout, error) {
+	if accountId == "" {
+		return nil, errors.New("accountId is required")
+	}
+
+	params := &stripe.PayoutListParams{
+		CreatedRange: &stripe.RangeQueryParams{GreaterThanOrEqual: from, LesserThan: to},
+	}
+	params.SetStripeAccount(accountId)
+
+	var payouts []*stripe.Payout
+	for p, err := range sc.client.V1Payouts.List(ctx, params) {
+		if err != nil {
+			return nil, errors.New("failed to list payouts: " + err.Error())
+		}
+		payouts = append(payouts, p)
+	}
+
+	return payouts, nil
+}
+
+// ListPayoutTransactions returns the balance transactions a payout of a connected account settled. The transfer
+// behind each tow payment is expanded; its transfer group is the tow ID.
+func (sc *StripeUtility) ListPayoutTransactions(ctx context.Context, accountId, payoutId string) ([]*stripe.BalanceTransaction, error) {
+	if accountId == "" || payoutId == "" {
+		return nil, errors.New("accountId and payoutId are required")
+	}
+
+	params := &stripe.BalanceTransactionListParams{Payout: stripe.String(payoutId)}
+	params.AddExpand("data.source.source_transfer")
+	params.SetStripeAccount(accountId)
+
+	var transactions []*stripe.BalanceTransaction
+	for t, err := range sc.client.V1BalanceTransactions.List(ctx, params) {
+		if err != nil {
+			return nil, errors.New("failed to list payout transactions: " + err.Error())
+		}
+		transactions = append(transactions, t)
+	}
+
+	return transactions, nil
+}
+
+// createDiscountCoupon creates a single-use coupon for a fixed discount in cents and returns its ID.
+func (sc *StripeUtility) createDiscountCoupon(ctx context.Context, amount int64, name string) (string, error) {
+	// Coupon names are limited to 40 characters
+	if len(name) > 40 {
+		name = name[:40]
+	}
+
+	params := &stripe.CouponCreateParams{
+		AmountOff:      stripe.Int64(amount),
+		Currency:       stripe.String(string(stripe.CurrencyUSD)),
+		Duration:       stripe.String(string(stripe.CouponDurationOnce)),
+		MaxRedemptions: stripe.Int64(1),
+		Name:           stripe.String(name),
+	}
+
+	c, err := sc.client.V1Coupons.Create(ctx, params)
+	if err != nil {
+		return "", errors.New("failed to create discount coupon: " + err.Error())
+	}
+
+	return c.ID, nil
+}
+
+// deleteDiscountCoupon removes a coupon made for a checkout that could not be created, so it cannot be redeemed
+// elsewhere. Failures are logged; the coupon is single-use either way.
+func (sc *StripeUtility) deleteDiscountCoupon(ctx context.Context, couponId string) {
+	if _, err := sc.client.V1Coupons.Delete(ctx, couponId, nil); err != nil {
+		log.Println("failed to delete discount coupon " + couponId + ": " + err.Error())
+	}
+}
+
+// ValidatePayableItem checks the arguments of a tow checkout: a tow, a positive total, named non-zero line items
+// (negative ones are discounts) that add up to the total, the company's connected account, a fee no larger than
+// the total and both return pages.
+func ValidatePayableItem(towId string, total int64, lineItems []model.PayableLineItem, destinationAccountId string, applicationFee int64, successURL, cancelURL string) error {
+	if towId == "" {
+		return errors.New("towId is required")
+	}
+	if total <= 0 {
+		return errors.New("total must be greater than 0")
+	}
+	if len(lineItems) == 0 {
+		return errors.New("at least one line item is required")
+	}
+	if destinationAccountId == "" {
+		return errors.New("destinationAccountId is required")
+	}
+	if applicationFee < 0 || applicationFee > total {
+		return errors.New("applicationFee must be between 0 and the total")
+	}
+
+	if successURL == "" {
+		return errors.New("successURL is required")
+	}
+	if cancelURL == "" {
+		return errors.New("cancelURL is required")
+	}
+
+	for i, li := range lineItems {
+		if li.Name == "" {
+			return fmt.Errorf("lineItems[%d].Name is required", i)
+		}
+		if li.Amount == 0 {
+			return fmt.Errorf("lineItems[%d].Amount must not be 0", i)
+		}
+	}
+	if sum := LineItemsTotal(lineItems); sum != total {
+		return fmt.Errorf("line items add up to %d, not the total of %d", sum, total)
+	}
+	return nil
+}
+
+// LineItemsTotal adds up the amounts of line items, discounts included.
+func LineItemsTotal(lineItems []model.PayableLineItem) int64 {
+	var sum int64
+	for _, li := range lineItems {
+		sum += li.Amount
+	}
+	return sum
+}
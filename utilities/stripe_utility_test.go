@@ -0,0 +1,245 @@
+package utilities
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"tow-management-system-api/model"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+// stripeStandIn is a stand-in for the Stripe API that records the form of each request by path.
+type stripeStandIn struct {
+	mu           sync.Mutex
+	requests     map[string]url.Values
+	deleted      []string
+	failCheckout bool
+}
+
+// newStripeStandIn starts a local server standing in for the Stripe API and returns a StripeUtility whose client
+// talks to it.
+func newStripeStandIn(t *testing.T) (*stripeStandIn, *StripeUtility) {
+	t.Helper()
+
+	standIn := &stripeStandIn{requests: map[string]url.Values{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse stripe request: %v", err)
+		}
+		standIn.mu.Lock()
+		standIn.requests[r.URL.Path] = r.PostForm
+		if r.Method == http.MethodDelete {
+			standIn.deleted = append(standIn.deleted, r.URL.Path)
+		}
+		failCheckout := standIn.failCheckout
+		standIn.mu.Unlock()
+
+		var body any
+		switch {
+		case r.URL.Path == "/v1/checkout/sessions" && failCheckout:
+			w.WriteHeader(http.StatusBadRequest)
+			body = map[string]any{"error": map[string]string{"message": "checkout rejected"}}
+		case r.URL.Path == "/v1/checkout/sessions":
+			body = map[string]string{"id": "cs_test_1", "object": "checkout.session", "url": "https://checkout.stripe.com/c/pay/cs_test_1"}
+		case r.URL.Path == "/v1/coupons":
+			body = map[string]string{"id": "coupon_test_1", "object": "coupon"}
+		case r.URL.Path == "/v1/coupons/coupon_test_1" && r.Method == http.MethodDelete:
+			body = map[string]any{"id": "coupon_test_1", "object": "coupon", "deleted": true}
+		case r.URL.Path == "/v1/refunds":
+			body = map[string]any{"id": "re_test_1", "object": "refund", "amount": 2500, "status": "succeeded"}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			body = map[string]any{"error": map[string]string{"message": "unexpected path " + r.URL.Path}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+
+	backends := stripe.NewBackendsWithConfig(&stripe.BackendConfig{
+		URL:               stripe.String(server.URL),
+		MaxNetworkRetries: stripe.Int64(0),
+	})
+	client := stripe.NewClient("sk_test_stand_in", stripe.WithBackends(backends))
+
+	return standIn, NewStripeUtility(client, "")
+}
+
+func (s *stripeStandIn) request(path string) url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func TestCreatePayableItemDestinationCharge(t *testing.T) {
+	standIn, sc := newStripeStandIn(t)
+
+	lineItems := []model.PayableLineItem{
+		{Name: "Hook Up Fee", Amount: 7500, Quantity: 1},
+		{Name: "Per Mile Amount (10 miles at $2.50 per mile)", Amount: 2500, Quantity: 1},
+	}
+
+	sessionId, checkoutURL, err := sc.CreatePayableItem(context.Background(), "t1", 10000, lineItems, "acct_company", 500, "https://tows.example.com/paid?towId=t1&session_id={CHECKOUT_SESSION_ID}", "https://tows.example.com/cancelled")
+	if err != nil {
+		t.Fatalf("CreatePayableItem() error = %v", err)
+	}
+	if sessionId != "cs_test_1" || checkoutURL != "https://checkout.stripe.com/c/pay/cs_test_1" {
+		t.Errorf("CreatePayableItem() = %q, %q", sessionId, checkoutURL)
+	}
+
+	form := standIn.request("/v1/checkout/sessions")
+	want := map[string]string{
+		"payment_intent_data[transfer_data][destination]": "acct_company",
+		"payment_intent_data[on_behalf_of]":               "acct_company",
+		"payment_intent_data[application_fee_amount]":     "500",
+		"payment_intent_data[transfer_group]":             "t1",
+		"payment_intent_data[metadata][towId]":            "t1",
+		"line_items[0][price_data][unit_amount]":          "7500",
+		"line_items[1][price_data][unit_amount]":          "2500",
+		"success_url":                                     "https://tows.example.com/paid?towId=t1&session_id={CHECKOUT_SESSION_ID}",
+		"cancel_url":                                      "https://tows.example.com/cancelled",
+	}
+	for key, value := range want {
+		if got := form.Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if form.Has("discounts[0][coupon]") {
+		t.Errorf("unexpected discount on a checkout without negative line items")
+	}
+}
+
+func TestCreatePayableItemWithoutFeeAndWithDiscount(t *testing.T) {
+	standIn, sc := newStripeStandIn(t)
+
+	lineItems := []model.PayableLineItem{
+		{Name: "Hook Up Fee", Amount: 7500, Quantity: 1},
+		{Name: "Promo SAVE10", Amount: -750, Quantity: 1},
+	}
+
+	if _, _, err := sc.CreatePayableItem(context.Background(), "t2", 6750, lineItems, "acct_company", 0, "https://tows.example.com/paid", "https://tows.example.com/cancelled"); err != nil {
+		t.Fatalf("CreatePayableItem() error = %v", err)
+	}
+
+	form := standIn.request("/v1/checkout/sessions")
+	if form.Has("payment_intent_data[application_fee_amount]") {
+		t.Errorf("application fee sent for a zero fee")
+	}
+	if got := form.Get("payment_intent_data[transfer_data][destination]"); got != "acct_company" {
+		t.Errorf("destination = %q, want acct_company", got)
+	}
+	if got := form.Get("discounts[0][coupon]"); got != "coupon_test_1" {
+		t.Errorf("discount coupon = %q, want coupon_test_1", got)
+	}
+	if got := standIn.request("/v1/coupons").Get("amount_off"); got != "750" {
+		t.Errorf("coupon amount_off = %q, want 750", got)
+	}
+}
+
+func TestCreatePayableItemDeletesCouponWhenCheckoutFails(t *testing.T) {
+	standIn, sc := newStripeStandIn(t)
+	standIn.failCheckout = true
+
+	lineItems := []model.PayableLineItem{
+		{Name: "Hook Up Fee", Amount: 7500, Quantity: 1},
+		{Name: "Promo SAVE10", Amount: -750, Quantity: 1},
+	}
+
+	if _, _, err := sc.CreatePayableItem(context.Background(), "t2", 6750, lineItems, "acct_company", 0, "https://tows.example.com/paid", "https://tows.example.com/cancelled"); err == nil {
+		t.Fatalf("CreatePayableItem() expected an error")
+	}
+
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+	if len(standIn.deleted) != 1 || standIn.deleted[0] != "/v1/coupons/coupon_test_1" {
+		t.Errorf("deleted = %v, want the discount coupon", standIn.deleted)
+	}
+}
+
+func TestCreatePayableItemValidation(t *testing.T) {
+	_, sc := newStripeStandIn(t)
+	lineItems := []model.PayableLineItem{{Name: "Hook Up Fee", Amount: 7500, Quantity: 1}}
+
+	tests := []struct {
+		name        string
+		destination string
+		fee         int64
+		successURL  string
+	}{
+		{name: "missing destination account", destination: "", fee: 0, successURL: "https://tows.example.com/paid"},
+		{name: "negative fee", destination: "acct_company", fee: -1, successURL: "https://tows.example.com/paid"},
+		{name: "fee above the total", destination: "acct_company", fee: 7501, successURL: "https://tows.example.com/paid"},
+		{name: "missing success url", destination: "acct_company", fee: 0, successURL: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := sc.CreatePayableItem(context.Background(), "t3", 7500, lineItems, tt.destination, tt.fee, tt.successURL, "https://tows.example.com/cancelled"); err == nil {
+				t.Errorf("CreatePayableItem() expected an error")
+			}
+		})
+	}
+}
+
+func TestValidatePayableItemLineItemsAddUpToTotal(t *testing.T) {
+	lineItems := []model.PayableLineItem{
+		{Name: "Hook Up Fee", Amount: 7500, Quantity: 1},
+		{Name: "Promo SAVE10", Amount: -750, Quantity: 1},
+	}
+
+	if err := ValidatePayableItem("t4", 6750, lineItems, "acct_company", 0, "https://tows.example.com/paid", "https://tows.example.com/cancelled"); err != nil {
+		t.Errorf("ValidatePayableItem() error = %v", err)
+	}
+	if err := ValidatePayableItem("t4", 8000, lineItems, "acct_company", 0, "https://tows.example.com/paid", "https://tows.example.com/cancelled"); err == nil {
+		t.Errorf("ValidatePayableItem() accepted line items that do not add up to the total")
+	}
+}
+
+func TestCreateRefundReversesTransfer(t *testing.T) {
+	standIn, sc := newStripeStandIn(t)
+
+	r, err := sc.CreateRefund(context.Background(), "pi_test_1", 2500, "requested_by_customer", map[string]string{"towId": "t1", "issuedBy": "u1"})
+	if err != nil {
+		t.Fatalf("CreateRefund() error = %v", err)
+	}
+	if r.ID != "re_test_1" {
+		t.Errorf("CreateRefund() id = %q, want re_test_1", r.ID)
+	}
+
+	form := standIn.request("/v1/refunds")
+	want := map[string]string{
+		"payment_intent":         "pi_test_1",
+		"amount":                 "2500",
+		"reverse_transfer":       "true",
+		"refund_application_fee": "true",
+		"reason":                 "requested_by_customer",
+		"metadata[towId]":        "t1",
+		"metadata[issuedBy]":     "u1",
+	}
+	for key, value := range want {
+		if got := form.Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestCreateRefundKeepsFreeTextReasonOutOfStripeReason(t *testing.T) {
+	standIn, sc := newStripeStandIn(t)
+
+	if _, err := sc.CreateRefund(context.Background(), "pi_test_1", 2500, "vehicle was damaged", map[string]string{"reason": "vehicle was damaged"}); err != nil {
+		t.Fatalf("CreateRefund() error = %v", err)
+	}
+
+	form := standIn.request("/v1/refunds")
+	if form.Has("reason") {
+		t.Errorf("reason = %q, want it omitted", form.Get("reason"))
+	}
+	if got := form.Get("metadata[reason]"); got != "vehicle was damaged" {
+		t.Errorf("metadata[reason] = %q", got)
+	}
+}